@@ -0,0 +1,309 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"gin-web/config"
+	"sync"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultPublishBuffer     = 1000
+	defaultPublishTimeout    = 30 * time.Second
+	defaultReconnectInterval = 5 * time.Second
+	// 未配置发布超时时至少等待的重连次数，保证短暂断线期间缓冲的消息能在重连后发出
+	publishTimeoutReconnects = 3
+)
+
+// AmqpBroker RabbitMQ 实现，连接在首次使用时建立，断开后自动重新建立
+// 发布统一交给常驻的 publisher 协程，使用 confirm 模式，断线期间消息暂存在有界缓冲中
+type AmqpBroker struct {
+	url               string
+	publishTimeout    time.Duration
+	reconnectInterval time.Duration
+	logger            *zap.Logger
+
+	mu       sync.Mutex
+	conn     *amqp091.Connection
+	getCh    *amqp091.Channel // 拉取死信等少量操作使用的 channel
+	declared map[string]bool  // getCh 上已声明的队列
+	closed   bool
+
+	publisher     *amqpPublisher
+	publisherOnce sync.Once
+	done          chan struct{}
+}
+
+// NewAmqpBroker 日志由调用方传入（通常为 global.App.Log），broker 包不能依赖 global
+func NewAmqpBroker(cfg config.RabbitMQ, logger *zap.Logger) *AmqpBroker {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	publishBuffer := cfg.PublishBuffer
+	if publishBuffer <= 0 {
+		publishBuffer = defaultPublishBuffer
+	}
+	reconnectInterval := time.Duration(cfg.ReconnectInterval) * time.Second
+	if reconnectInterval <= 0 {
+		reconnectInterval = defaultReconnectInterval
+	}
+	publishTimeout := time.Duration(cfg.PublishTimeout) * time.Second
+	if publishTimeout <= 0 {
+		publishTimeout = defaultPublishTimeout
+		if minTimeout := publishTimeoutReconnects * reconnectInterval; publishTimeout < minTimeout {
+			publishTimeout = minTimeout
+		}
+	}
+
+	b := &AmqpBroker{
+		url: fmt.Sprintf("amqp://%s:%s@%s:%d/%s",
+			cfg.Username,
+			cfg.Password,
+			cfg.Host,
+			cfg.Port,
+			cfg.Vhost,
+		),
+		publishTimeout:    publishTimeout,
+		reconnectInterval: reconnectInterval,
+		logger:            logger,
+		declared:          map[string]bool{},
+		done:              make(chan struct{}),
+	}
+	b.publisher = newAmqpPublisher(b, publishBuffer)
+	return b
+}
+
+// connection 获取可用连接，调用方需持有锁
+func (b *AmqpBroker) connection() (*amqp091.Connection, error) {
+	if b.closed {
+		return nil, ErrClosed
+	}
+	if b.conn != nil && !b.conn.IsClosed() {
+		return b.conn, nil
+	}
+	conn, err := amqp091.Dial(b.url)
+	if err != nil {
+		return nil, err
+	}
+	b.conn = conn
+	b.getCh = nil
+	b.declared = map[string]bool{}
+	return conn, nil
+}
+
+// openChannel 在当前连接上打开新 channel，连接断开时重新连接
+func (b *AmqpBroker) openChannel() (*amqp091.Channel, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	conn, err := b.connection()
+	if err != nil {
+		return nil, err
+	}
+	return conn.Channel()
+}
+
+// declareQueue 声明持久化队列
+func declareQueue(ch *amqp091.Channel, queue string) error {
+	_, err := ch.QueueDeclare(
+		queue,
+		true,  // durable
+		false, // autoDelete
+		false, // exclusive
+		false, // noWait
+		nil,   // args
+	)
+	return err
+}
+
+// declareDelayQueue 声明延迟队列：消息在其中等待 TTL 到期后经默认交换机死信路由回目标队列
+// 同一目标队列、同一延迟时间共用一个延迟队列，长时间未使用时自动删除
+func declareDelayQueue(ch *amqp091.Channel, name string, queue string, delay time.Duration) error {
+	ttl := delay.Milliseconds()
+	_, err := ch.QueueDeclare(
+		name,
+		true,  // durable
+		false, // autoDelete
+		false, // exclusive
+		false, // noWait
+		amqp091.Table{
+			"x-message-ttl":             ttl,
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": queue,
+			"x-expires":                 ttl*2 + int64(time.Hour/time.Millisecond),
+		},
+	)
+	return err
+}
+
+// getChannel 获取 Get 使用的 channel，调用方需持有锁
+func (b *AmqpBroker) getChannel() (*amqp091.Channel, error) {
+	conn, err := b.connection()
+	if err != nil {
+		return nil, err
+	}
+	if b.getCh == nil || b.getCh.IsClosed() {
+		if b.getCh, err = conn.Channel(); err != nil {
+			return nil, err
+		}
+		b.declared = map[string]bool{}
+	}
+	return b.getCh, nil
+}
+
+// Publish 发布消息，收到 broker 确认后返回；被 broker 拒绝或超时返回错误
+// 超时以 ctx 和 publish_timeout 中较早者为准，超时后消息仍可能在重连后发出（至少一次）
+func (b *AmqpBroker) Publish(ctx context.Context, queue string, msg Message) error {
+	return b.PublishDelayed(ctx, queue, msg, 0)
+}
+
+func (b *AmqpBroker) PublishDelayed(ctx context.Context, queue string, msg Message, delay time.Duration) error {
+	b.publisherOnce.Do(func() {
+		go b.publisher.run()
+	})
+	ctx, cancel := context.WithTimeout(ctx, b.publishTimeout)
+	defer cancel()
+	return b.publisher.publish(ctx, queue, msg, delay)
+}
+
+func (b *AmqpBroker) Get(ctx context.Context, queue string) (*Message, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch, err := b.getChannel()
+	if err != nil {
+		return nil, err
+	}
+	if !b.declared[queue] {
+		if err = declareQueue(ch, queue); err != nil {
+			return nil, err
+		}
+		b.declared[queue] = true
+	}
+	d, ok, err := ch.Get(queue, false)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return toMessage(queue, d), nil
+}
+
+func (b *AmqpBroker) Subscribe(ctx context.Context, queue string, opts SubscribeOptions) (<-chan *Message, error) {
+	// 每个订阅独占一个 channel，确认消息时必须使用投递它的 channel
+	ch, err := b.openChannel()
+	if err != nil {
+		return nil, err
+	}
+	if opts.Prefetch > 0 {
+		if err = ch.Qos(opts.Prefetch, 0, false); err != nil {
+			ch.Close()
+			return nil, err
+		}
+	}
+	if err = declareQueue(ch, queue); err != nil {
+		ch.Close()
+		return nil, err
+	}
+	deliveries, err := ch.Consume(
+		queue,
+		"",    // consumer
+		false, // autoAck
+		false, // exclusive
+		false, // noLocal
+		false, // noWait
+		nil,   // args
+	)
+	if err != nil {
+		ch.Close()
+		return nil, err
+	}
+
+	out := make(chan *Message)
+	go func() {
+		defer close(out)
+		defer ch.Close()
+		for {
+			select {
+			case d, ok := <-deliveries:
+				if !ok {
+					return
+				}
+				select {
+				case out <- toMessage(queue, d):
+				case <-ctx.Done():
+					// 未交给处理方的消息退回队列
+					d.Nack(false, true)
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// toMessage 转换为与中间件无关的消息
+func toMessage(queue string, d amqp091.Delivery) *Message {
+	return &Message{
+		ID:          d.MessageId,
+		ContentType: d.ContentType,
+		Headers:     map[string]interface{}(d.Headers),
+		Body:        d.Body,
+		Timestamp:   d.Timestamp,
+		Queue:       queue,
+		Redelivered: d.Redelivered,
+		acker:       &amqpAcker{delivery: d},
+	}
+}
+
+func (b *AmqpBroker) Ack(msg *Message) error {
+	return ack(msg)
+}
+
+func (b *AmqpBroker) Nack(msg *Message, requeue bool) error {
+	return nack(msg, requeue)
+}
+
+func (b *AmqpBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	close(b.done)
+	if b.conn != nil && !b.conn.IsClosed() {
+		return b.conn.Close()
+	}
+	return nil
+}
+
+// amqpAcker 通过投递消息的 channel 确认
+type amqpAcker struct {
+	delivery amqp091.Delivery
+	mu       sync.Mutex
+	done     bool
+}
+
+func (a *amqpAcker) ack() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.done {
+		return ErrAcknowledged
+	}
+	a.done = true
+	return a.delivery.Ack(false)
+}
+
+func (a *amqpAcker) nack(requeue bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.done {
+		return ErrAcknowledged
+	}
+	a.done = true
+	return a.delivery.Nack(false, requeue)
+}
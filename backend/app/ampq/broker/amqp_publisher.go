@@ -0,0 +1,245 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrPublishNacked     = errors.New("message rejected by broker")
+	ErrPublishBufferFull = errors.New("publish buffer full, broker unavailable")
+)
+
+// publishRequest 一次待确认的发布
+type publishRequest struct {
+	ctx    context.Context
+	queue  string
+	msg    Message
+	delay  time.Duration
+	result chan error // 缓冲为 1，发布方超时离开后协程写入也不会阻塞
+}
+
+// amqpPublisher 常驻发布协程：维护 confirm 模式的 channel，断线时等待重连，
+// 期间新消息进入有界缓冲，已发出但未确认的消息在重连后重新发布
+type amqpPublisher struct {
+	broker   *AmqpBroker
+	requests chan *publishRequest
+	wake     chan struct{} // 有消息放入 retry 时通知 serve，避免其阻塞在 requests 上
+
+	mu    sync.Mutex
+	retry []*publishRequest // 因断线需要重新发布的消息，优先于缓冲中的新消息
+}
+
+func newAmqpPublisher(b *AmqpBroker, buffer int) *amqpPublisher {
+	return &amqpPublisher{
+		broker:   b,
+		requests: make(chan *publishRequest, buffer),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// publish 放入缓冲并等待确认结果
+func (p *amqpPublisher) publish(ctx context.Context, queue string, msg Message, delay time.Duration) error {
+	select {
+	case <-p.broker.done:
+		return ErrClosed
+	default:
+	}
+
+	msg.Headers = copyHeaders(msg.Headers)
+	req := &publishRequest{ctx: ctx, queue: queue, msg: msg, delay: delay, result: make(chan error, 1)}
+	select {
+	case p.requests <- req:
+	default:
+		return ErrPublishBufferFull
+	}
+
+	select {
+	case err := <-req.result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run 连接断开后按间隔重连，直到 broker 关闭
+func (p *amqpPublisher) run() {
+	for {
+		ch, err := p.open()
+		if err == nil {
+			p.serve(ch)
+		} else if !errors.Is(err, ErrClosed) {
+			p.broker.logger.Warn("amqp publisher connect failed",
+				zap.Duration("reconnect_in", p.broker.reconnectInterval), zap.Error(err))
+		}
+
+		select {
+		case <-p.broker.done:
+			p.failAll(ErrClosed)
+			return
+		default:
+		}
+		if err != nil {
+			select {
+			case <-p.broker.done:
+				p.failAll(ErrClosed)
+				return
+			case <-time.After(p.broker.reconnectInterval):
+			}
+		}
+	}
+}
+
+// open 打开 confirm 模式的 channel
+func (p *amqpPublisher) open() (*amqp091.Channel, error) {
+	ch, err := p.broker.openChannel()
+	if err != nil {
+		return nil, err
+	}
+	if err = ch.Confirm(false); err != nil {
+		ch.Close()
+		return nil, err
+	}
+	return ch, nil
+}
+
+// serve 在一个 channel 上持续发布，channel 关闭或发布出错时返回
+func (p *amqpPublisher) serve(ch *amqp091.Channel) {
+	defer ch.Close()
+	closed := ch.NotifyClose(make(chan *amqp091.Error, 1))
+	declared := map[string]bool{}
+
+	for {
+		req := p.popRetry()
+		if req == nil {
+			select {
+			case <-p.broker.done:
+				return
+			case err := <-closed:
+				p.broker.logger.Warn("amqp publisher channel closed", zap.Error(err))
+				return
+			case <-p.wake:
+				continue
+			case req = <-p.requests:
+			}
+		}
+
+		// 发布方已超时离开，不再发送
+		if req.ctx.Err() != nil {
+			req.result <- req.ctx.Err()
+			continue
+		}
+
+		confirm, err := p.send(ch, declared, req)
+		if err != nil {
+			p.broker.logger.Error("amqp publish failed, will retry after reconnect",
+				zap.String("queue", req.queue), zap.String("message_id", req.msg.ID), zap.Error(err))
+			p.pushRetry(req)
+			return
+		}
+		go p.waitConfirm(ch, req, confirm)
+	}
+}
+
+// send 声明所需队列后发布
+func (p *amqpPublisher) send(ch *amqp091.Channel, declared map[string]bool, req *publishRequest) (*amqp091.DeferredConfirmation, error) {
+	// 目标队列需先存在，否则延迟到期的消息会被丢弃
+	if !declared[req.queue] {
+		if err := declareQueue(ch, req.queue); err != nil {
+			return nil, err
+		}
+		declared[req.queue] = true
+	}
+	routingKey := req.queue
+	if req.delay > 0 {
+		routingKey = req.queue + ".delay." + strconv.FormatInt(req.delay.Milliseconds(), 10)
+		if !declared[routingKey] {
+			if err := declareDelayQueue(ch, routingKey, req.queue, req.delay); err != nil {
+				return nil, err
+			}
+			declared[routingKey] = true
+		}
+	}
+
+	contentType := req.msg.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	return ch.PublishWithDeferredConfirmWithContext(
+		req.ctx,
+		"",         // exchange
+		routingKey, // routing key
+		false,      // mandatory
+		false,      // immediate
+		amqp091.Publishing{
+			MessageId:    req.msg.ID,
+			ContentType:  contentType,
+			Headers:      amqp091.Table(req.msg.Headers),
+			Body:         req.msg.Body,
+			Timestamp:    req.msg.Timestamp,
+			DeliveryMode: amqp091.Persistent,
+		},
+	)
+}
+
+// waitConfirm 等待 broker 确认；channel 断开导致的未确认消息重新发布，broker 明确拒绝时返回错误
+func (p *amqpPublisher) waitConfirm(ch *amqp091.Channel, req *publishRequest, confirm *amqp091.DeferredConfirmation) {
+	select {
+	case <-confirm.Done():
+	case <-req.ctx.Done():
+		// 发布方已超时离开，结果无人接收
+		return
+	}
+	if confirm.Acked() {
+		req.result <- nil
+		return
+	}
+	if ch.IsClosed() {
+		p.pushRetry(req)
+		return
+	}
+	req.result <- ErrPublishNacked
+}
+
+func (p *amqpPublisher) pushRetry(req *publishRequest) {
+	p.mu.Lock()
+	p.retry = append(p.retry, req)
+	p.mu.Unlock()
+
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (p *amqpPublisher) popRetry() *publishRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.retry) == 0 {
+		return nil
+	}
+	req := p.retry[0]
+	p.retry = p.retry[1:]
+	return req
+}
+
+// failAll broker 关闭时结束全部等待中的发布
+func (p *amqpPublisher) failAll(err error) {
+	for req := p.popRetry(); req != nil; req = p.popRetry() {
+		req.result <- err
+	}
+	for {
+		select {
+		case req := <-p.requests:
+			req.result <- err
+		default:
+			return
+		}
+	}
+}
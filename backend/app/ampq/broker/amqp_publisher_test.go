@@ -0,0 +1,43 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"gin-web/config"
+	"net"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestAmqpPublisherLogsConnectFailure(t *testing.T) {
+	// 占用一个端口后立即释放，保证连接被拒绝
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	core, logs := observer.New(zap.WarnLevel)
+	b := NewAmqpBroker(config.RabbitMQ{Host: "127.0.0.1", Port: port, ReconnectInterval: 1}, zap.New(core))
+	defer b.Close()
+
+	// 断线期间消息留在缓冲中，直到发布超时
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err = b.Publish(ctx, testQueue, Message{ID: "1"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want DeadlineExceeded", err)
+	}
+
+	entries := logs.FilterMessage("amqp publisher connect failed").All()
+	if len(entries) == 0 {
+		t.Fatal("connect failure not logged")
+	}
+	fields := entries[0].ContextMap()
+	if fields["error"] == nil || fields["reconnect_in"] != time.Second {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+}
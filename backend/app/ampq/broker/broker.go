@@ -0,0 +1,163 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// 消费失败重试和死信相关的消息头
+const (
+	HeaderRetryCount    = "x-retry-count"    // 已重试次数
+	HeaderOriginalQueue = "x-original-queue" // 进入死信队列前所在的队列
+	HeaderLastError     = "x-last-error"     // 最后一次处理失败的原因
+	HeaderFailedAt      = "x-failed-at"      // 进入死信队列的时间（unix 秒）
+)
+
+// HeaderSchemaVersion 消息体的 schema 版本，缺省视为 1
+const HeaderSchemaVersion = "x-schema-version"
+
+// DeadLetterQueue 队列对应的死信队列名称
+func DeadLetterQueue(queue string) string {
+	return queue + ".dlq"
+}
+
+// RetryCount 消息已重试次数
+func (m *Message) RetryCount() int {
+	return headerInt(m.Headers, HeaderRetryCount)
+}
+
+// SchemaVersion 消息体的 schema 版本，未携带版本头的消息视为版本 1
+func (m *Message) SchemaVersion() int {
+	if version := headerInt(m.Headers, HeaderSchemaVersion); version > 0 {
+		return version
+	}
+	return 1
+}
+
+// headerInt 读取整数消息头，AMQP 传输后数值类型可能变化
+func headerInt(headers map[string]interface{}, key string) int {
+	switch v := headers[key].(type) {
+	case int:
+		return v
+	case int8:
+		return int(v)
+	case int16:
+		return int(v)
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case uint8:
+		return int(v)
+	case uint16:
+		return int(v)
+	case uint32:
+		return int(v)
+	case float64:
+		return int(v)
+	case string:
+		n, _ := strconv.Atoi(v)
+		return n
+	default:
+		return 0
+	}
+}
+
+// HeaderString 读取字符串消息头
+func (m *Message) HeaderString(key string) string {
+	switch v := m.Headers[key].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// HeaderInt 读取整数消息头
+func (m *Message) HeaderInt(key string) int {
+	return headerInt(m.Headers, key)
+}
+
+var (
+	ErrClosed       = errors.New("broker closed")
+	ErrAcknowledged = errors.New("message already acknowledged")
+	ErrNotDelivery  = errors.New("message is not a delivery")
+)
+
+// Message 与具体消息中间件无关的消息，发布时填写 Body 等字段，消费时由 Broker 补充投递信息
+type Message struct {
+	ID          string
+	ContentType string
+	Headers     map[string]interface{}
+	Body        []byte
+	Timestamp   time.Time
+
+	// 以下字段仅在消费到的消息中有效
+	Queue       string
+	Redelivered bool // 是否为重新投递（之前被 nack 重新入队或消费者断开）
+
+	acker acknowledger
+}
+
+// acknowledger 由各实现提供的确认方式，每条消息只能确认一次
+type acknowledger interface {
+	ack() error
+	nack(requeue bool) error
+}
+
+// SubscribeOptions 订阅参数
+type SubscribeOptions struct {
+	Prefetch int // 未确认消息上限，0 表示不限制
+}
+
+// Broker 消息中间件接口，目前有 AMQP（RabbitMQ）和进程内存两种实现
+type Broker interface {
+	// Publish 发布消息到队列
+	Publish(ctx context.Context, queue string, msg Message) error
+	// PublishDelayed 延迟 delay 后投递到队列，用于失败重试
+	PublishDelayed(ctx context.Context, queue string, msg Message, delay time.Duration) error
+	// Subscribe 订阅队列，ctx 取消或连接断开时关闭返回的 channel，调用方可重新订阅
+	// 收到的消息必须调用 Ack 或 Nack
+	Subscribe(ctx context.Context, queue string, opts SubscribeOptions) (<-chan *Message, error)
+	// Get 主动拉取一条消息，队列为空时返回 nil，用于查看和重放死信队列
+	Get(ctx context.Context, queue string) (*Message, error)
+	// Ack 确认消息已处理
+	Ack(msg *Message) error
+	// Nack 处理失败，requeue 为 true 时重新入队
+	Nack(msg *Message, requeue bool) error
+	// Close 关闭连接，之后的发布和订阅返回 ErrClosed
+	Close() error
+}
+
+func ack(msg *Message) error {
+	if msg == nil || msg.acker == nil {
+		return ErrNotDelivery
+	}
+	return msg.acker.ack()
+}
+
+func nack(msg *Message, requeue bool) error {
+	if msg == nil || msg.acker == nil {
+		return ErrNotDelivery
+	}
+	return msg.acker.nack(requeue)
+}
+
+// copyHeaders 复制消息头，避免发布方后续修改影响已发布的消息
+func copyHeaders(headers map[string]interface{}) map[string]interface{} {
+	if headers == nil {
+		return nil
+	}
+	copied := make(map[string]interface{}, len(headers))
+	for k, v := range headers {
+		copied[k] = v
+	}
+	return copied
+}
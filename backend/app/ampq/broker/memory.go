@@ -0,0 +1,240 @@
+package broker
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryBroker 进程内存实现，用于本地开发和测试 handler，不持久化、不跨进程
+type MemoryBroker struct {
+	mu     sync.Mutex
+	queues map[string]*memoryQueue
+	closed bool
+	done   chan struct{}
+}
+
+// memoryQueue 待投递的消息和唤醒等待中订阅者的信号
+type memoryQueue struct {
+	messages []*Message
+	// 有新消息或有消息被确认时关闭并替换，唤醒全部等待的订阅者
+	wake chan struct{}
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		queues: map[string]*memoryQueue{},
+		done:   make(chan struct{}),
+	}
+}
+
+// queue 获取队列，不存在时创建，调用方需持有锁
+func (b *MemoryBroker) queue(name string) *memoryQueue {
+	q, ok := b.queues[name]
+	if !ok {
+		q = &memoryQueue{wake: make(chan struct{})}
+		b.queues[name] = q
+	}
+	return q
+}
+
+// notify 唤醒队列的订阅者，调用方需持有锁
+func (q *memoryQueue) notify() {
+	close(q.wake)
+	q.wake = make(chan struct{})
+}
+
+func (b *MemoryBroker) Publish(ctx context.Context, queue string, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrClosed
+	}
+
+	if msg.Timestamp.IsZero() {
+		msg.Timestamp = time.Now()
+	}
+	msg.Headers = copyHeaders(msg.Headers)
+	msg.Body = append([]byte(nil), msg.Body...)
+	msg.Queue = queue
+	msg.Redelivered = false
+	msg.acker = nil
+
+	q := b.queue(queue)
+	q.messages = append(q.messages, &msg)
+	q.notify()
+	return nil
+}
+
+func (b *MemoryBroker) PublishDelayed(ctx context.Context, queue string, msg Message, delay time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	closed := b.closed
+	b.mu.Unlock()
+	if closed {
+		return ErrClosed
+	}
+	if delay <= 0 {
+		return b.Publish(ctx, queue, msg)
+	}
+	msg.Headers = copyHeaders(msg.Headers)
+	msg.Body = append([]byte(nil), msg.Body...)
+	time.AfterFunc(delay, func() {
+		b.Publish(context.Background(), queue, msg)
+	})
+	return nil
+}
+
+func (b *MemoryBroker) Get(ctx context.Context, queue string) (*Message, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+	q := b.queue(queue)
+	if len(q.messages) == 0 {
+		return nil, nil
+	}
+	published := q.messages[0]
+	q.messages = q.messages[1:]
+	sub := &memorySubscription{broker: b, queue: queue, unacked: 1}
+	delivery := *published
+	delivery.acker = &memoryAcker{sub: sub, message: published}
+	return &delivery, nil
+}
+
+func (b *MemoryBroker) Subscribe(ctx context.Context, queue string, opts SubscribeOptions) (<-chan *Message, error) {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil, ErrClosed
+	}
+	b.queue(queue)
+	b.mu.Unlock()
+
+	sub := &memorySubscription{broker: b, queue: queue, prefetch: opts.Prefetch}
+	out := make(chan *Message)
+	go sub.run(ctx, out)
+	return out, nil
+}
+
+func (b *MemoryBroker) Ack(msg *Message) error {
+	return ack(msg)
+}
+
+func (b *MemoryBroker) Nack(msg *Message, requeue bool) error {
+	return nack(msg, requeue)
+}
+
+func (b *MemoryBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.closed {
+		b.closed = true
+		close(b.done)
+	}
+	return nil
+}
+
+// Len 队列中待投递的消息数，不含已投递未确认的消息
+func (b *MemoryBroker) Len(queue string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if q, ok := b.queues[queue]; ok {
+		return len(q.messages)
+	}
+	return 0
+}
+
+// memorySubscription 一个订阅者，按 prefetch 限制未确认消息数
+type memorySubscription struct {
+	broker   *MemoryBroker
+	queue    string
+	prefetch int
+	unacked  int // 受 broker.mu 保护
+}
+
+func (s *memorySubscription) run(ctx context.Context, out chan<- *Message) {
+	defer close(out)
+	b := s.broker
+	for {
+		b.mu.Lock()
+		q := b.queue(s.queue)
+		if b.closed {
+			b.mu.Unlock()
+			return
+		}
+		if len(q.messages) == 0 || (s.prefetch > 0 && s.unacked >= s.prefetch) {
+			wake := q.wake
+			b.mu.Unlock()
+			select {
+			case <-wake:
+				continue
+			case <-ctx.Done():
+				return
+			case <-b.done:
+				return
+			}
+		}
+
+		published := q.messages[0]
+		q.messages = q.messages[1:]
+		s.unacked++
+		delivery := *published
+		delivery.acker = &memoryAcker{sub: s, message: published}
+		b.mu.Unlock()
+
+		select {
+		case out <- &delivery:
+		case <-ctx.Done():
+			// 未送达的消息放回队首
+			delivery.acker.nack(true)
+			return
+		case <-b.done:
+			return
+		}
+	}
+}
+
+// memoryAcker 确认或退回一条已投递的消息
+type memoryAcker struct {
+	sub     *memorySubscription
+	message *Message
+	done    bool
+}
+
+func (a *memoryAcker) ack() error {
+	return a.settle(false)
+}
+
+func (a *memoryAcker) nack(requeue bool) error {
+	return a.settle(requeue)
+}
+
+func (a *memoryAcker) settle(requeue bool) error {
+	b := a.sub.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if a.done {
+		return ErrAcknowledged
+	}
+	a.done = true
+	a.sub.unacked--
+
+	q := b.queue(a.sub.queue)
+	if requeue && !b.closed {
+		redelivered := *a.message
+		redelivered.Redelivered = true
+		q.messages = append([]*Message{&redelivered}, q.messages...)
+	}
+	q.notify()
+	return nil
+}
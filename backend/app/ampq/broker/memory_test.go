@@ -0,0 +1,187 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+const testQueue = "test"
+
+// receive 在超时前从订阅中取一条消息
+func receive(t *testing.T, msgs <-chan *Message) *Message {
+	t.Helper()
+	select {
+	case msg, ok := <-msgs:
+		if !ok {
+			t.Fatal("subscription closed")
+		}
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("no message received")
+	}
+	return nil
+}
+
+// expectNone 确认一段时间内没有投递消息
+func expectNone(t *testing.T, msgs <-chan *Message) {
+	t.Helper()
+	select {
+	case msg := <-msgs:
+		t.Fatalf("unexpected message %q", msg.ID)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func subscribe(t *testing.T, b *MemoryBroker, prefetch int) <-chan *Message {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	msgs, err := b.Subscribe(ctx, testQueue, SubscribeOptions{Prefetch: prefetch})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msgs
+}
+
+func TestMemoryBrokerAck(t *testing.T) {
+	b := NewMemoryBroker()
+	defer b.Close()
+
+	headers := map[string]interface{}{HeaderRetryCount: 1}
+	if err := b.Publish(context.Background(), testQueue, Message{ID: "1", Headers: headers, Body: []byte("hello")}); err != nil {
+		t.Fatal(err)
+	}
+	// 发布后修改不影响已发布的消息
+	headers[HeaderRetryCount] = 5
+
+	msg := receive(t, subscribe(t, b, 0))
+	if msg.ID != "1" || string(msg.Body) != "hello" || msg.Queue != testQueue || msg.Redelivered {
+		t.Fatalf("unexpected message %+v", msg)
+	}
+	if msg.RetryCount() != 1 {
+		t.Fatalf("RetryCount() = %d, want 1", msg.RetryCount())
+	}
+	if err := b.Ack(msg); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Ack(msg); !errors.Is(err, ErrAcknowledged) {
+		t.Fatalf("second ack: err = %v, want ErrAcknowledged", err)
+	}
+	if b.Len(testQueue) != 0 {
+		t.Fatalf("Len() = %d, want 0", b.Len(testQueue))
+	}
+}
+
+func TestMemoryBrokerNackRequeue(t *testing.T) {
+	b := NewMemoryBroker()
+	defer b.Close()
+	ctx := context.Background()
+	b.Publish(ctx, testQueue, Message{ID: "1"})
+	b.Publish(ctx, testQueue, Message{ID: "2"})
+
+	msgs := subscribe(t, b, 1)
+	first := receive(t, msgs)
+	if first.ID != "1" {
+		t.Fatalf("first message = %q, want 1", first.ID)
+	}
+	if err := b.Nack(first, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新入队的消息放回队首并标记为重新投递
+	redelivered := receive(t, msgs)
+	if redelivered.ID != "1" || !redelivered.Redelivered {
+		t.Fatalf("unexpected redelivery %+v", redelivered)
+	}
+	b.Ack(redelivered)
+	if second := receive(t, msgs); second.ID != "2" || second.Redelivered {
+		t.Fatalf("unexpected message %+v", second)
+	}
+}
+
+func TestMemoryBrokerNackDiscard(t *testing.T) {
+	b := NewMemoryBroker()
+	defer b.Close()
+	b.Publish(context.Background(), testQueue, Message{ID: "1"})
+
+	msgs := subscribe(t, b, 0)
+	if err := b.Nack(receive(t, msgs), false); err != nil {
+		t.Fatal(err)
+	}
+	expectNone(t, msgs)
+	if b.Len(testQueue) != 0 {
+		t.Fatalf("Len() = %d, want 0", b.Len(testQueue))
+	}
+}
+
+func TestMemoryBrokerPrefetch(t *testing.T) {
+	b := NewMemoryBroker()
+	defer b.Close()
+	ctx := context.Background()
+	b.Publish(ctx, testQueue, Message{ID: "1"})
+	b.Publish(ctx, testQueue, Message{ID: "2"})
+
+	msgs := subscribe(t, b, 1)
+	first := receive(t, msgs)
+	// 未确认消息达到 prefetch 时不再投递
+	expectNone(t, msgs)
+	b.Ack(first)
+	if second := receive(t, msgs); second.ID != "2" {
+		t.Fatalf("second message = %q, want 2", second.ID)
+	}
+}
+
+func TestMemoryBrokerPublishDelayed(t *testing.T) {
+	b := NewMemoryBroker()
+	defer b.Close()
+
+	if err := b.PublishDelayed(context.Background(), testQueue, Message{ID: "1"}, 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if b.Len(testQueue) != 0 {
+		t.Fatal("delayed message delivered immediately")
+	}
+	if msg := receive(t, subscribe(t, b, 0)); msg.ID != "1" {
+		t.Fatalf("message = %q, want 1", msg.ID)
+	}
+}
+
+func TestMemoryBrokerGet(t *testing.T) {
+	b := NewMemoryBroker()
+	defer b.Close()
+	ctx := context.Background()
+
+	msg, err := b.Get(ctx, testQueue)
+	if err != nil || msg != nil {
+		t.Fatalf("empty queue: msg = %v, err = %v", msg, err)
+	}
+
+	b.Publish(ctx, testQueue, Message{ID: "1"})
+	if msg, err = b.Get(ctx, testQueue); err != nil || msg == nil || msg.ID != "1" {
+		t.Fatalf("msg = %v, err = %v", msg, err)
+	}
+	b.Nack(msg, true)
+	if b.Len(testQueue) != 1 {
+		t.Fatalf("Len() = %d, want 1", b.Len(testQueue))
+	}
+}
+
+func TestMemoryBrokerClose(t *testing.T) {
+	b := NewMemoryBroker()
+	msgs := subscribe(t, b, 0)
+	b.Close()
+
+	select {
+	case _, ok := <-msgs:
+		if ok {
+			t.Fatal("unexpected message after close")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscription not closed")
+	}
+	if err := b.Publish(context.Background(), testQueue, Message{ID: "1"}); !errors.Is(err, ErrClosed) {
+		t.Fatalf("publish after close: err = %v, want ErrClosed", err)
+	}
+}
@@ -1,7 +1,19 @@
 package consumer
 
-import "github.com/streadway/amqp"
+import (
+	"context"
+	"gin-web/app/ampq/broker"
+)
 
+// ConsumerHandler 消费者处理器，返回错误时按配置延迟重试，返回 ErrUndecodable 时直接转入死信队列
+// ctx 带有单条消息的处理超时和链路追踪信息，耗时操作应在 ctx 结束时尽快返回
 type ConsumerHandler interface {
-	HandleMessage(msg amqp.Delivery) error
+	HandleMessage(ctx context.Context, msg *broker.Message) error
+}
+
+// HandlerFunc 函数形式的 ConsumerHandler
+type HandlerFunc func(ctx context.Context, msg *broker.Message) error
+
+func (f HandlerFunc) HandleMessage(ctx context.Context, msg *broker.Message) error {
+	return f(ctx, msg)
 }
@@ -0,0 +1,130 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"gin-web/app/ampq/broker"
+	"gin-web/global"
+	"gin-web/utils"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+// ErrMessageInProgress 同一消息正由其他消费者处理，延迟后重新投递，不计入重试次数
+var ErrMessageInProgress = errors.New("message is being processed by another consumer")
+
+const (
+	idempotencyDone       = "done"
+	idempotencyProcessing = "processing:"
+)
+
+// acquireIdempotencyLuaScript 未处理过时写入处理中标记并返回空串，否则返回已有状态
+const acquireIdempotencyLuaScript = `
+local state = redis.call("GET", KEYS[1])
+if state then
+    return state
+end
+redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+return ""
+`
+
+// finishIdempotencyLuaScript 仍持有处理中标记时：ARGV[2] 为空则删除标记，否则标记为已处理
+const finishIdempotencyLuaScript = `
+if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+    return 0
+end
+if ARGV[2] == "" then
+    return redis.call("DEL", KEYS[1])
+end
+redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+return 1
+`
+
+var (
+	acquireIdempotencyScript = redis.NewScript(acquireIdempotencyLuaScript)
+	finishIdempotencyScript  = redis.NewScript(finishIdempotencyLuaScript)
+)
+
+// IdempotencyKeyer 由 handler 根据消息内容给出幂等 key，未实现时使用消息 ID
+type IdempotencyKeyer interface {
+	IdempotencyKey(msg *broker.Message) (string, error)
+}
+
+// IdempotencyOptions 幂等中间件配置
+type IdempotencyOptions struct {
+	TTL           time.Duration // 已处理记录的保留时长，超出后同一消息会再次处理
+	ProcessingTTL time.Duration // 处理中标记的有效期，需大于单条消息的处理超时，进程崩溃后标记到期即可重新处理
+	Key           func(msg *broker.Message) (string, error)
+}
+
+// Idempotency 按消息 ID 或 handler 定义的 key 在 Redis 中记录处理状态，跳过已处理的重复消息
+// 处理前写入处理中标记，其他消费者收到同一消息时返回 ErrMessageInProgress 延迟重试；处理失败时删除标记以便重试
+// 处理超时时 handler 可能仍在运行，保留标记避免重试与其并发处理；handler 之后结束时按结果标记已处理或删除标记，
+// 一直未结束时标记在 ProcessingTTL 到期后失效
+// 无法得到 key 的消息不做去重
+func Idempotency(opts IdempotencyOptions) Middleware {
+	if opts.Key == nil {
+		opts.Key = func(msg *broker.Message) (string, error) { return msg.ID, nil }
+	}
+	return func(next ConsumerHandler) ConsumerHandler {
+		return HandlerFunc(func(ctx context.Context, msg *broker.Message) error {
+			key, err := opts.Key(msg)
+			if err != nil || key == "" {
+				return next.HandleMessage(ctx, msg)
+			}
+			redisKey := getIdempotencyKey(msg.Queue, key)
+			token := idempotencyProcessing + utils.RandToken(16)
+
+			state, err := acquireIdempotencyScript.Run(ctx, global.App.Redis, []string{redisKey},
+				token, opts.ProcessingTTL.Milliseconds()).Text()
+			if err != nil {
+				return err
+			}
+			switch {
+			case state == idempotencyDone:
+				global.App.Log.Info("skip duplicate message",
+					zap.String("queue", msg.Queue), zap.String("message_id", msg.ID), zap.String("key", key))
+				return nil
+			case strings.HasPrefix(state, idempotencyProcessing):
+				return ErrMessageInProgress
+			}
+
+			finish := func(err error) {
+				finishIdempotency(msg, redisKey, token, err, opts.TTL)
+			}
+			err = next.HandleMessage(withLateResult(ctx, func(err error) {
+				finish(err)
+				global.App.Log.Warn("message handled after timeout",
+					zap.String("queue", msg.Queue), zap.String("message_id", msg.ID), zap.Error(err))
+			}), msg)
+			// 超时后 handler 仍在运行，由其结束时的回调处理标记
+			if errors.Is(err, ErrHandleTimeout) {
+				return err
+			}
+			finish(err)
+			return err
+		})
+	}
+}
+
+// finishIdempotency 仍持有处理中标记时，处理成功标记为已处理，失败删除标记以便重试
+// 标记失败时消息已处理完成，仍确认消息，重复投递时会再处理一次
+func finishIdempotency(msg *broker.Message, redisKey string, token string, err error, ttl time.Duration) {
+	if err != nil {
+		finishIdempotencyScript.Run(context.Background(), global.App.Redis, []string{redisKey}, token, "", 0)
+		return
+	}
+	if err := finishIdempotencyScript.Run(context.Background(), global.App.Redis, []string{redisKey},
+		token, idempotencyDone, ttl.Milliseconds()).Err(); err != nil {
+		global.App.Log.Error("mark message processed failed",
+			zap.String("queue", msg.Queue), zap.String("message_id", msg.ID), zap.Error(err))
+	}
+}
+
+// 消息处理状态 key
+func getIdempotencyKey(queue string, key string) string {
+	return "consumer_idempotency:" + queue + ":" + utils.MD5([]byte(key))
+}
@@ -0,0 +1,230 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"gin-web/app/ampq/broker"
+	"gin-web/global"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// setupRedis 使用 miniredis 作为全局 Redis
+func setupRedis(t *testing.T) *miniredis.Miniredis {
+	setupLog(t)
+	mr := miniredis.RunT(t)
+	previous := global.App.Redis
+	t.Cleanup(func() { global.App.Redis = previous })
+	global.App.Redis = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	return mr
+}
+
+var testIdempotencyOptions = IdempotencyOptions{TTL: time.Hour, ProcessingTTL: time.Minute}
+
+// observeLateFinish 记录日志，用于等待超时后 handler 结束时的回调完成
+func observeLateFinish() *observer.ObservedLogs {
+	core, logs := observer.New(zap.WarnLevel)
+	global.App.Log = zap.New(core)
+	return logs
+}
+
+// waitLateFinish 等待超时后结束的 handler 完成标记
+func waitLateFinish(t *testing.T, logs *observer.ObservedLogs) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for logs.FilterMessage("message handled after timeout").Len() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("late finish not reported")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestIdempotencySkipsProcessedMessage(t *testing.T) {
+	mr := setupRedis(t)
+	var calls int32
+	handler := Chain(HandlerFunc(func(ctx context.Context, msg *broker.Message) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}), Idempotency(testIdempotencyOptions))
+
+	msg := &broker.Message{ID: "1", Queue: "test"}
+	for i := 0; i < 2; i++ {
+		if err := handler.HandleMessage(context.Background(), msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("handled %d times, want 1", calls)
+	}
+
+	// 已处理记录到期后同一消息会再次处理
+	mr.FastForward(time.Hour)
+	handler.HandleMessage(context.Background(), msg)
+	if calls != 2 {
+		t.Fatalf("handled %d times after ttl, want 2", calls)
+	}
+}
+
+func TestIdempotencyReleasesOnFailure(t *testing.T) {
+	setupRedis(t)
+	failure := errors.New("failed")
+	var calls int32
+	handler := Chain(HandlerFunc(func(ctx context.Context, msg *broker.Message) error {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return failure
+		}
+		return nil
+	}), Idempotency(testIdempotencyOptions))
+
+	msg := &broker.Message{ID: "1", Queue: "test"}
+	if err := handler.HandleMessage(context.Background(), msg); !errors.Is(err, failure) {
+		t.Fatalf("err = %v, want failure", err)
+	}
+	// 失败后清除标记，重试可以立即处理
+	if err := handler.HandleMessage(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Fatalf("handled %d times, want 2", calls)
+	}
+}
+
+func TestIdempotencyInProgress(t *testing.T) {
+	setupRedis(t)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := Chain(HandlerFunc(func(ctx context.Context, msg *broker.Message) error {
+		close(started)
+		<-release
+		return nil
+	}), Idempotency(testIdempotencyOptions))
+
+	msg := &broker.Message{ID: "1", Queue: "test"}
+	done := make(chan error, 1)
+	go func() { done <- handler.HandleMessage(context.Background(), msg) }()
+	<-started
+
+	if err := handler.HandleMessage(context.Background(), msg); !errors.Is(err, ErrMessageInProgress) {
+		t.Fatalf("err = %v, want ErrMessageInProgress", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIdempotencyKeepsMarkerOnTimeout(t *testing.T) {
+	mr := setupRedis(t)
+	logs := observeLateFinish()
+	release := make(chan struct{})
+	var calls int32
+	// 与默认中间件顺序一致，timeout 在 idempotency 内层
+	handler := Chain(HandlerFunc(func(ctx context.Context, msg *broker.Message) error {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			// 忽略 ctx 取消，超时后仍在运行
+			<-release
+		}
+		return nil
+	}), Idempotency(testIdempotencyOptions), Timeout(20*time.Millisecond))
+
+	msg := &broker.Message{ID: "1", Queue: "test"}
+	if err := handler.HandleMessage(context.Background(), msg); !errors.Is(err, ErrHandleTimeout) {
+		t.Fatalf("err = %v, want ErrHandleTimeout", err)
+	}
+	// handler 仍在运行，重试不能并发处理
+	if err := handler.HandleMessage(context.Background(), msg); !errors.Is(err, ErrMessageInProgress) {
+		t.Fatalf("retry: err = %v, want ErrMessageInProgress", err)
+	}
+
+	// 处理中标记到期后可以重新处理
+	mr.FastForward(testIdempotencyOptions.ProcessingTTL)
+	if err := handler.HandleMessage(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Fatalf("handled %d times, want 2", calls)
+	}
+
+	// 标记到期后才结束的 handler 不会覆盖重试的处理结果
+	close(release)
+	waitLateFinish(t, logs)
+	state, _ := global.App.Redis.Get(context.Background(), getIdempotencyKey(msg.Queue, msg.ID)).Result()
+	if state != idempotencyDone {
+		t.Fatalf("state = %q, want done", state)
+	}
+}
+
+func TestIdempotencyLateFinish(t *testing.T) {
+	failure := errors.New("failed")
+	for _, c := range []struct {
+		name      string
+		result    error
+		wantCalls int32
+	}{
+		{"success", nil, 1},
+		{"failure", failure, 2},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			setupRedis(t)
+			logs := observeLateFinish()
+			release := make(chan struct{})
+			var calls int32
+			handler := Chain(HandlerFunc(func(ctx context.Context, msg *broker.Message) error {
+				if atomic.AddInt32(&calls, 1) == 1 {
+					<-release
+					return c.result
+				}
+				return nil
+			}), Idempotency(testIdempotencyOptions), Timeout(20*time.Millisecond))
+
+			msg := &broker.Message{ID: "1", Queue: "test"}
+			if err := handler.HandleMessage(context.Background(), msg); !errors.Is(err, ErrHandleTimeout) {
+				t.Fatalf("err = %v, want ErrHandleTimeout", err)
+			}
+			// 超时后 handler 结束，成功时标记已处理，失败时删除标记
+			close(release)
+			waitLateFinish(t, logs)
+			state, _ := global.App.Redis.Get(context.Background(), getIdempotencyKey(msg.Queue, msg.ID)).Result()
+			if (c.result == nil && state != idempotencyDone) || (c.result != nil && state != "") {
+				t.Fatalf("state = %q after late finish", state)
+			}
+
+			if err := handler.HandleMessage(context.Background(), msg); err != nil {
+				t.Fatal(err)
+			}
+			if calls != c.wantCalls {
+				t.Fatalf("handled %d times, want %d", calls, c.wantCalls)
+			}
+		})
+	}
+}
+
+func TestIdempotencyCustomKey(t *testing.T) {
+	setupRedis(t)
+	var calls int32
+	handler := NewTypedHandler(1, func(ctx context.Context, msg *broker.Message, payload testPayload) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}).Key(func(payload testPayload) string { return payload.Name })
+	opts := testIdempotencyOptions
+	opts.Key = handler.IdempotencyKey
+	wrapped := Chain(handler, Idempotency(opts))
+
+	// 消息 ID 不同但 key 相同，只处理一次
+	for _, id := range []string{"1", "2"} {
+		msg := &broker.Message{ID: id, Queue: "test", Body: []byte(`{"name":"alice"}`)}
+		if err := wrapped.HandleMessage(context.Background(), msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("handled %d times, want 1", calls)
+	}
+}
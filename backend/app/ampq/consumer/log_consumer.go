@@ -1,42 +1,28 @@
 package consumer
 
 import (
-	"encoding/json"
+	"context"
+	"gin-web/app/ampq/broker"
 	"gin-web/app/api"
-	"github.com/streadway/amqp"
-	"log"
 )
 
-type LogConsumer struct{}
+// LogMessageVersion 日志消息当前的 schema 版本
+const LogMessageVersion = 1
 
-func (c *LogConsumer) HandleMessage(msg amqp.Delivery) error {
-
-	//return nil
-	// 使用 defer + recover 捕获 panic 确保一定会ack处理
-	defer func() {
-		if r := recover(); r != nil {
-			log.Printf("Recovered from panic: %v", r)
-			//msg.Ack(false)
-		}
-	}()
-
-	log.Printf("Processing order: %s", msg.Body)
-	var raw map[string]interface{}
-	if err := json.Unmarshal(msg.Body, &raw); err != nil {
-		log.Printf("JSON解析失败: %v", err)
-		//msg.Ack(false)
-		return nil
-	}
+// LogMessage 日志消息体
+type LogMessage struct {
+	Data struct {
+		ReqID string `json:"req_id" binding:"required"`
+		Type  string `json:"type" binding:"required"`
+	} `json:"data"`
+}
 
-	// 提取 data 字段
-	data, ok := raw["data"].(map[string]interface{})
-	if !ok {
-		log.Println("data字段不存在或类型错误")
-		//msg.Ack(false)
-		return nil
-	}
+// NewLogConsumer 将日志消息写入表格存储，按消息 ID 去重，重复投递的同一条日志只写入一次
+func NewLogConsumer() ConsumerHandler {
+	return NewTypedHandler(LogMessageVersion, handleLog)
+}
 
-	//global.App.Log.Info(string(msg.Body))
+func handleLog(ctx context.Context, msg *broker.Message, payload LogMessage) error {
 	params := api.LogParams{
 		Data: struct {
 			ReqID    string `json:"req_id"`
@@ -46,8 +32,8 @@ func (c *LogConsumer) HandleMessage(msg amqp.Delivery) error {
 			Custom1  string `json:"custom1,omitempty"`
 			Custom2  string `json:"custom2,omitempty"`
 		}{
-			ReqID:    data["req_id"].(string),
-			Name:     data["type"].(string),
+			ReqID:    payload.Data.ReqID,
+			Name:     payload.Data.Type,
 			LogLevel: 10,
 			Detail:   string(msg.Body),
 			Custom1:  "test",
@@ -56,7 +42,5 @@ func (c *LogConsumer) HandleMessage(msg amqp.Delivery) error {
 		TableName: "eric_request_logs",
 	}
 	api.SendTableStoreLog(params)
-
-	//msg.Ack(false)
 	return nil
 }
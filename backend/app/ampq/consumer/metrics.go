@@ -0,0 +1,62 @@
+package consumer
+
+import (
+	"gin-web/app/common/response"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ConsumerMetrics 当前实例各队列的消息处理统计，由 Metrics 中间件记录
+var ConsumerMetrics = &metrics{queues: map[string]*queueMetrics{}}
+
+type metrics struct {
+	mu     sync.Mutex
+	queues map[string]*queueMetrics
+}
+
+type queueMetrics struct {
+	processed       int64
+	failed          int64
+	totalDuration   time.Duration
+	maxDuration     time.Duration
+	lastProcessedAt time.Time
+}
+
+func (m *metrics) observe(queue string, duration time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	q, ok := m.queues[queue]
+	if !ok {
+		q = &queueMetrics{}
+		m.queues[queue] = q
+	}
+	q.processed++
+	if err != nil {
+		q.failed++
+	}
+	q.totalDuration += duration
+	if duration > q.maxDuration {
+		q.maxDuration = duration
+	}
+	q.lastProcessedAt = time.Now()
+}
+
+// Snapshot 按队列名排序的统计快照
+func (m *metrics) Snapshot() []response.ConsumerMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := make([]response.ConsumerMetrics, 0, len(m.queues))
+	for queue, q := range m.queues {
+		result = append(result, response.ConsumerMetrics{
+			Queue:           queue,
+			Processed:       q.processed,
+			Failed:          q.failed,
+			AvgDurationMs:   float64(q.totalDuration) / float64(q.processed) / float64(time.Millisecond),
+			MaxDurationMs:   float64(q.maxDuration) / float64(time.Millisecond),
+			LastProcessedAt: q.lastProcessedAt,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Queue < result[j].Queue })
+	return result
+}
@@ -0,0 +1,186 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gin-web/app/ampq/broker"
+	"gin-web/global"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// 链路追踪相关的消息头
+const (
+	HeaderTraceParent = "traceparent" // W3C Trace Context
+	HeaderRequestID   = "x-request-id"
+)
+
+// Middleware 包装 ConsumerHandler，在处理前后执行通用逻辑
+type Middleware func(next ConsumerHandler) ConsumerHandler
+
+// Chain 按顺序组合中间件，第一个中间件在最外层
+func Chain(handler ConsumerHandler, middlewares ...Middleware) ConsumerHandler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// Recovery 捕获 handler 的 panic 并记录堆栈，消息按处理失败重试
+func Recovery() Middleware {
+	return func(next ConsumerHandler) ConsumerHandler {
+		return HandlerFunc(func(ctx context.Context, msg *broker.Message) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					stack := debug.Stack()
+					if p, ok := r.(*handlerPanic); ok {
+						r, stack = p.value, p.stack
+					}
+					global.App.Log.Error("consumer panic",
+						zap.String("queue", msg.Queue),
+						zap.String("message_id", msg.ID),
+						zap.String("trace_id", TraceID(ctx)),
+						zap.Any("err", r),
+						zap.ByteString("stack", stack))
+					err = fmt.Errorf("panic: %v", r)
+				}
+			}()
+			return next.HandleMessage(ctx, msg)
+		})
+	}
+}
+
+// Logging 记录每条消息的处理结果和耗时
+func Logging() Middleware {
+	return func(next ConsumerHandler) ConsumerHandler {
+		return HandlerFunc(func(ctx context.Context, msg *broker.Message) error {
+			start := time.Now()
+			err := next.HandleMessage(ctx, msg)
+			fields := []zap.Field{
+				zap.String("queue", msg.Queue),
+				zap.String("message_id", msg.ID),
+				zap.String("trace_id", TraceID(ctx)),
+				zap.Int("retry_count", msg.RetryCount()),
+				zap.Duration("duration", time.Since(start)),
+			}
+			if err != nil {
+				global.App.Log.Error("consume message failed", append(fields, zap.Error(err))...)
+			} else {
+				global.App.Log.Debug("consume message", fields...)
+			}
+			return err
+		})
+	}
+}
+
+// Metrics 按队列统计处理次数和耗时，见 ConsumerMetrics
+func Metrics() Middleware {
+	return func(next ConsumerHandler) ConsumerHandler {
+		return HandlerFunc(func(ctx context.Context, msg *broker.Message) error {
+			start := time.Now()
+			err := next.HandleMessage(ctx, msg)
+			ConsumerMetrics.observe(msg.Queue, time.Since(start), err)
+			return err
+		})
+	}
+}
+
+// ErrHandleTimeout 处理超时，此时 handler 协程可能仍在运行
+var ErrHandleTimeout = errors.New("handle message timeout")
+
+// handlerPanic 超时中间件中 handler 协程的 panic，转交给调用方的 Recovery 处理
+type handlerPanic struct {
+	value interface{}
+	stack []byte
+}
+
+type lateResultKey struct{}
+
+// withLateResult 注册处理超时后 handler 协程最终结束时的回调，由 Timeout 在协程结束后调用
+// 外层中间件（如 Idempotency）借此记录超时后才完成的处理结果
+func withLateResult(ctx context.Context, report func(err error)) context.Context {
+	return context.WithValue(ctx, lateResultKey{}, report)
+}
+
+// Timeout 限制单条消息的处理时长，超时后返回错误按失败重试
+// handler 在独立协程中执行，应在 ctx 结束时尽快返回，否则超时后仍会继续运行，结束后将结果交给 withLateResult 注册的回调
+func Timeout(timeout time.Duration) Middleware {
+	return func(next ConsumerHandler) ConsumerHandler {
+		return HandlerFunc(func(ctx context.Context, msg *broker.Message) error {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			done := make(chan error, 1)
+			panicked := make(chan *handlerPanic, 1)
+			go func() {
+				defer func() {
+					if r := recover(); r != nil {
+						panicked <- &handlerPanic{value: r, stack: debug.Stack()}
+					}
+				}()
+				done <- next.HandleMessage(ctx, msg)
+			}()
+
+			select {
+			case err := <-done:
+				return err
+			case p := <-panicked:
+				panic(p)
+			case <-ctx.Done():
+				if report, ok := ctx.Value(lateResultKey{}).(func(err error)); ok {
+					go func() {
+						select {
+						case err := <-done:
+							report(err)
+						case p := <-panicked:
+							report(fmt.Errorf("panic: %v", p.value))
+						}
+					}()
+				}
+				return fmt.Errorf("%w after %v: %w", ErrHandleTimeout, timeout, ctx.Err())
+			}
+		})
+	}
+}
+
+type traceIDKey struct{}
+
+// Trace 从消息头提取链路追踪 ID 放入 ctx，依次取 traceparent 中的 trace-id、x-request-id，都没有时使用消息 ID
+func Trace() Middleware {
+	return func(next ConsumerHandler) ConsumerHandler {
+		return HandlerFunc(func(ctx context.Context, msg *broker.Message) error {
+			traceID := parseTraceParent(msg.HeaderString(HeaderTraceParent))
+			if traceID == "" {
+				traceID = msg.HeaderString(HeaderRequestID)
+			}
+			if traceID == "" {
+				traceID = msg.ID
+			}
+			return next.HandleMessage(context.WithValue(ctx, traceIDKey{}, traceID), msg)
+		})
+	}
+}
+
+// TraceID 获取 ctx 中的链路追踪 ID
+func TraceID(ctx context.Context) string {
+	traceID, _ := ctx.Value(traceIDKey{}).(string)
+	return traceID
+}
+
+// parseTraceParent 解析 traceparent（version-traceid-parentid-flags）中的 trace-id，格式不合法时返回空
+func parseTraceParent(traceParent string) string {
+	parts := strings.Split(strings.TrimSpace(traceParent), "-")
+	if len(parts) < 4 || len(parts[1]) != 32 || parts[1] == strings.Repeat("0", 32) {
+		return ""
+	}
+	for _, c := range parts[1] {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return ""
+		}
+	}
+	return parts[1]
+}
@@ -0,0 +1,118 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"gin-web/app/ampq/broker"
+	"gin-web/global"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// setupLog 测试期间使用不输出的日志
+func setupLog(t *testing.T) {
+	previous := global.App.Log
+	t.Cleanup(func() { global.App.Log = previous })
+	global.App.Log = zap.NewNop()
+}
+
+// recordMiddleware 记录中间件进入和退出的顺序
+func recordMiddleware(name string, calls *[]string) Middleware {
+	return func(next ConsumerHandler) ConsumerHandler {
+		return HandlerFunc(func(ctx context.Context, msg *broker.Message) error {
+			*calls = append(*calls, name+":before")
+			err := next.HandleMessage(ctx, msg)
+			*calls = append(*calls, name+":after")
+			return err
+		})
+	}
+}
+
+func TestChainOrder(t *testing.T) {
+	var calls []string
+	handler := Chain(HandlerFunc(func(ctx context.Context, msg *broker.Message) error {
+		calls = append(calls, "handler")
+		return nil
+	}), recordMiddleware("a", &calls), recordMiddleware("b", &calls))
+
+	if err := handler.HandleMessage(context.Background(), &broker.Message{}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a:before", "b:before", "handler", "b:after", "a:after"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	setupLog(t)
+	handler := Chain(HandlerFunc(func(ctx context.Context, msg *broker.Message) error {
+		panic("boom")
+	}), Recovery())
+
+	err := handler.HandleMessage(context.Background(), &broker.Message{})
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("err = %v, want panic error", err)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	finished := make(chan struct{})
+	handler := Chain(HandlerFunc(func(ctx context.Context, msg *broker.Message) error {
+		defer close(finished)
+		<-ctx.Done()
+		return ctx.Err()
+	}), Timeout(20*time.Millisecond))
+
+	err := handler.HandleMessage(context.Background(), &broker.Message{})
+	if !errors.Is(err, ErrHandleTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want ErrHandleTimeout", err)
+	}
+	<-finished
+}
+
+func TestTimeoutPanicRecovered(t *testing.T) {
+	setupLog(t)
+	// handler 协程中的 panic 交给外层的 Recovery
+	handler := Chain(HandlerFunc(func(ctx context.Context, msg *broker.Message) error {
+		panic("boom")
+	}), Recovery(), Timeout(time.Second))
+
+	err := handler.HandleMessage(context.Background(), &broker.Message{})
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("err = %v, want panic error", err)
+	}
+}
+
+func TestTrace(t *testing.T) {
+	cases := []struct {
+		name    string
+		headers map[string]interface{}
+		want    string
+	}{
+		{"traceparent", map[string]interface{}{
+			HeaderTraceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			HeaderRequestID:   "req-1",
+		}, "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{"invalid traceparent", map[string]interface{}{
+			HeaderTraceParent: "00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+			HeaderRequestID:   "req-1",
+		}, "req-1"},
+		{"message id", nil, "msg-1"},
+	}
+	for _, c := range cases {
+		var traceID string
+		handler := Chain(HandlerFunc(func(ctx context.Context, msg *broker.Message) error {
+			traceID = TraceID(ctx)
+			return nil
+		}), Trace())
+		handler.HandleMessage(context.Background(), &broker.Message{ID: "msg-1", Headers: c.headers})
+		if traceID != c.want {
+			t.Errorf("%s: trace id = %q, want %q", c.name, traceID, c.want)
+		}
+	}
+}
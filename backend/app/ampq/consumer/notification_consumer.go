@@ -0,0 +1,69 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"gin-web/app/ampq/broker"
+	"gin-web/app/ampq/event"
+	"gin-web/app/models"
+	"gin-web/app/services"
+)
+
+// NewNotificationConsumer 消费领域事件并生成站内通知
+func NewNotificationConsumer() ConsumerHandler {
+	return NewTypedHandler(event.Version, handleDomainEvent)
+}
+
+func handleDomainEvent(ctx context.Context, msg *broker.Message, domainEvent event.DomainEvent) error {
+	switch domainEvent.Event {
+	case event.EventCommentReplied:
+		var data event.CommentRepliedEvent
+		if err := Decode(domainEvent.Data, &data); err != nil {
+			return err
+		}
+		return services.NotificationService.Notify(
+			msg.ID,
+			data.RecipientID,
+			models.NotificationTypeCommentReply,
+			fmt.Sprintf("%s 回复了你的评论", data.ReplierName),
+			data.Content,
+			data,
+		)
+	case event.EventModVersionPublished:
+		var data event.ModVersionPublishedEvent
+		if err := Decode(domainEvent.Data, &data); err != nil {
+			return err
+		}
+		// 同一事件被重复投递时，已生成的通知会被唯一索引跳过，中途失败重试也不会重复通知
+		for _, followerID := range data.FollowerIDs {
+			err := services.NotificationService.Notify(
+				msg.ID,
+				followerID,
+				models.NotificationTypeModVersion,
+				fmt.Sprintf("%s 发布了新版本 %s", data.ModName, data.Version),
+				"",
+				data,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case event.EventModerationDecided:
+		var data event.ModerationDecidedEvent
+		if err := Decode(domainEvent.Data, &data); err != nil {
+			return err
+		}
+		return services.NotificationService.Notify(
+			msg.ID,
+			data.UserID,
+			models.NotificationTypeModeration,
+			fmt.Sprintf("你的%s审核结果：%s", data.Target, data.Decision),
+			data.Reason,
+			data,
+		)
+	default:
+		// 不关心的事件直接确认
+		return nil
+	}
+}
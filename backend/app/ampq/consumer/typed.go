@@ -0,0 +1,106 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gin-web/app/ampq/broker"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+// ErrUndecodable 消息无法解码、校验失败或版本不受支持，重试也不会成功，直接转入死信队列
+var ErrUndecodable = errors.New("undecodable message")
+
+// Upgrader 将消息体从某个 schema 版本升级到下一个版本
+type Upgrader func(body []byte) ([]byte, error)
+
+// TypedHandler 按 schema 版本升级消息体后解码为 T 并校验，再交给 handle 处理
+// 校验规则与请求参数一致，使用 binding 标签
+type TypedHandler[T any] struct {
+	version  int
+	upgrades map[int]Upgrader
+	key      func(payload T) string
+	handle   func(ctx context.Context, msg *broker.Message, payload T) error
+}
+
+// NewTypedHandler version 为 handle 接收的当前 schema 版本
+func NewTypedHandler[T any](version int, handle func(ctx context.Context, msg *broker.Message, payload T) error) *TypedHandler[T] {
+	return &TypedHandler[T]{
+		version:  version,
+		upgrades: map[int]Upgrader{},
+		handle:   handle,
+	}
+}
+
+// Upgrade 注册从 from 版本升级到 from+1 版本的函数，旧版本消息依次升级到当前版本
+func (h *TypedHandler[T]) Upgrade(from int, upgrader Upgrader) *TypedHandler[T] {
+	h.upgrades[from] = upgrader
+	return h
+}
+
+// Key 以消息内容定义幂等 key，供 Idempotency 中间件替代消息 ID 去重
+func (h *TypedHandler[T]) Key(key func(payload T) string) *TypedHandler[T] {
+	h.key = key
+	return h
+}
+
+// IdempotencyKey 实现 IdempotencyKeyer，未定义 Key 时使用消息 ID
+func (h *TypedHandler[T]) IdempotencyKey(msg *broker.Message) (string, error) {
+	if h.key == nil {
+		return msg.ID, nil
+	}
+	payload, err := h.decode(msg)
+	if err != nil {
+		return "", err
+	}
+	return h.key(payload), nil
+}
+
+func (h *TypedHandler[T]) HandleMessage(ctx context.Context, msg *broker.Message) error {
+	payload, err := h.decode(msg)
+	if err != nil {
+		return err
+	}
+	return h.handle(ctx, msg, payload)
+}
+
+// decode 升级到当前版本后解码并校验
+func (h *TypedHandler[T]) decode(msg *broker.Message) (payload T, err error) {
+	body, err := h.upgrade(msg.SchemaVersion(), msg.Body)
+	if err != nil {
+		return
+	}
+	err = Decode(body, &payload)
+	return
+}
+
+// upgrade 将消息体逐级升级到当前版本；比当前版本新的消息留在死信队列，升级部署后可重新投递
+func (h *TypedHandler[T]) upgrade(version int, body []byte) ([]byte, error) {
+	if version > h.version {
+		return nil, fmt.Errorf("%w: schema version %d is newer than supported version %d", ErrUndecodable, version, h.version)
+	}
+	for ; version < h.version; version++ {
+		upgrader, ok := h.upgrades[version]
+		if !ok {
+			return nil, fmt.Errorf("%w: no upgrade from schema version %d", ErrUndecodable, version)
+		}
+		var err error
+		if body, err = upgrader(body); err != nil {
+			return nil, fmt.Errorf("%w: upgrade from schema version %d: %v", ErrUndecodable, version, err)
+		}
+	}
+	return body, nil
+}
+
+// Decode 将 JSON 解码到 v 并按 binding 标签校验，失败时返回 ErrUndecodable
+func Decode(data []byte, v interface{}) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %v", ErrUndecodable, err)
+	}
+	if err := binding.Validator.ValidateStruct(v); err != nil {
+		return fmt.Errorf("%w: %v", ErrUndecodable, err)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package consumer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"gin-web/app/ampq/broker"
+	"testing"
+)
+
+type testPayload struct {
+	Name  string `json:"name" binding:"required"`
+	Email string `json:"email"`
+}
+
+func newTestMessage(version int, body string) *broker.Message {
+	return &broker.Message{
+		ID:      "1",
+		Headers: map[string]interface{}{broker.HeaderSchemaVersion: version},
+		Body:    []byte(body),
+		Queue:   "test",
+	}
+}
+
+// newTestTypedHandler 当前版本为 3，v1 的 user 字段改名为 name，v2 增加 email
+func newTestTypedHandler(received *testPayload) *TypedHandler[testPayload] {
+	return NewTypedHandler(3, func(ctx context.Context, msg *broker.Message, payload testPayload) error {
+		*received = payload
+		return nil
+	}).Upgrade(1, func(body []byte) ([]byte, error) {
+		return bytes.Replace(body, []byte(`"user"`), []byte(`"name"`), 1), nil
+	}).Upgrade(2, func(body []byte) ([]byte, error) {
+		return bytes.Replace(body, []byte(`}`), []byte(`,"email":"default@example.com"}`), 1), nil
+	})
+}
+
+func TestTypedHandlerDecode(t *testing.T) {
+	var received testPayload
+	handler := newTestTypedHandler(&received)
+
+	if err := handler.HandleMessage(context.Background(), newTestMessage(3, `{"name":"alice","email":"a@example.com"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if received.Name != "alice" || received.Email != "a@example.com" {
+		t.Fatalf("unexpected payload %+v", received)
+	}
+}
+
+func TestTypedHandlerUpgrade(t *testing.T) {
+	var received testPayload
+	handler := newTestTypedHandler(&received)
+
+	// 未携带版本头的消息视为版本 1，依次升级到当前版本
+	msg := newTestMessage(1, `{"user":"bob"}`)
+	msg.Headers = nil
+	if err := handler.HandleMessage(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	if received.Name != "bob" || received.Email != "default@example.com" {
+		t.Fatalf("unexpected payload %+v", received)
+	}
+}
+
+func TestTypedHandlerUndecodable(t *testing.T) {
+	var received testPayload
+	handler := newTestTypedHandler(&received)
+	failing := NewTypedHandler(3, func(ctx context.Context, msg *broker.Message, payload testPayload) error {
+		return nil
+	}).Upgrade(2, func(body []byte) ([]byte, error) {
+		return nil, errors.New("broken")
+	})
+
+	cases := []struct {
+		name    string
+		handler ConsumerHandler
+		msg     *broker.Message
+	}{
+		{"invalid json", handler, newTestMessage(3, `{"name":`)},
+		{"validation failed", handler, newTestMessage(3, `{"email":"a@example.com"}`)},
+		{"newer version", handler, newTestMessage(4, `{"name":"alice"}`)},
+		{"missing upgrade", failing, newTestMessage(1, `{"name":"alice"}`)},
+		{"upgrade failed", failing, newTestMessage(2, `{"name":"alice"}`)},
+	}
+	for _, c := range cases {
+		if err := c.handler.HandleMessage(context.Background(), c.msg); !errors.Is(err, ErrUndecodable) {
+			t.Errorf("%s: err = %v, want ErrUndecodable", c.name, err)
+		}
+	}
+}
+
+func TestTypedHandlerIdempotencyKey(t *testing.T) {
+	var received testPayload
+	handler := newTestTypedHandler(&received)
+	msg := newTestMessage(1, `{"user":"bob"}`)
+
+	if key, err := handler.IdempotencyKey(msg); err != nil || key != msg.ID {
+		t.Fatalf("default key = %q, err = %v, want message ID", key, err)
+	}
+
+	// 自定义 key 基于升级后的消息内容
+	handler.Key(func(payload testPayload) string { return payload.Name })
+	if key, err := handler.IdempotencyKey(msg); err != nil || key != "bob" {
+		t.Fatalf("key = %q, err = %v, want bob", key, err)
+	}
+	if _, err := handler.IdempotencyKey(newTestMessage(3, `{}`)); !errors.Is(err, ErrUndecodable) {
+		t.Fatalf("err = %v, want ErrUndecodable", err)
+	}
+}
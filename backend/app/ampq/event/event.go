@@ -0,0 +1,58 @@
+// Package event 领域事件的名称和消息体，发布方（services）与消费方（consumer）共用
+package event
+
+import "encoding/json"
+
+// QueueNotification 生成站内通知的领域事件队列
+const QueueNotification = "mod.domain_events.notification"
+
+// Version 领域事件消息体当前的 schema 版本，随 x-schema-version 消息头发送
+const Version = 1
+
+// 领域事件名称
+const (
+	EventCommentReplied      = "comment.replied"
+	EventModVersionPublished = "mod.version_published"
+	EventModerationDecided   = "moderation.decided"
+)
+
+// DomainEvent 领域事件消息体
+type DomainEvent struct {
+	Event string          `json:"event" binding:"required"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// New 序列化领域事件消息体
+func New(name string, data interface{}) ([]byte, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(DomainEvent{Event: name, Data: raw})
+}
+
+// CommentRepliedEvent 评论被回复
+type CommentRepliedEvent struct {
+	RecipientID uint   `json:"recipient_id" binding:"required"`
+	ReplierName string `json:"replier_name"`
+	ModID       uint   `json:"mod_id"`
+	CommentID   uint   `json:"comment_id"`
+	Content     string `json:"content"`
+}
+
+// ModVersionPublishedEvent 关注的 mod 发布新版本
+type ModVersionPublishedEvent struct {
+	ModID       uint   `json:"mod_id" binding:"required"`
+	ModName     string `json:"mod_name"`
+	Version     string `json:"version" binding:"required"`
+	FollowerIDs []uint `json:"follower_ids"`
+}
+
+// ModerationDecidedEvent 审核结果
+type ModerationDecidedEvent struct {
+	UserID   uint   `json:"user_id" binding:"required"`
+	Target   string `json:"target"`
+	TargetID uint   `json:"target_id"`
+	Decision string `json:"decision"`
+	Reason   string `json:"reason"`
+}
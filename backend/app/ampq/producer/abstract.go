@@ -3,73 +3,37 @@ package producer
 
 import (
 	"context"
-	"fmt"
-	"gin-web/config"
-	"github.com/rabbitmq/amqp091-go"
+	"gin-web/app/ampq/broker"
+	"gin-web/utils"
 )
 
 type Producer interface {
-	Publish(body []byte) error
+	// Publish 发布消息，broker 确认后返回，被拒绝或超时返回错误
+	Publish(ctx context.Context, body []byte) error
 	QueueName() string
 }
 
 type BaseProducer struct {
-	conn    *amqp091.Connection
-	channel *amqp091.Channel
-	config  config.RabbitMQ
-	queue   string
+	broker broker.Broker
+	queue  string
 }
 
-func NewBaseProducer(cfg config.RabbitMQ, queue string) (*BaseProducer, error) {
-	conn, err := amqp091.Dial(getAMQPURI(cfg))
-	if err != nil {
-		return nil, err
-	}
-
-	ch, err := conn.Channel()
-	if err != nil {
-		return nil, err
-	}
-
-	// 声明队列
-	_, err = ch.QueueDeclare(
-		queue,
-		true,  // durable
-		false, // autoDelete
-		false, // exclusive
-		false, // noWait
-		nil,   // args
-	)
-
+func NewBaseProducer(b broker.Broker, queue string) *BaseProducer {
 	return &BaseProducer{
-		conn:    conn,
-		channel: ch,
-		config:  cfg,
-		queue:   queue,
-	}, err
+		broker: b,
+		queue:  queue,
+	}
 }
 
-func (p *BaseProducer) Publish(body []byte) error {
-	return p.channel.PublishWithContext(
-		context.Background(),
-		"",      // exchange
-		p.queue, // routing key
-		false,   // mandatory
-		false,   // immediate
-		amqp091.Publishing{
-			ContentType:  "application/json",
-			Body:         body,
-			DeliveryMode: amqp091.Persistent,
-		},
-	)
+func (p *BaseProducer) Publish(ctx context.Context, body []byte) error {
+	// 消息 ID 在重试和重新投递中保持不变，供消费方去重
+	return p.broker.Publish(ctx, p.queue, broker.Message{
+		ID:          utils.RandToken(16),
+		ContentType: "application/json",
+		Body:        body,
+	})
 }
 
-func getAMQPURI(cfg config.RabbitMQ) string {
-	return fmt.Sprintf("amqp://%s:%s@%s:%d/%s",
-		cfg.Username,
-		cfg.Password,
-		cfg.Host,
-		cfg.Port,
-		cfg.Vhost,
-	)
+func (p *BaseProducer) QueueName() string {
+	return p.queue
 }
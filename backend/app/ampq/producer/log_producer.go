@@ -2,25 +2,25 @@
 package producer
 
 import (
-	"gin-web/config"
+	"context"
+	"gin-web/app/ampq/broker"
 	"gin-web/global"
+
+	"go.uber.org/zap"
 )
 
 type LogProducer struct {
 	*BaseProducer
 }
 
-func NewLogProducer(cfg config.RabbitMQ) (*LogProducer, error) {
-	base, err := NewBaseProducer(cfg, "log_queue")
-	if err != nil {
-		return nil, err
-	}
-	return &LogProducer{base}, nil
+func NewLogProducer(b broker.Broker) *LogProducer {
+	return &LogProducer{NewBaseProducer(b, "log_queue")}
 }
 
 // 使用示例
 func ExampleUsage() {
-	cfg := global.App.Config.RabbitMQ
-	p, _ := NewLogProducer(cfg)
-	p.Publish([]byte(`{"level":"info","msg":"test"}`))
+	p := NewLogProducer(global.App.Broker)
+	if err := p.Publish(context.Background(), []byte(`{"level":"info","msg":"test"}`)); err != nil {
+		global.App.Log.Error("publish log failed", zap.Error(err))
+	}
 }
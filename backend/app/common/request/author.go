@@ -0,0 +1,52 @@
+package request
+
+// AuthorProfileRequest 获取作者主页请求
+type AuthorProfileRequest struct {
+	ID uint `uri:"id" binding:"required,min=1"` // 作者用户ID
+}
+
+// ClaimAuthor 认领作者署名请求
+type ClaimAuthor struct {
+	Alias    string `form:"alias" json:"alias" binding:"required,max=100"`        // 历史 mod 上的作者署名
+	Evidence string `form:"evidence" json:"evidence" binding:"required,max=2000"` // 所有权证明，供版主核实
+}
+
+func (claim ClaimAuthor) GetMessages() ValidatorMessages {
+	return ValidatorMessages{
+		"alias.required":    "作者署名不能为空",
+		"alias.max":         "作者署名不能超过100个字符",
+		"evidence.required": "请提供所有权证明，如原发布页链接",
+		"evidence.max":      "所有权证明不能超过2000个字符",
+	}
+}
+
+// AuthorClaimQuery 认领申请列表查询
+type AuthorClaimQuery struct {
+	Status   int8 `form:"status" json:"status" binding:"omitempty,oneof=1 2 3"` // 不传时返回待审核
+	Page     int  `form:"page" json:"page" binding:"min=0"`
+	PageSize int  `form:"page_size" json:"page_size" binding:"min=0,max=100"`
+}
+
+func (query AuthorClaimQuery) GetMessages() ValidatorMessages {
+	return ValidatorMessages{
+		"status.oneof":  "状态只能为 1、2、3",
+		"page_size.max": "每页数量不能超过100",
+	}
+}
+
+// ReviewAuthorClaim 审核认领申请
+type ReviewAuthorClaim struct {
+	Approve bool   `form:"approve" json:"approve"`
+	Note    string `form:"note" json:"note" binding:"max=500"`
+}
+
+func (review ReviewAuthorClaim) GetMessages() ValidatorMessages {
+	return ValidatorMessages{
+		"note.max": "审核意见不能超过500个字符",
+	}
+}
+
+// AuthorClaimID 单个认领申请
+type AuthorClaimID struct {
+	ID uint `uri:"id" binding:"required,min=1"` // 认领申请ID
+}
@@ -0,0 +1,37 @@
+package request
+
+// CommentList 评论列表请求
+type CommentList struct {
+	Page     int `form:"page" json:"page" binding:"min=0"`                   // 页码
+	PageSize int `form:"page_size" json:"page_size" binding:"min=0,max=100"` // 页面大小
+}
+
+// CreateComment 发表评论请求
+type CreateComment struct {
+	Content  string `form:"content" json:"content" binding:"required,max=2000"`
+	ParentID *uint  `form:"parent_id" json:"parent_id" binding:"omitempty,min=1"` // 回复的评论ID
+}
+
+func (comment CreateComment) GetMessages() ValidatorMessages {
+	return ValidatorMessages{
+		"content.required": "评论内容不能为空",
+		"content.max":      "评论内容不能超过2000个字符",
+	}
+}
+
+// CommentID 单条评论请求
+type CommentID struct {
+	ID uint `uri:"id" binding:"required,min=1"` // 评论ID
+}
+
+// DeleteComment 版主删除评论请求
+type DeleteComment struct {
+	Reason string `form:"reason" json:"reason" binding:"required,max=500"` // 删除原因，会通知评论作者
+}
+
+func (deleteComment DeleteComment) GetMessages() ValidatorMessages {
+	return ValidatorMessages{
+		"reason.required": "删除原因不能为空",
+		"reason.max":      "删除原因不能超过500个字符",
+	}
+}
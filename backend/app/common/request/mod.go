@@ -5,8 +5,9 @@ type ModSearchRequest struct {
 	Keyword    string `form:"keyword" json:"keyword"`                             // 搜索关键词
 	GameID     uint   `form:"game_id" json:"game_id"`                             // 游戏ID
 	CategoryID uint   `form:"category_id" json:"category_id"`                     // 分类ID
-	Author     string `form:"author" json:"author"`                               // 作者
-	SortBy     string `form:"sort_by" json:"sort_by"`                             // 排序字段: rating, download_count, created_at
+	Author     string `form:"author" json:"author"`                               // 作者署名（模糊匹配）
+	AuthorID   uint   `form:"author_id" json:"author_id"`                         // 发布者用户ID
+	SortBy     string `form:"sort_by" json:"sort_by"`                             // 排序字段: rating, download_count, view_count, created_at
 	Order      string `form:"order" json:"order"`                                 // 排序方向: asc, desc
 	Page       int    `form:"page" json:"page" binding:"min=0"`                   // 页码，允许0（控制器设置默认值）
 	PageSize   int    `form:"page_size" json:"page_size" binding:"min=0,max=100"` // 页面大小，允许0（控制器设置默认值）
@@ -16,3 +17,24 @@ type ModSearchRequest struct {
 type ModDetailRequest struct {
 	ID uint `uri:"id" binding:"required,min=1"` // mod ID
 }
+
+// ModStatsRequest 获取mod统计请求
+type ModStatsRequest struct {
+	From        string `form:"from" json:"from"`                                                  // 开始日期 2006-01-02，默认最近30天
+	To          string `form:"to" json:"to"`                                                      // 结束日期 2006-01-02（含），默认今天
+	Granularity string `form:"granularity" json:"granularity" binding:"omitempty,oneof=day hour"` // 统计粒度: day, hour
+	Version     string `form:"version" json:"version"`                                            // 版本号，为空时汇总所有版本
+	Format      string `form:"format" json:"format" binding:"omitempty,oneof=json csv"`           // 返回格式: json, csv
+}
+
+// ModRecommendationRequest 获取相关mod请求
+type ModRecommendationRequest struct {
+	Limit int `form:"limit" json:"limit" binding:"min=0,max=50"` // 返回数量，默认10
+}
+
+// ModReleaseRequest 发布mod新版本请求
+type ModReleaseRequest struct {
+	Version     string `form:"version" json:"version" binding:"required,max=50"`        // 版本号
+	DownloadURL string `form:"download_url" json:"download_url" binding:"required,url"` // 下载地址
+	FileSize    int64  `form:"file_size" json:"file_size" binding:"min=0"`              // 文件大小（字节）
+}
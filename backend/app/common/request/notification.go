@@ -0,0 +1,24 @@
+package request
+
+// NotificationList 通知列表请求
+type NotificationList struct {
+	UnreadOnly bool `form:"unread_only" json:"unread_only"`                     // 只看未读
+	Page       int  `form:"page" json:"page" binding:"min=0"`                   // 页码
+	PageSize   int  `form:"page_size" json:"page_size" binding:"min=0,max=100"` // 页面大小
+}
+
+// NotificationID 单条通知请求
+type NotificationID struct {
+	ID uint `uri:"id" binding:"required,min=1"` // 通知ID
+}
+
+// NotificationPreferences 更新通知偏好请求
+type NotificationPreferences struct {
+	Preferences map[string]bool `form:"preferences" json:"preferences" binding:"required"` // 通知类型 => 是否接收
+}
+
+func (preferences NotificationPreferences) GetMessages() ValidatorMessages {
+	return ValidatorMessages{
+		"preferences.required": "通知偏好不能为空",
+	}
+}
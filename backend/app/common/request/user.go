@@ -1,9 +1,14 @@
 package request
 
+import (
+	"gin-web/global"
+	"gin-web/utils"
+)
+
 type Register struct {
 	Name     string `form:"name" json:"name" binding:"required"`
 	Mobile   string `form:"mobile" json:"mobile" binding:"required,mobile"`
-	Password string `form:"password" json:"password" binding:"required"`
+	Password string `form:"password" json:"password" binding:"required,password"`
 	Email    string `form:"email" json:"email" binding:"required,email"`
 }
 
@@ -18,6 +23,7 @@ func (register Register) GetMessages() ValidatorMessages {
 		"Name.required":     "用户名称不能为空",
 		"Mobile.required":   "手机号码不能为空",
 		"password.required": "用户密码不能为空",
+		"password.password": utils.PasswordPolicyHint(global.App.Config.Password),
 		"Email.required":    "邮箱不能为空",
 		"mobile.mobile":     "手机号码格式不正确",
 		"email.email":       "邮箱格式不正确",
@@ -31,3 +37,271 @@ func (login Login) GetMessages() ValidatorMessages {
 		"password.required": "用户密码不能为空",
 	}
 }
+
+// AssignRoles 分配角色
+type AssignRoles struct {
+	Roles []string `form:"roles" json:"roles" binding:"required"`
+}
+
+func (assignRoles AssignRoles) GetMessages() ValidatorMessages {
+	return ValidatorMessages{
+		"roles.required": "角色不能为空",
+	}
+}
+
+// UserID 用户ID路径参数
+type UserID struct {
+	ID uint `uri:"id" binding:"required,min=1"`
+}
+
+// RefreshToken 刷新 token
+type RefreshToken struct {
+	RefreshToken string `form:"refresh_token" json:"refresh_token" binding:"required"`
+}
+
+func (refreshToken RefreshToken) GetMessages() ValidatorMessages {
+	return ValidatorMessages{
+		"refresh_token.required": "refresh_token 不能为空",
+	}
+}
+
+// AdminLogin 管理后台登录
+type AdminLogin struct {
+	Username string `form:"username" json:"username" binding:"required"`
+	Password string `form:"password" json:"password" binding:"required"`
+}
+
+func (adminLogin AdminLogin) GetMessages() ValidatorMessages {
+	return ValidatorMessages{
+		"username.required": "账号不能为空",
+		"password.required": "密码不能为空",
+	}
+}
+
+// OauthCallback 第三方登录回调
+type OauthCallback struct {
+	Code  string `form:"code" json:"code" binding:"required"`
+	State string `form:"state" json:"state" binding:"required"`
+}
+
+func (oauthCallback OauthCallback) GetMessages() ValidatorMessages {
+	return ValidatorMessages{
+		"code.required":  "code 不能为空",
+		"state.required": "state 不能为空",
+	}
+}
+
+// VerifyEmail 邮箱验证
+type VerifyEmail struct {
+	Token string `form:"token" json:"token" binding:"required"`
+}
+
+func (verifyEmail VerifyEmail) GetMessages() ValidatorMessages {
+	return ValidatorMessages{
+		"token.required": "token 不能为空",
+	}
+}
+
+// ForgotPassword 忘记密码
+type ForgotPassword struct {
+	Email string `form:"email" json:"email" binding:"required,email"`
+}
+
+func (forgotPassword ForgotPassword) GetMessages() ValidatorMessages {
+	return ValidatorMessages{
+		"email.required": "邮箱不能为空",
+		"email.email":    "邮箱格式不正确",
+	}
+}
+
+// ResetPassword 重置密码
+type ResetPassword struct {
+	Token    string `form:"token" json:"token" binding:"required"`
+	Password string `form:"password" json:"password" binding:"required,password"`
+}
+
+func (resetPassword ResetPassword) GetMessages() ValidatorMessages {
+	return ValidatorMessages{
+		"token.required":    "token 不能为空",
+		"password.required": "新密码不能为空",
+		"password.password": utils.PasswordPolicyHint(global.App.Config.Password),
+	}
+}
+
+// SmsCode 发送短信验证码
+type SmsCode struct {
+	Mobile string `form:"mobile" json:"mobile" binding:"required,mobile"`
+}
+
+func (smsCode SmsCode) GetMessages() ValidatorMessages {
+	return ValidatorMessages{
+		"mobile.required": "手机号码不能为空",
+		"mobile.mobile":   "手机号码格式不正确",
+	}
+}
+
+// SmsLogin 短信验证码登录
+type SmsLogin struct {
+	Mobile string `form:"mobile" json:"mobile" binding:"required,mobile"`
+	Code   string `form:"code" json:"code" binding:"required"`
+}
+
+func (smsLogin SmsLogin) GetMessages() ValidatorMessages {
+	return ValidatorMessages{
+		"mobile.required": "手机号码不能为空",
+		"mobile.mobile":   "手机号码格式不正确",
+		"code.required":   "验证码不能为空",
+	}
+}
+
+// MfaLogin 登录第二步
+type MfaLogin struct {
+	MfaToken string `form:"mfa_token" json:"mfa_token" binding:"required"`
+	Code     string `form:"code" json:"code" binding:"required"`
+}
+
+func (mfaLogin MfaLogin) GetMessages() ValidatorMessages {
+	return ValidatorMessages{
+		"mfa_token.required": "mfa_token 不能为空",
+		"code.required":      "验证码不能为空",
+	}
+}
+
+// MfaToken 登录第二步凭证
+type MfaToken struct {
+	MfaToken string `form:"mfa_token" json:"mfa_token" binding:"required"`
+}
+
+func (mfaToken MfaToken) GetMessages() ValidatorMessages {
+	return ValidatorMessages{
+		"mfa_token.required": "mfa_token 不能为空",
+	}
+}
+
+// MfaCode 两步验证码（TOTP 验证码或恢复码）
+type MfaCode struct {
+	Code string `form:"code" json:"code" binding:"required"`
+}
+
+func (mfaCode MfaCode) GetMessages() ValidatorMessages {
+	return ValidatorMessages{
+		"code.required": "验证码不能为空",
+	}
+}
+
+// CreatePersonalAccessToken 创建个人访问令牌
+type CreatePersonalAccessToken struct {
+	Name          string   `form:"name" json:"name" binding:"required,max=100"`
+	Scopes        []string `form:"scopes" json:"scopes" binding:"required,min=1"`                   // read、publish、admin
+	ExpiresInDays int      `form:"expires_in_days" json:"expires_in_days" binding:"min=0,max=3650"` // 0 表示永不过期
+}
+
+func (createPersonalAccessToken CreatePersonalAccessToken) GetMessages() ValidatorMessages {
+	return ValidatorMessages{
+		"name.required":       "令牌名称不能为空",
+		"name.max":            "令牌名称不能超过 100 个字符",
+		"scopes.required":     "权限范围不能为空",
+		"scopes.min":          "权限范围不能为空",
+		"expires_in_days.max": "有效期不能超过 3650 天",
+	}
+}
+
+// TokenID 令牌ID路径参数
+type TokenID struct {
+	ID uint `uri:"id" binding:"required,min=1"`
+}
+
+// UnlockLogin 解除登录锁定，账号和 IP 至少指定一个
+type UnlockLogin struct {
+	Guard   string `form:"guard" json:"guard" binding:"omitempty,oneof=app admin"` // 默认 app（前台手机号），admin 为后台账号
+	Account string `form:"account" json:"account"`
+	Ip      string `form:"ip" json:"ip" binding:"omitempty,ip"`
+}
+
+func (unlockLogin UnlockLogin) GetMessages() ValidatorMessages {
+	return ValidatorMessages{
+		"guard.oneof": "guard 只能为 app 或 admin",
+		"ip.ip":       "IP 格式不正确",
+	}
+}
+
+// UpdateProfile 修改个人资料，未传的字段保持不变
+type UpdateProfile struct {
+	Name   *string `form:"name" json:"name" binding:"omitempty,min=1,max=30"`
+	Avatar *string `form:"avatar" json:"avatar" binding:"omitempty,url,max=500"`
+	Bio    *string `form:"bio" json:"bio" binding:"omitempty,max=500"`
+}
+
+func (updateProfile UpdateProfile) GetMessages() ValidatorMessages {
+	return ValidatorMessages{
+		"name.min":   "用户名称不能为空",
+		"name.max":   "用户名称不能超过 30 个字符",
+		"avatar.url": "头像地址格式不正确",
+		"avatar.max": "头像地址过长",
+		"bio.max":    "个人简介不能超过 500 个字符",
+	}
+}
+
+// ChangePassword 修改密码，未设置过密码（短信、第三方登录注册）时无需旧密码，改为校验当前手机号验证码
+type ChangePassword struct {
+	OldPassword string `form:"old_password" json:"old_password"`
+	Password    string `form:"password" json:"password" binding:"required,password"`
+	MfaCode     string `form:"mfa_code" json:"mfa_code"`       // 已开启两步验证时必填
+	VerifyCode  string `form:"verify_code" json:"verify_code"` // 未设置密码且未开启两步验证时必填，发送到当前手机号的验证码
+}
+
+func (changePassword ChangePassword) GetMessages() ValidatorMessages {
+	return ValidatorMessages{
+		"password.required": "新密码不能为空",
+		"password.password": utils.PasswordPolicyHint(global.App.Config.Password),
+	}
+}
+
+// ChangeMobileCode 发送更换手机号验证码
+type ChangeMobileCode struct {
+	Mobile string `form:"mobile" json:"mobile" binding:"required,mobile"`
+}
+
+func (changeMobileCode ChangeMobileCode) GetMessages() ValidatorMessages {
+	return ValidatorMessages{
+		"mobile.required": "手机号码不能为空",
+		"mobile.mobile":   "手机号码格式不正确",
+	}
+}
+
+// ChangeMobile 更换手机号，新手机号需通过短信验证
+type ChangeMobile struct {
+	Mobile     string `form:"mobile" json:"mobile" binding:"required,mobile"`
+	Code       string `form:"code" json:"code" binding:"required"`
+	Password   string `form:"password" json:"password"`       // 已设置密码时必填
+	MfaCode    string `form:"mfa_code" json:"mfa_code"`       // 已开启两步验证时必填
+	VerifyCode string `form:"verify_code" json:"verify_code"` // 未设置密码且未开启两步验证时必填，发送到当前手机号的验证码
+}
+
+func (changeMobile ChangeMobile) GetMessages() ValidatorMessages {
+	return ValidatorMessages{
+		"mobile.required": "手机号码不能为空",
+		"mobile.mobile":   "手机号码格式不正确",
+		"code.required":   "验证码不能为空",
+	}
+}
+
+// DeleteAccount 申请注销账号
+type DeleteAccount struct {
+	Password   string `form:"password" json:"password"`       // 已设置密码时必填
+	MfaCode    string `form:"mfa_code" json:"mfa_code"`       // 已开启两步验证时必填
+	VerifyCode string `form:"verify_code" json:"verify_code"` // 未设置密码且未开启两步验证时必填，发送到当前手机号的验证码
+}
+
+// DeadLetterQuery 查看或重放死信队列
+type DeadLetterQuery struct {
+	Queue string `form:"queue" json:"queue" binding:"required"`          // 消费队列名称，对应死信队列为 {queue}.dlq
+	Limit int    `form:"limit" json:"limit" binding:"omitempty,max=100"` // 默认 20
+}
+
+func (deadLetterQuery DeadLetterQuery) GetMessages() ValidatorMessages {
+	return ValidatorMessages{
+		"queue.required": "队列名称不能为空",
+		"limit.max":      "每次最多处理 100 条",
+	}
+}
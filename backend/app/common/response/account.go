@@ -0,0 +1,29 @@
+package response
+
+import (
+	"gin-web/app/models"
+	"time"
+)
+
+// AccountDeletion 注销申请结果
+type AccountDeletion struct {
+	ScheduledAt time.Time `json:"scheduled_at"` // 到期后清除账号数据，之前可撤销
+}
+
+// UserExport 用户数据导出
+type UserExport struct {
+	ExportedAt              time.Time                       `json:"exported_at"`
+	Profile                 models.User                     `json:"profile"`
+	Roles                   []string                        `json:"roles"`
+	Identities              []models.UserIdentity           `json:"identities"`
+	Mfa                     MfaStatus                       `json:"mfa"`
+	Sessions                []Session                       `json:"sessions"`
+	PersonalAccessTokens    []PersonalAccessToken           `json:"personal_access_tokens"`
+	Mods                    []models.Mod                    `json:"mods"`
+	Downloads               []models.ModDownload            `json:"downloads"`
+	Notifications           []models.Notification           `json:"notifications"`
+	NotificationPreferences []models.NotificationPreference `json:"notification_preferences"`
+	Follows                 []models.ModFollow              `json:"follows"`
+	Comments                []models.ModComment             `json:"comments"`
+	AuthorClaims            []models.AuthorClaim            `json:"author_claims"`
+}
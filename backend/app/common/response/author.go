@@ -0,0 +1,34 @@
+package response
+
+import (
+	"gin-web/app/models"
+	"time"
+)
+
+// AuthorProfileResponse 作者主页响应
+type AuthorProfileResponse struct {
+	ID             uint      `json:"id"`
+	Name           string    `json:"name"`
+	Avatar         string    `json:"avatar"`
+	Bio            string    `json:"bio"`
+	Alias          string    `json:"alias"`
+	ModCount       int64     `json:"mod_count"`
+	TotalDownloads int64     `json:"total_downloads"`
+	AverageRating  float64   `json:"average_rating"` // 仅统计已有评分的 mod
+	Mods           []ModItem `json:"mods"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// ClaimAuthorResponse 认领作者署名响应
+type ClaimAuthorResponse struct {
+	Alias      string `json:"alias"`
+	ClaimedMod int64  `json:"claimed_mod"` // 本次归属到用户名下的 mod 数量
+}
+
+// AuthorClaimListResponse 认领申请列表响应
+type AuthorClaimListResponse struct {
+	List     []models.AuthorClaim `json:"list"`
+	Total    int64                `json:"total"`
+	Page     int                  `json:"page"`
+	PageSize int                  `json:"page_size"`
+}
@@ -0,0 +1,22 @@
+package response
+
+import "time"
+
+// CommentItem 评论
+type CommentItem struct {
+	ID        uint      `json:"id"`
+	ModID     uint      `json:"mod_id"`
+	UserID    uint      `json:"user_id"`
+	UserName  string    `json:"user_name"`
+	ParentID  *uint     `json:"parent_id"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CommentListResponse 评论列表响应
+type CommentListResponse struct {
+	List     []CommentItem `json:"list"`
+	Total    int64         `json:"total"`
+	Page     int           `json:"page"`
+	PageSize int           `json:"page_size"`
+}
@@ -0,0 +1,30 @@
+package response
+
+import "time"
+
+// DeadLetter 死信消息
+type DeadLetter struct {
+	ID            string                 `json:"id"`
+	OriginalQueue string                 `json:"original_queue"`
+	ContentType   string                 `json:"content_type"`
+	Body          string                 `json:"body"`
+	Headers       map[string]interface{} `json:"headers"`
+	RetryCount    int                    `json:"retry_count"`
+	LastError     string                 `json:"last_error"`
+	FailedAt      *time.Time             `json:"failed_at"`
+}
+
+// ReplayDeadLetters 死信重放结果
+type ReplayDeadLetters struct {
+	Replayed int `json:"replayed"`
+}
+
+// ConsumerMetrics 当前实例某个队列的消息处理统计
+type ConsumerMetrics struct {
+	Queue           string    `json:"queue"`
+	Processed       int64     `json:"processed"`
+	Failed          int64     `json:"failed"`
+	AvgDurationMs   float64   `json:"avg_duration_ms"`
+	MaxDurationMs   float64   `json:"max_duration_ms"`
+	LastProcessedAt time.Time `json:"last_processed_at"`
+}
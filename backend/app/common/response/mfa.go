@@ -0,0 +1,36 @@
+package response
+
+import "time"
+
+// MfaStatus 两步验证状态
+type MfaStatus struct {
+	Enabled           bool       `json:"enabled"`
+	EnabledAt         *time.Time `json:"enabled_at"`
+	Required          bool       `json:"required"` // 当前角色是否要求开启
+	RecoveryCodesLeft int64      `json:"recovery_codes_left"`
+}
+
+// MfaSetup 绑定验证器所需信息，uri 可直接生成二维码
+type MfaSetup struct {
+	Secret string `json:"secret"`
+	Uri    string `json:"uri"`
+}
+
+// MfaRecoveryCodes 恢复码，只在生成时返回一次
+type MfaRecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MfaChallenge 密码校验通过但需要输入两步验证码，setup_required 为 true 时需先完成绑定
+type MfaChallenge struct {
+	MfaRequired   bool   `json:"mfa_required"`
+	SetupRequired bool   `json:"setup_required"`
+	MfaToken      string `json:"mfa_token"`
+	ExpiresIn     int    `json:"expires_in"`
+}
+
+// MfaEnrollment 登录时完成强制绑定，返回 token 和恢复码
+type MfaEnrollment struct {
+	Token         interface{} `json:"token"`
+	RecoveryCodes []string    `json:"recovery_codes"`
+}
@@ -19,6 +19,7 @@ type ModItem struct {
 	ID            uint      `json:"id"`
 	Name          string    `json:"name"`
 	Author        string    `json:"author"`
+	AuthorID      uint      `json:"author_id"`
 	Version       string    `json:"version"`
 	Rating        float64   `json:"rating"`
 	DownloadCount int       `json:"download_count"`
@@ -35,10 +36,12 @@ type ModDetailResponse struct {
 	Name          string            `json:"name"`
 	Description   string            `json:"description"`
 	Author        string            `json:"author"`
+	AuthorID      uint              `json:"author_id"`
 	Version       string            `json:"version"`
 	DownloadURL   string            `json:"download_url"`
 	Rating        float64           `json:"rating"`
 	DownloadCount int               `json:"download_count"`
+	ViewCount     int               `json:"view_count"`
 	FileSize      int64             `json:"file_size"`
 	Game          models.Game       `json:"game"`
 	Categories    []models.Category `json:"categories"`
@@ -55,3 +58,34 @@ type GameListResponse struct {
 type CategoryListResponse struct {
 	List []models.Category `json:"list"`
 }
+
+// ModStatsResponse mod统计时间序列响应
+type ModStatsResponse struct {
+	ModID          uint           `json:"mod_id"`
+	Granularity    string         `json:"granularity"`
+	Version        string         `json:"version"`
+	From           time.Time      `json:"from"`
+	To             time.Time      `json:"to"`
+	TotalViews     int64          `json:"total_views"`
+	TotalDownloads int64          `json:"total_downloads"`
+	Series         []ModStatPoint `json:"series"`
+}
+
+// ModStatPoint 统计时间点，无数据的时间点补 0
+type ModStatPoint struct {
+	Time      time.Time `json:"time"`
+	Views     int64     `json:"views"`
+	Downloads int64     `json:"downloads"`
+}
+
+// ModRecommendationResponse 相关mod响应
+type ModRecommendationResponse struct {
+	List []RecommendedMod `json:"list"`
+}
+
+// RecommendedMod 相关mod，source 为 co_download（共同下载）或 category（分类相似）
+type RecommendedMod struct {
+	ModItem
+	Score  float64 `json:"score"`
+	Source string  `json:"source"`
+}
@@ -0,0 +1,22 @@
+package response
+
+import "gin-web/app/models"
+
+// NotificationListResponse 通知列表响应
+type NotificationListResponse struct {
+	List     []models.Notification `json:"list"`
+	Total    int64                 `json:"total"`
+	Page     int                   `json:"page"`
+	PageSize int                   `json:"page_size"`
+}
+
+// UnreadCountResponse 未读数响应
+type UnreadCountResponse struct {
+	Count int64 `json:"count"`
+}
+
+// NotificationPreference 单个类型的通知偏好
+type NotificationPreference struct {
+	Type    string `json:"type"`
+	Enabled bool   `json:"enabled"`
+}
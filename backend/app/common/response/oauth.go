@@ -0,0 +1,14 @@
+package response
+
+// OauthProvider 可用的第三方登录方式
+type OauthProvider struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+// OauthAuthorize 授权跳转地址
+type OauthAuthorize struct {
+	Url     string `json:"url"`
+	State   string `json:"state"`
+	Binding string `json:"-"` // 浏览器绑定值，通过 cookie 下发，不出现在响应体中
+}
@@ -51,6 +51,10 @@ func TokenFail(c *gin.Context) {
 	FailByError(c, global.Errors.TokenError)
 }
 
+func ForbiddenFail(c *gin.Context) {
+	FailByError(c, global.Errors.ForbiddenError)
+}
+
 func ServerError(c *gin.Context, err interface{}) {
 	msg := "Internal Server Error"
 	// 非生产环境显示具体错误信息
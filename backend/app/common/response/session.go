@@ -0,0 +1,18 @@
+package response
+
+import "time"
+
+// Session 登录会话（设备），ID 即 token 家族ID
+type Session struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current"` // 是否为发起请求的会话
+}
+
+// RevokeSessionsResponse 批量下线结果
+type RevokeSessionsResponse struct {
+	Revoked int `json:"revoked"`
+}
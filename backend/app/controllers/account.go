@@ -0,0 +1,153 @@
+package app
+
+import (
+	"gin-web/app/common/request"
+	"gin-web/app/common/response"
+	"gin-web/app/services"
+	"github.com/gin-gonic/gin"
+	"strconv"
+	"time"
+)
+
+// UpdateProfile 修改个人资料
+func UpdateProfile(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.TokenFail(c)
+		return
+	}
+	var form request.UpdateProfile
+	if err := c.ShouldBindJSON(&form); err != nil {
+		response.ValidateFail(c, request.GetErrorMsg(form, err))
+		return
+	}
+	err, user := services.AccountService.UpdateProfile(userID, form)
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, user)
+}
+
+// ChangePassword 修改密码，其他设备随之下线
+func ChangePassword(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.TokenFail(c)
+		return
+	}
+	var form request.ChangePassword
+	if err := c.ShouldBindJSON(&form); err != nil {
+		response.ValidateFail(c, request.GetErrorMsg(form, err))
+		return
+	}
+	if err := services.AccountService.ChangePassword(userID, form, c.ClientIP(), currentFamilyID(c)); err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, nil)
+}
+
+// SendIdentityCode 向当前手机号发送身份确认验证码，用于未设置密码的账号
+func SendIdentityCode(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.TokenFail(c)
+		return
+	}
+	if err := services.AccountService.SendIdentityCode(userID); err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, nil)
+}
+
+// ChangeMobileCode 向新手机号发送验证码
+func ChangeMobileCode(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.TokenFail(c)
+		return
+	}
+	var form request.ChangeMobileCode
+	if err := c.ShouldBindJSON(&form); err != nil {
+		response.ValidateFail(c, request.GetErrorMsg(form, err))
+		return
+	}
+	if err := services.AccountService.SendChangeMobileCode(userID, form.Mobile); err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, nil)
+}
+
+// ChangeMobile 更换手机号
+func ChangeMobile(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.TokenFail(c)
+		return
+	}
+	var form request.ChangeMobile
+	if err := c.ShouldBindJSON(&form); err != nil {
+		response.ValidateFail(c, request.GetErrorMsg(form, err))
+		return
+	}
+	err, user := services.AccountService.ChangeMobile(userID, form, c.ClientIP())
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, user)
+}
+
+// DeleteAccount 申请注销账号
+func DeleteAccount(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.TokenFail(c)
+		return
+	}
+	var form request.DeleteAccount
+	if err := c.ShouldBindJSON(&form); err != nil {
+		response.ValidateFail(c, request.GetErrorMsg(form, err))
+		return
+	}
+	err, result := services.AccountService.RequestDeletion(userID, form, c.ClientIP(), currentFamilyID(c))
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, result)
+}
+
+// CancelDeleteAccount 撤销注销申请
+func CancelDeleteAccount(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.TokenFail(c)
+		return
+	}
+	if err := services.AccountService.CancelDeletion(userID); err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, nil)
+}
+
+// ExportAccount 以 JSON 文件导出当前用户的全部数据
+func ExportAccount(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.TokenFail(c)
+		return
+	}
+	err, export := services.AccountService.Export(userID, currentFamilyID(c))
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	filename := "user-" + strconv.Itoa(int(userID)) + "-" + time.Now().Format("20060102150405") + ".json"
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	response.Success(c, export)
+}
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"gin-web/app/ampq/consumer"
+	"gin-web/app/common/request"
+	"gin-web/app/common/response"
+	"gin-web/app/services"
+	"github.com/gin-gonic/gin"
+)
+
+// Roles 角色列表
+func Roles(c *gin.Context) {
+	err, roles := services.RbacService.ListRoles()
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, roles)
+}
+
+// AssignUserRoles 覆盖设置用户角色
+func AssignUserRoles(c *gin.Context) {
+	var uri request.UserID
+	if err := c.ShouldBindUri(&uri); err != nil {
+		response.ValidateFail(c, err.Error())
+		return
+	}
+
+	var form request.AssignRoles
+	if err := c.ShouldBindJSON(&form); err != nil {
+		response.ValidateFail(c, request.GetErrorMsg(form, err))
+		return
+	}
+
+	err, access := services.RbacService.AssignRoles(uri.ID, form.Roles)
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, access)
+}
+
+// UnlockLogin 解除账号或 IP 的登录锁定
+func UnlockLogin(c *gin.Context) {
+	var form request.UnlockLogin
+	if err := c.ShouldBindJSON(&form); err != nil {
+		response.ValidateFail(c, request.GetErrorMsg(form, err))
+		return
+	}
+	guard := form.Guard
+	if guard == "" {
+		guard = services.AppGuardName
+	}
+	if err := services.LoginThrottleService.Unlock(guard, form.Account, form.Ip); err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, nil)
+}
+
+// DeadLetters 查看死信队列
+func DeadLetters(c *gin.Context) {
+	var form request.DeadLetterQuery
+	if err := c.ShouldBindQuery(&form); err != nil {
+		response.ValidateFail(c, request.GetErrorMsg(form, err))
+		return
+	}
+	deadLetters, err := services.DeadLetterService.List(form.Queue, form.Limit)
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, deadLetters)
+}
+
+// ReplayDeadLetters 将死信重新投递到原队列
+func ReplayDeadLetters(c *gin.Context) {
+	var form request.DeadLetterQuery
+	if err := c.ShouldBindJSON(&form); err != nil {
+		response.ValidateFail(c, request.GetErrorMsg(form, err))
+		return
+	}
+	result, err := services.DeadLetterService.Replay(form.Queue, form.Limit)
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, result)
+}
+
+// ConsumerMetrics 当前实例各队列的消息处理统计
+func ConsumerMetrics(c *gin.Context) {
+	response.Success(c, consumer.ConsumerMetrics.Snapshot())
+}
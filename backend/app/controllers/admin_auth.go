@@ -0,0 +1,79 @@
+package app
+
+import (
+	"gin-web/app/common/request"
+	"gin-web/app/common/response"
+	"gin-web/app/services"
+	"gin-web/global"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// AdminLogin 管理后台登录，校验两步验证后颁发 admin guard 的 token
+func AdminLogin(c *gin.Context) {
+	var form request.AdminLogin
+	if err := c.ShouldBindJSON(&form); err != nil {
+		response.ValidateFail(c, request.GetErrorMsg(form, err))
+		return
+	}
+
+	if err, adminUser := services.AdminUserService.Login(form, c.ClientIP()); err != nil {
+		response.BusinessFail(c, err.Error())
+	} else {
+		// 后台账号一律要求两步验证，未绑定时先完成绑定
+		respondGuardLogin(c, services.AdminGuardName, adminUser.ID.ID, adminUser)
+	}
+}
+
+// AdminMfaLogin 后台登录第二步
+func AdminMfaLogin(c *gin.Context) {
+	mfaLogin(c, services.AdminGuardName)
+}
+
+// AdminMfaLoginSetup 后台登录时强制绑定，凭 mfa_token 获取 TOTP 密钥
+func AdminMfaLoginSetup(c *gin.Context) {
+	mfaLoginSetup(c, services.AdminGuardName)
+}
+
+// AdminMfaLoginEnable 后台登录时强制绑定，校验验证码开启两步验证后颁发 token
+func AdminMfaLoginEnable(c *gin.Context) {
+	mfaLoginEnable(c, services.AdminGuardName)
+}
+
+// AdminInfo 当前管理员信息
+func AdminInfo(c *gin.Context) {
+	err, adminUser := services.AdminUserService.GetAdminInfo(c.Keys["id"].(string))
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, adminUser)
+}
+
+// AdminRefresh 管理后台刷新 token
+func AdminRefresh(c *gin.Context) {
+	var form request.RefreshToken
+	if err := c.ShouldBindJSON(&form); err != nil {
+		response.ValidateFail(c, request.GetErrorMsg(form, err))
+		return
+	}
+
+	err, tokenData := services.JwtService.Refresh(services.AdminGuardName, form.RefreshToken)
+	if err != nil {
+		response.Fail(c, global.Errors.TokenError.ErrorCode, err.Error())
+		return
+	}
+	response.Success(c, tokenData)
+}
+
+// AdminLogout 管理后台登出
+func AdminLogout(c *gin.Context) {
+	token := c.Keys["token"].(*jwt.Token)
+	err := services.JwtService.JoinBlackList(services.AdminGuardName, token)
+	if err != nil {
+		response.BusinessFail(c, "登出失败")
+		return
+	}
+	services.JwtService.RevokeFamily(services.AdminGuardName, token.Claims.(*services.CustomClaims).FamilyID)
+	response.Success(c, nil)
+}
@@ -3,9 +3,14 @@ package app
 import (
 	"gin-web/app/common/request"
 	"gin-web/app/common/response"
+	"gin-web/app/models"
 	"gin-web/app/services"
-	"github.com/dgrijalva/jwt-go"
+	"gin-web/global"
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"net/http"
+	"strconv"
+	"time"
 )
 
 func Login(c *gin.Context) {
@@ -15,18 +20,108 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	if err, user := services.UserService.Login(form); err != nil {
+	if err, user := services.UserService.Login(form, c.ClientIP()); err != nil {
 		response.BusinessFail(c, err.Error())
 	} else {
-		tokenData, err, _ := services.JwtService.CreateToken(services.AppGuardName, user)
-		if err != nil {
-			response.BusinessFail(c, err.Error())
-			return
-		}
-		response.Success(c, tokenData)
+		respondLogin(c, *user)
 	}
 }
 
+// respondLogin 身份校验通过后颁发 token，需要两步验证时改为返回第二步凭证
+func respondLogin(c *gin.Context, user models.User) {
+	respondGuardLogin(c, services.AppGuardName, user.ID.ID, user)
+}
+
+// respondGuardLogin 已开启两步验证时返回输入验证码的凭证，账号要求开启但尚未开启时返回绑定凭证
+func respondGuardLogin(c *gin.Context, guard string, userID uint, user services.JwtUser) {
+	err, challenge := services.MfaService.LoginChallenge(guard, userID)
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	if challenge != nil {
+		response.Success(c, challenge)
+		return
+	}
+
+	tokenData, err, _ := services.JwtService.CreateToken(guard, user, sessionClient(c))
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, tokenData)
+}
+
+// MfaLogin 登录第二步，校验 TOTP 验证码或恢复码后颁发 token
+func MfaLogin(c *gin.Context) {
+	mfaLogin(c, services.AppGuardName)
+}
+
+// MfaLoginSetup 登录时强制绑定，凭 mfa_token 获取 TOTP 密钥
+func MfaLoginSetup(c *gin.Context) {
+	mfaLoginSetup(c, services.AppGuardName)
+}
+
+// MfaLoginEnable 登录时强制绑定，校验验证码开启两步验证后颁发 token
+func MfaLoginEnable(c *gin.Context) {
+	mfaLoginEnable(c, services.AppGuardName)
+}
+
+func mfaLogin(c *gin.Context, guard string) {
+	var form request.MfaLogin
+	if err := c.ShouldBindJSON(&form); err != nil {
+		response.ValidateFail(c, request.GetErrorMsg(form, err))
+		return
+	}
+
+	err, user := services.MfaService.VerifyChallenge(guard, form.MfaToken, form.Code)
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	tokenData, err, _ := services.JwtService.CreateToken(guard, user, sessionClient(c))
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, tokenData)
+}
+
+func mfaLoginSetup(c *gin.Context, guard string) {
+	var form request.MfaToken
+	if err := c.ShouldBindJSON(&form); err != nil {
+		response.ValidateFail(c, request.GetErrorMsg(form, err))
+		return
+	}
+
+	err, setup := services.MfaService.SetupChallenge(guard, form.MfaToken)
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, setup)
+}
+
+func mfaLoginEnable(c *gin.Context, guard string) {
+	var form request.MfaLogin
+	if err := c.ShouldBindJSON(&form); err != nil {
+		response.ValidateFail(c, request.GetErrorMsg(form, err))
+		return
+	}
+
+	err, user, codes := services.MfaService.EnableChallenge(guard, form.MfaToken, form.Code)
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	tokenData, err, _ := services.JwtService.CreateToken(guard, user, sessionClient(c))
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, response.MfaEnrollment{Token: tokenData, RecoveryCodes: codes.RecoveryCodes})
+}
+
 func Info(c *gin.Context) {
 	err, user := services.UserService.GetUserInfo(c.Keys["id"].(string))
 	if err != nil {
@@ -36,11 +131,36 @@ func Info(c *gin.Context) {
 	response.Success(c, user)
 }
 
+// Refresh 使用 refresh token 换取新的 token 对
+func Refresh(c *gin.Context) {
+	var form request.RefreshToken
+	if err := c.ShouldBindJSON(&form); err != nil {
+		response.ValidateFail(c, request.GetErrorMsg(form, err))
+		return
+	}
+
+	err, tokenData := services.JwtService.Refresh(services.AppGuardName, form.RefreshToken)
+	if err != nil {
+		response.Fail(c, global.Errors.TokenError.ErrorCode, err.Error())
+		return
+	}
+	response.Success(c, tokenData)
+}
+
 func Logout(c *gin.Context) {
-	err := services.JwtService.JoinBlackList(c.Keys["token"].(*jwt.Token))
+	token := c.Keys["token"].(*jwt.Token)
+	err := services.JwtService.JoinBlackList(services.AppGuardName, token)
 	if err != nil {
 		response.BusinessFail(c, "登出失败")
 		return
 	}
+	// 同时吊销 refresh token
+	services.JwtService.RevokeFamily(services.AppGuardName, token.Claims.(*services.CustomClaims).FamilyID)
 	response.Success(c, nil)
 }
+
+// Jwks 公开验签公钥（JWKS 标准格式，不使用统一响应结构），供其他服务验证 token
+func Jwks(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age="+strconv.Itoa(int(services.JwksCacheTtl/time.Second)))
+	c.JSON(http.StatusOK, services.JwtKeyService.Jwks())
+}
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"gin-web/app/common/request"
+	"gin-web/app/common/response"
+	"gin-web/app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuthorController 作者控制器
+type AuthorController struct{}
+
+// Profile 获取作者主页
+func (ac *AuthorController) Profile(c *gin.Context) {
+	var req request.AuthorProfileRequest
+
+	// 绑定URI参数
+	if err := c.ShouldBindUri(&req); err != nil {
+		response.ValidateFail(c, err.Error())
+		return
+	}
+
+	result, err := services.AuthorService.GetAuthorProfile(req.ID)
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// Claim 提交历史作者署名认领申请
+func (ac *AuthorController) Claim(c *gin.Context) {
+	var form request.ClaimAuthor
+	if err := c.ShouldBindJSON(&form); err != nil {
+		response.ValidateFail(c, request.GetErrorMsg(form, err))
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.TokenFail(c)
+		return
+	}
+
+	result, err := services.AuthorService.ClaimAlias(userID, form.Alias, form.Evidence)
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// MyClaims 当前用户的认领申请
+func (ac *AuthorController) MyClaims(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.TokenFail(c)
+		return
+	}
+
+	result, err := services.AuthorService.MyClaims(userID)
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// Claims 待审核的认领申请列表
+func (ac *AuthorController) Claims(c *gin.Context) {
+	var query request.AuthorClaimQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.ValidateFail(c, request.GetErrorMsg(query, err))
+		return
+	}
+
+	result, err := services.AuthorService.ListClaims(query)
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// ReviewClaim 审核认领申请
+func (ac *AuthorController) ReviewClaim(c *gin.Context) {
+	var uri request.AuthorClaimID
+	if err := c.ShouldBindUri(&uri); err != nil {
+		response.ValidateFail(c, err.Error())
+		return
+	}
+	var form request.ReviewAuthorClaim
+	if err := c.ShouldBindJSON(&form); err != nil {
+		response.ValidateFail(c, request.GetErrorMsg(form, err))
+		return
+	}
+
+	reviewerID, ok := currentUserID(c)
+	if !ok {
+		response.TokenFail(c)
+		return
+	}
+
+	result, err := services.AuthorService.ReviewClaim(reviewerID, uri.ID, form.Approve, form.Note)
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
@@ -0,0 +1,64 @@
+package app
+
+import (
+	"gin-web/app/common/request"
+	"gin-web/app/common/response"
+	"gin-web/app/services"
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyEmail 校验邮箱验证链接
+func VerifyEmail(c *gin.Context) {
+	var form request.VerifyEmail
+	if err := c.ShouldBindJSON(&form); err != nil {
+		response.ValidateFail(c, request.GetErrorMsg(form, err))
+		return
+	}
+	if err := services.EmailService.Verify(form.Token); err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, nil)
+}
+
+// ResendVerifyEmail 重新发送验证邮件
+func ResendVerifyEmail(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.TokenFail(c)
+		return
+	}
+	if err := services.EmailService.ResendVerification(userID); err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, nil)
+}
+
+// ForgotPassword 发送重置密码邮件
+func ForgotPassword(c *gin.Context) {
+	var form request.ForgotPassword
+	if err := c.ShouldBindJSON(&form); err != nil {
+		response.ValidateFail(c, request.GetErrorMsg(form, err))
+		return
+	}
+	if err := services.EmailService.ForgotPassword(form.Email); err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, nil)
+}
+
+// ResetPassword 通过邮件链接重置密码
+func ResetPassword(c *gin.Context) {
+	var form request.ResetPassword
+	if err := c.ShouldBindJSON(&form); err != nil {
+		response.ValidateFail(c, request.GetErrorMsg(form, err))
+		return
+	}
+	if err := services.EmailService.ResetPassword(form.Token, form.Password); err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, nil)
+}
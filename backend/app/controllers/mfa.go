@@ -0,0 +1,97 @@
+package app
+
+import (
+	"gin-web/app/common/request"
+	"gin-web/app/common/response"
+	"gin-web/app/services"
+	"github.com/gin-gonic/gin"
+)
+
+// MfaStatus 两步验证状态
+func MfaStatus(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.TokenFail(c)
+		return
+	}
+	err, status := services.MfaService.Status(currentGuard(c), userID)
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, status)
+}
+
+// MfaSetup 生成 TOTP 密钥和绑定二维码地址
+func MfaSetup(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.TokenFail(c)
+		return
+	}
+	err, setup := services.MfaService.Setup(currentGuard(c), userID)
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, setup)
+}
+
+// MfaEnable 校验验证码后开启两步验证，返回恢复码
+func MfaEnable(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.TokenFail(c)
+		return
+	}
+	var form request.MfaCode
+	if err := c.ShouldBindJSON(&form); err != nil {
+		response.ValidateFail(c, request.GetErrorMsg(form, err))
+		return
+	}
+	err, codes := services.MfaService.Enable(currentGuard(c), userID, form.Code)
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, codes)
+}
+
+// MfaDisable 关闭两步验证
+func MfaDisable(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.TokenFail(c)
+		return
+	}
+	var form request.MfaCode
+	if err := c.ShouldBindJSON(&form); err != nil {
+		response.ValidateFail(c, request.GetErrorMsg(form, err))
+		return
+	}
+	if err := services.MfaService.Disable(currentGuard(c), userID, form.Code); err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, nil)
+}
+
+// MfaRecoveryCodes 重新生成恢复码
+func MfaRecoveryCodes(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.TokenFail(c)
+		return
+	}
+	var form request.MfaCode
+	if err := c.ShouldBindJSON(&form); err != nil {
+		response.ValidateFail(c, request.GetErrorMsg(form, err))
+		return
+	}
+	err, codes := services.MfaService.RegenerateRecoveryCodes(currentGuard(c), userID, form.Code)
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, codes)
+}
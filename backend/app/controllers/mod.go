@@ -1,11 +1,15 @@
 package app
 
 import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
 	"gin-web/app/common/request"
 	"gin-web/app/common/response"
 	"gin-web/app/services"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -58,6 +62,11 @@ func (mc *ModController) Detail(c *gin.Context) {
 		return
 	}
 
+	// 记录浏览次数
+	if services.CounterService.Hit(services.CounterView, result.ID, visitorKey(c)) {
+		services.StatService.Record(services.CounterView, result.ID, result.Version)
+	}
+
 	response.Success(c, result)
 }
 
@@ -77,8 +86,15 @@ func (mc *ModController) Download(c *gin.Context) {
 		return
 	}
 
-	// 如果有下载链接，重定向到下载地址
+	// 如果有下载链接，记录下载次数并重定向到下载地址
 	if result.DownloadURL != "" {
+		if services.CounterService.Hit(services.CounterDownload, result.ID, visitorKey(c)) {
+			services.StatService.Record(services.CounterDownload, result.ID, result.Version)
+			// 登录用户的下载用于计算相关推荐
+			if userID, ok := currentUserID(c); ok {
+				services.RecommendService.RecordDownload(userID, result.ID, result.Game.ID)
+			}
+		}
 		c.Redirect(http.StatusFound, result.DownloadURL)
 		return
 	}
@@ -86,6 +102,227 @@ func (mc *ModController) Download(c *gin.Context) {
 	response.BusinessFail(c, "Download URL not available")
 }
 
+// Stats 获取mod浏览、下载统计时间序列，format=csv 时导出 CSV
+func (mc *ModController) Stats(c *gin.Context) {
+	var uri request.ModDetailRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		response.ValidateFail(c, err.Error())
+		return
+	}
+
+	var req request.ModStatsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.ValidateFail(c, err.Error())
+		return
+	}
+
+	result, err := services.StatService.GetModStats(uri.ID, req)
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+
+	if req.Format == "csv" {
+		var buf bytes.Buffer
+		writer := csv.NewWriter(&buf)
+		_ = writer.Write([]string{"time", "views", "downloads"})
+		for _, point := range result.Series {
+			_ = writer.Write([]string{
+				point.Time.Format(time.RFC3339),
+				strconv.FormatInt(point.Views, 10),
+				strconv.FormatInt(point.Downloads, 10),
+			})
+		}
+		writer.Flush()
+
+		filename := fmt.Sprintf("mod_%d_stats_%s.csv", result.ModID, result.Granularity)
+		c.Header("Content-Disposition", "attachment; filename="+filename)
+		c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// Recommendations 获取相关mod（下载了这个的用户也下载了）
+func (mc *ModController) Recommendations(c *gin.Context) {
+	var uri request.ModDetailRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		response.ValidateFail(c, err.Error())
+		return
+	}
+
+	var req request.ModRecommendationRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.ValidateFail(c, err.Error())
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = 10
+	}
+
+	result, err := services.RecommendService.GetRecommendations(uri.ID, req.Limit)
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// PublishRelease 发布新版本，支持个人访问令牌（publish 范围）供 CI 调用
+func (mc *ModController) PublishRelease(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.TokenFail(c)
+		return
+	}
+
+	var uri request.ModDetailRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		response.ValidateFail(c, err.Error())
+		return
+	}
+
+	var req request.ModReleaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidateFail(c, err.Error())
+		return
+	}
+
+	result, err := services.ModService.PublishRelease(userID, uri.ID, req)
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// Follow 关注mod，新版本发布时会收到通知
+func (mc *ModController) Follow(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.TokenFail(c)
+		return
+	}
+
+	var uri request.ModDetailRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		response.ValidateFail(c, err.Error())
+		return
+	}
+
+	if err := services.FollowService.Follow(userID, uri.ID); err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+// Unfollow 取消关注mod
+func (mc *ModController) Unfollow(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.TokenFail(c)
+		return
+	}
+
+	var uri request.ModDetailRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		response.ValidateFail(c, err.Error())
+		return
+	}
+
+	if err := services.FollowService.Unfollow(userID, uri.ID); err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+// Comments mod评论列表
+func (mc *ModController) Comments(c *gin.Context) {
+	var uri request.ModDetailRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		response.ValidateFail(c, err.Error())
+		return
+	}
+
+	var req request.CommentList
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.ValidateFail(c, err.Error())
+		return
+	}
+
+	result, err := services.CommentService.List(uri.ID, req)
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// CreateComment 发表评论，回复他人时通知被回复者
+func (mc *ModController) CreateComment(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.TokenFail(c)
+		return
+	}
+
+	var uri request.ModDetailRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		response.ValidateFail(c, err.Error())
+		return
+	}
+
+	var form request.CreateComment
+	if err := c.ShouldBindJSON(&form); err != nil {
+		response.ValidateFail(c, request.GetErrorMsg(form, err))
+		return
+	}
+
+	result, err := services.CommentService.Create(userID, uri.ID, form)
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// DeleteComment 版主删除评论，并通知评论作者
+func (mc *ModController) DeleteComment(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.TokenFail(c)
+		return
+	}
+
+	var uri request.CommentID
+	if err := c.ShouldBindUri(&uri); err != nil {
+		response.ValidateFail(c, err.Error())
+		return
+	}
+
+	var form request.DeleteComment
+	if err := c.ShouldBindJSON(&form); err != nil {
+		response.ValidateFail(c, request.GetErrorMsg(form, err))
+		return
+	}
+
+	if err := services.CommentService.Delete(userID, uri.ID, form.Reason); err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+
+	response.Success(c, nil)
+}
+
 // Games 获取游戏列表
 func (mc *ModController) Games(c *gin.Context) {
 	result, err := services.ModService.GetGames()
@@ -107,3 +344,11 @@ func (mc *ModController) Categories(c *gin.Context) {
 
 	response.Success(c, result)
 }
+
+// visitorKey 计数去重的访客标识，登录用户按用户ID，游客按IP
+func visitorKey(c *gin.Context) string {
+	if id, ok := c.Get("id"); ok {
+		return "user:" + id.(string)
+	}
+	return "ip:" + c.ClientIP()
+}
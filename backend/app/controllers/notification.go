@@ -0,0 +1,130 @@
+package app
+
+import (
+	"gin-web/app/common/request"
+	"gin-web/app/common/response"
+	"gin-web/app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationController 站内通知控制器
+type NotificationController struct{}
+
+// List 通知列表
+func (nc *NotificationController) List(c *gin.Context) {
+	var req request.NotificationList
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.ValidateFail(c, err.Error())
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.TokenFail(c)
+		return
+	}
+
+	result, err := services.NotificationService.List(userID, req)
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// UnreadCount 未读数
+func (nc *NotificationController) UnreadCount(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.TokenFail(c)
+		return
+	}
+
+	result, err := services.NotificationService.UnreadCount(userID)
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// MarkRead 标记单条已读
+func (nc *NotificationController) MarkRead(c *gin.Context) {
+	var req request.NotificationID
+	if err := c.ShouldBindUri(&req); err != nil {
+		response.ValidateFail(c, err.Error())
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.TokenFail(c)
+		return
+	}
+
+	if err := services.NotificationService.MarkRead(userID, req.ID); err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+// MarkAllRead 全部标记已读
+func (nc *NotificationController) MarkAllRead(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.TokenFail(c)
+		return
+	}
+
+	if err := services.NotificationService.MarkAllRead(userID); err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+
+	response.Success(c, nil)
+}
+
+// Preferences 获取通知偏好
+func (nc *NotificationController) Preferences(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.TokenFail(c)
+		return
+	}
+
+	result, err := services.NotificationService.GetPreferences(userID)
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// UpdatePreferences 更新通知偏好
+func (nc *NotificationController) UpdatePreferences(c *gin.Context) {
+	var form request.NotificationPreferences
+	if err := c.ShouldBindJSON(&form); err != nil {
+		response.ValidateFail(c, request.GetErrorMsg(form, err))
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.TokenFail(c)
+		return
+	}
+
+	result, err := services.NotificationService.UpdatePreferences(userID, form.Preferences)
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"gin-web/app/common/request"
+	"gin-web/app/common/response"
+	"gin-web/app/services"
+	"gin-web/global"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+// OauthProviders 可用的第三方登录方式
+func OauthProviders(c *gin.Context) {
+	response.Success(c, services.OauthService.Providers())
+}
+
+// 授权流程浏览器绑定 cookie，只在 oauth 接口下发送
+const (
+	oauthBindingCookie = "oauth_binding"
+	oauthCookiePath    = "/api/auth/oauth"
+)
+
+// setOauthBindingCookie 写入或清除（maxAge < 0）浏览器绑定 cookie
+func setOauthBindingCookie(c *gin.Context, value string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oauthBindingCookie, value, maxAge, oauthCookiePath, "", global.App.Config.Oauth.CookieSecure, true)
+}
+
+// OauthAuthorize 获取第三方授权地址，已登录时回调后绑定到当前用户
+// 同时下发 HttpOnly cookie，回调必须由同一浏览器携带该 cookie 完成
+func OauthAuthorize(c *gin.Context) {
+	userID, _ := currentUserID(c)
+	err, result := services.OauthService.AuthorizeURL(c.Request.Context(), c.Param("provider"), userID)
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	setOauthBindingCookie(c, result.Binding, int(services.OauthService.StateTtl()/time.Second))
+	response.Success(c, result)
+}
+
+// OauthCallback 第三方授权回调，登录或注册后返回 token
+func OauthCallback(c *gin.Context) {
+	var form request.OauthCallback
+	if err := c.ShouldBindJSON(&form); err != nil {
+		response.ValidateFail(c, request.GetErrorMsg(form, err))
+		return
+	}
+
+	binding, _ := c.Cookie(oauthBindingCookie)
+	userID, _ := currentUserID(c)
+	err, user := services.OauthService.Callback(c.Request.Context(), c.Param("provider"), form.Code, form.State, binding, userID)
+	setOauthBindingCookie(c, "", -1)
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	respondLogin(c, user)
+}
+
+// Identities 当前用户绑定的第三方账号
+func Identities(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.TokenFail(c)
+		return
+	}
+	err, identities := services.OauthService.Identities(userID)
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, identities)
+}
+
+// UnlinkIdentity 解绑第三方账号
+func UnlinkIdentity(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.TokenFail(c)
+		return
+	}
+	if err := services.OauthService.Unlink(userID, c.Param("provider")); err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, nil)
+}
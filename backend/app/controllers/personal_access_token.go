@@ -0,0 +1,62 @@
+package app
+
+import (
+	"gin-web/app/common/request"
+	"gin-web/app/common/response"
+	"gin-web/app/services"
+	"github.com/gin-gonic/gin"
+)
+
+// PersonalAccessTokens 个人访问令牌列表
+func PersonalAccessTokens(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.TokenFail(c)
+		return
+	}
+	err, tokens := services.PersonalAccessTokenService.List(userID)
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, tokens)
+}
+
+// CreatePersonalAccessToken 创建个人访问令牌
+func CreatePersonalAccessToken(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.TokenFail(c)
+		return
+	}
+	var form request.CreatePersonalAccessToken
+	if err := c.ShouldBindJSON(&form); err != nil {
+		response.ValidateFail(c, request.GetErrorMsg(form, err))
+		return
+	}
+	err, token := services.PersonalAccessTokenService.Create(userID, form)
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, token)
+}
+
+// RevokePersonalAccessToken 吊销个人访问令牌
+func RevokePersonalAccessToken(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		response.TokenFail(c)
+		return
+	}
+	var uri request.TokenID
+	if err := c.ShouldBindUri(&uri); err != nil {
+		response.ValidateFail(c, err.Error())
+		return
+	}
+	if err := services.PersonalAccessTokenService.Revoke(userID, uri.ID); err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, nil)
+}
@@ -0,0 +1,47 @@
+package app
+
+import (
+	"gin-web/app/common/response"
+	"gin-web/app/services"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// currentFamilyID 当前请求所属的会话ID，需配合 JWTAuth 中间件使用
+func currentFamilyID(c *gin.Context) string {
+	token, ok := c.Keys["token"].(*jwt.Token)
+	if !ok {
+		return ""
+	}
+	return token.Claims.(*services.CustomClaims).FamilyID
+}
+
+// Sessions 当前用户的登录会话（设备）列表
+func Sessions(c *gin.Context) {
+	sessions, err := services.SessionService.List(services.AppGuardName, c.Keys["id"].(string), currentFamilyID(c))
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, sessions)
+}
+
+// RevokeSession 下线指定会话
+func RevokeSession(c *gin.Context) {
+	err := services.SessionService.Revoke(services.AppGuardName, c.Keys["id"].(string), c.Param("id"))
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, nil)
+}
+
+// RevokeOtherSessions 下线除当前会话外的全部会话
+func RevokeOtherSessions(c *gin.Context) {
+	count, err := services.SessionService.RevokeOthers(services.AppGuardName, c.Keys["id"].(string), currentFamilyID(c))
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, response.RevokeSessionsResponse{Revoked: count})
+}
@@ -0,0 +1,42 @@
+package app
+
+import (
+	"gin-web/app/common/request"
+	"gin-web/app/common/response"
+	"gin-web/app/services"
+	"github.com/gin-gonic/gin"
+)
+
+// SmsLoginCode 发送登录验证码
+func SmsLoginCode(c *gin.Context) {
+	var form request.SmsCode
+	if err := c.ShouldBindJSON(&form); err != nil {
+		response.ValidateFail(c, request.GetErrorMsg(form, err))
+		return
+	}
+	if err := services.SmsCodeService.SendCode(services.SmsSceneLogin, form.Mobile); err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	response.Success(c, nil)
+}
+
+// SmsLogin 短信验证码登录，手机号未注册时自动注册
+func SmsLogin(c *gin.Context) {
+	var form request.SmsLogin
+	if err := c.ShouldBindJSON(&form); err != nil {
+		response.ValidateFail(c, request.GetErrorMsg(form, err))
+		return
+	}
+	if err := services.SmsCodeService.VerifyCode(services.SmsSceneLogin, form.Mobile, form.Code); err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+
+	err, user := services.UserService.LoginByMobile(form.Mobile)
+	if err != nil {
+		response.BusinessFail(c, err.Error())
+		return
+	}
+	respondLogin(c, user)
+}
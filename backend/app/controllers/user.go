@@ -5,6 +5,7 @@ import (
 	"gin-web/app/common/response"
 	"gin-web/app/services"
 	"github.com/gin-gonic/gin"
+	"strconv"
 )
 
 // Register 用户注册
@@ -21,3 +22,29 @@ func Register(c *gin.Context) {
 		response.Success(c, user)
 	}
 }
+
+// sessionClient 登录会话的客户端信息
+func sessionClient(c *gin.Context) services.SessionClient {
+	return services.SessionClient{UserAgent: c.Request.UserAgent(), IP: c.ClientIP()}
+}
+
+// currentUserID 当前登录用户ID，需配合 JWTAuth 中间件使用
+func currentUserID(c *gin.Context) (uint, bool) {
+	id, ok := c.Get("id")
+	if !ok {
+		return 0, false
+	}
+	userID, err := strconv.ParseUint(id.(string), 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint(userID), true
+}
+
+// currentGuard 当前 token 所属 guard，个人访问令牌等未写入时视为前台用户
+func currentGuard(c *gin.Context) string {
+	if guard, ok := c.Get("guard"); ok {
+		return guard.(string)
+	}
+	return services.AppGuardName
+}
@@ -0,0 +1,15 @@
+package mailer
+
+import "context"
+
+// Message 邮件内容
+type Message struct {
+	To      string
+	Subject string
+	Body    string // HTML 正文
+}
+
+// Mailer 邮件发送接口，按配置选择 SMTP 或仅写日志的实现
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
@@ -0,0 +1,44 @@
+package mailer
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestSmtpMailerTimeout(t *testing.T) {
+	// 接受连接但从不发送问候语的 SMTP 服务
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	m := NewSmtpMailer("127.0.0.1", addr.Port, "", "", "no-reply@example.com", "test", 100*time.Millisecond)
+	start := time.Now()
+	// 调用方的 ctx 没有截止时间
+	if err = m.Send(context.Background(), Message{To: "user@example.com", Subject: "test"}); err == nil {
+		t.Fatal("send succeeded against a silent server")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("send blocked for %v", elapsed)
+	}
+}
+
+func TestSmtpMailerDefaultTimeout(t *testing.T) {
+	m := NewSmtpMailer("localhost", 25, "", "", "no-reply@example.com", "test", 0)
+	if m.timeout != defaultSendTimeout {
+		t.Fatalf("timeout = %v, want %v", m.timeout, defaultSendTimeout)
+	}
+}
@@ -10,7 +10,7 @@ func Cors() gin.HandlerFunc {
 	config.AllowAllOrigins = true
 	config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization"}
 	config.AllowCredentials = true
-	config.ExposeHeaders = []string{"New-Token", "New-Expires-In", "Content-Disposition"}
+	config.ExposeHeaders = []string{"Content-Disposition"}
 
 	return cors.New(config)
 }
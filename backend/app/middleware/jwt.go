@@ -3,59 +3,58 @@ package middleware
 import (
 	"gin-web/app/common/response"
 	"gin-web/app/services"
-	"gin-web/global"
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
-	"strconv"
-	"time"
+	"github.com/golang-jwt/jwt/v5"
 )
 
+// parseToken 解析并校验请求头中的 token，校验失败返回 false
+func parseToken(c *gin.Context, GuardName string) (*jwt.Token, *services.CustomClaims, bool) {
+	tokenStr := c.Request.Header.Get("Authorization")
+	if len(tokenStr) <= len(services.TokenType)+1 {
+		return nil, nil, false
+	}
+	tokenStr = tokenStr[len(services.TokenType)+1:]
+
+	// Token 解析校验
+	token, err := services.JwtService.ParseToken(tokenStr)
+	if err != nil || services.JwtService.IsInBlacklist(GuardName, tokenStr) {
+		return nil, nil, false
+	}
+
+	claims := token.Claims.(*services.CustomClaims)
+	// Token 发布者校验
+	if claims.Issuer != GuardName {
+		return nil, nil, false
+	}
+	// 所属会话已被吊销（登出、在其他设备下线、refresh token 重复使用）
+	if !services.SessionService.Touch(GuardName, claims.FamilyID, c.ClientIP()) {
+		return nil, nil, false
+	}
+	return token, claims, true
+}
+
 func JWTAuth(GuardName string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		tokenStr := c.Request.Header.Get("Authorization")
-		if tokenStr == "" {
+		token, claims, ok := parseToken(c, GuardName)
+		if !ok {
 			response.TokenFail(c)
 			c.Abort()
 			return
 		}
-		tokenStr = tokenStr[len(services.TokenType)+1:]
 
-		// Token 解析校验
-		token, err := jwt.ParseWithClaims(tokenStr, &services.CustomClaims{}, func(token *jwt.Token) (interface{}, error) {
-			return []byte(global.App.Config.Jwt.Secret), nil
-		})
-		if err != nil || services.JwtService.IsInBlacklist(tokenStr) {
-			response.TokenFail(c)
-			c.Abort()
-			return
-		}
-
-		claims := token.Claims.(*services.CustomClaims)
-		// Token 发布者校验
-		if claims.Issuer != GuardName {
-			response.TokenFail(c)
-			c.Abort()
-			return
-		}
+		c.Set("token", token)
+		c.Set("id", claims.ID)
+		c.Set("guard", GuardName)
+	}
+}
 
-		// token 续签
-		if claims.ExpiresAt-time.Now().Unix() < global.App.Config.Jwt.RefreshGracePeriod {
-			lock := global.Lock("refresh_token_lock", global.App.Config.Jwt.JwtBlacklistGracePeriod)
-			if lock.Get() {
-				err, user := services.JwtService.GetUserInfo(GuardName, claims.Id)
-				if err != nil {
-					global.App.Log.Error(err.Error())
-					lock.Release()
-				} else {
-					tokenData, _, _ := services.JwtService.CreateToken(GuardName, user)
-					c.Header("new-token", tokenData.AccessToken)
-					c.Header("new-expires-in", strconv.Itoa(tokenData.ExpiresIn))
-					_ = services.JwtService.JoinBlackList(token)
-				}
-			}
+// JWTAuthOptional 可选登录，token 有效时写入用户信息，无效或缺失时按游客继续处理
+func JWTAuthOptional(GuardName string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token, claims, ok := parseToken(c, GuardName); ok {
+			c.Set("token", token)
+			c.Set("id", claims.ID)
+			c.Set("guard", GuardName)
 		}
-
-		c.Set("token", token)
-		c.Set("id", claims.Id)
 	}
 }
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"gin-web/app/common/response"
+	"gin-web/app/services"
+	"gin-web/global"
+	"github.com/gin-gonic/gin"
+	"strconv"
+)
+
+// RequirePermission 校验当前用户是否拥有全部权限，需在 JWTAuth 之后使用
+// 角色要求开启两步验证（mfa.enforce_roles）而用户尚未开启时拒绝访问
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := c.Get("id")
+		if !ok {
+			response.TokenFail(c)
+			c.Abort()
+			return
+		}
+		userID, err := strconv.ParseUint(id.(string), 10, 32)
+		if err != nil {
+			response.TokenFail(c)
+			c.Abort()
+			return
+		}
+
+		err, access := services.RbacService.GetUserAccess(uint(userID))
+		if err != nil || !access.HasPermissions(permissions...) {
+			response.ForbiddenFail(c)
+			c.Abort()
+			return
+		}
+		if services.MfaService.Required(access) && !services.MfaService.IsEnabled(services.AppGuardName, uint(userID)) {
+			response.FailByError(c, global.Errors.MfaRequired)
+			c.Abort()
+			return
+		}
+	}
+}
+
+// RequireAdminPermission 校验当前后台账号是否拥有全部权限，需在 JWTAuth(AdminGuardName) 之后使用
+func RequireAdminPermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := c.Get("id")
+		if !ok {
+			response.TokenFail(c)
+			c.Abort()
+			return
+		}
+		adminID, err := strconv.ParseUint(id.(string), 10, 32)
+		if err != nil {
+			response.TokenFail(c)
+			c.Abort()
+			return
+		}
+
+		err, access := services.RbacService.GetAdminAccess(uint(adminID))
+		if err != nil || !access.HasPermissions(permissions...) {
+			response.ForbiddenFail(c)
+			c.Abort()
+			return
+		}
+	}
+}
+
+// RequireMfa 要求当前账号已开启两步验证，用于整个 guard 强制两步验证，需在 JWTAuth 之后使用
+// 登录时已强制完成绑定，这里拦截开启要求之前签发、尚未过期的 token
+func RequireMfa(GuardName string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := c.Get("id")
+		if !ok {
+			response.TokenFail(c)
+			c.Abort()
+			return
+		}
+		userID, err := strconv.ParseUint(id.(string), 10, 32)
+		if err != nil {
+			response.TokenFail(c)
+			c.Abort()
+			return
+		}
+
+		if !services.MfaService.IsEnabled(GuardName, uint(userID)) {
+			response.FailByError(c, global.Errors.MfaRequired)
+			c.Abort()
+			return
+		}
+	}
+}
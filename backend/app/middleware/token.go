@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"gin-web/app/common/response"
+	"gin-web/app/services"
+	"github.com/gin-gonic/gin"
+	"strconv"
+)
+
+// JWTOrTokenAuth 同时接受前台用户的 JWT 和个人访问令牌，个人访问令牌需包含指定权限范围
+// JWT 视为拥有全部权限范围，两种方式都会写入用户ID，可继续叠加 RequirePermission
+func JWTOrTokenAuth(scope string) gin.HandlerFunc {
+	jwtAuth := JWTAuth(services.AppGuardName)
+	return func(c *gin.Context) {
+		tokenStr := c.Request.Header.Get("Authorization")
+		if len(tokenStr) <= len(services.TokenType)+1 || !services.IsPersonalAccessToken(tokenStr[len(services.TokenType)+1:]) {
+			jwtAuth(c)
+			return
+		}
+		tokenStr = tokenStr[len(services.TokenType)+1:]
+
+		err, token := services.PersonalAccessTokenService.Authenticate(tokenStr, c.ClientIP())
+		if err != nil {
+			response.TokenFail(c)
+			c.Abort()
+			return
+		}
+		if !token.HasScope(scope) {
+			response.ForbiddenFail(c)
+			c.Abort()
+			return
+		}
+
+		c.Set("id", strconv.Itoa(int(token.UserID)))
+		c.Set("token_scopes", token.ScopeList())
+	}
+}
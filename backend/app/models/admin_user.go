@@ -0,0 +1,29 @@
+package models
+
+import (
+	"strconv"
+	"time"
+)
+
+// 管理后台账号状态
+const (
+	AdminUserStatusEnabled  = 1
+	AdminUserStatusDisabled = 2
+)
+
+// AdminUser 管理后台账号，与前台用户 User 完全独立，使用 admin guard 颁发 token
+type AdminUser struct {
+	ID
+	Username    string     `json:"username" gorm:"size:50;not null;uniqueIndex;comment:登录名"`
+	Name        string     `json:"name" gorm:"size:50;not null;default:'';comment:显示名称"`
+	Password    string     `json:"-" gorm:"not null;default:'';comment:密码"`
+	Status      int8       `json:"status" gorm:"not null;default:1;comment:状态 1启用 2禁用"`
+	LastLoginAt *time.Time `json:"last_login_at" gorm:"comment:最后登录时间"`
+	Roles       []Role     `json:"roles,omitempty" gorm:"many2many:admin_user_roles;"`
+	Timestamps
+	SoftDeletes
+}
+
+func (adminUser AdminUser) GetUid() string {
+	return strconv.Itoa(int(adminUser.ID.ID))
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// 作者署名认领申请状态
+const (
+	AuthorClaimStatusPending  = 1
+	AuthorClaimStatusApproved = 2
+	AuthorClaimStatusRejected = 3
+)
+
+// AuthorClaim 作者署名认领申请，版主审核通过后才将该署名下的历史 mod 归属到申请人
+type AuthorClaim struct {
+	ID
+	UserID     uint       `json:"user_id" gorm:"not null;index;comment:申请人用户ID"`
+	Alias      string     `json:"alias" gorm:"size:100;not null;index;comment:认领的作者署名"`
+	Evidence   string     `json:"evidence" gorm:"type:text;comment:所有权证明（原发布页链接、说明等）"`
+	Status     int8       `json:"status" gorm:"not null;default:1;index;comment:状态 1待审核 2已通过 3已拒绝"`
+	ReviewerID *uint      `json:"reviewer_id" gorm:"comment:审核人用户ID"`
+	ReviewNote string     `json:"review_note" gorm:"size:500;not null;default:'';comment:审核意见"`
+	ReviewedAt *time.Time `json:"reviewed_at" gorm:"comment:审核时间"`
+	Timestamps
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// JwtKey JWT 签名密钥，kid 写入 token header 用于验签时选择公钥
+// 新密钥先在 JWKS 中公开，到 ActivatesAt 才开始签发；轮换后旧密钥标记 RetiredAt，不再签发但在 access token 有效期内仍可验签
+type JwtKey struct {
+	ID
+	Kid         string     `json:"kid" gorm:"size:64;not null;uniqueIndex;comment:密钥ID"`
+	Algorithm   string     `json:"algorithm" gorm:"size:20;not null;comment:签名算法"`
+	PrivateKey  string     `json:"-" gorm:"type:text;not null;comment:私钥(PKCS8 PEM)"`
+	PublicKey   string     `json:"public_key" gorm:"type:text;not null;comment:公钥(PKIX PEM)"`
+	ActivatesAt *time.Time `json:"activates_at" gorm:"comment:开始签发时间，为空表示创建后立即签发"`
+	RetiredAt   *time.Time `json:"retired_at" gorm:"index;comment:停止签发时间"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
@@ -9,15 +9,18 @@ type Mod struct {
 	ID            uint    `json:"id" gorm:"primaryKey"`
 	Name          string  `json:"name" gorm:"size:255;not null;index" binding:"required"`
 	Description   string  `json:"description" gorm:"type:text"`
-	Author        string  `json:"author" gorm:"size:100;index"`
+	Author        string  `json:"author" gorm:"size:100;index"` // 作者署名，历史数据未关联用户时仅作展示
 	Version       string  `json:"version" gorm:"size:50"`
 	DownloadURL   string  `json:"download_url" gorm:"size:500"`
 	ImageURL      string  `json:"image_url" gorm:"size:500"`
 	Rating        float64 `json:"rating" gorm:"type:decimal(3,2);default:0"`
 	DownloadCount int     `json:"download_count" gorm:"default:0;index"`
+	ViewCount     int     `json:"view_count" gorm:"default:0"`
 	FileSize      int64   `json:"file_size" gorm:"default:0"`
 
 	// 外键关联
+	UserID     *uint      `json:"user_id" gorm:"index;comment:发布者用户ID"`
+	User       *User      `json:"-" gorm:"foreignKey:UserID"`
 	GameID     uint       `json:"game_id" gorm:"not null;index"`
 	Game       Game       `json:"game" gorm:"foreignKey:GameID"`
 	Categories []Category `json:"categories" gorm:"many2many:gw_mod_categories;"`
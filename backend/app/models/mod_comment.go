@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ModComment mod 评论，ParentID 不为空时为对某条评论的回复
+type ModComment struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	ModID     uint           `json:"mod_id" gorm:"not null;index:idx_mod_comment_mod,priority:1"`
+	UserID    uint           `json:"user_id" gorm:"not null;index"`
+	User      *User          `json:"-" gorm:"foreignKey:UserID"`
+	ParentID  *uint          `json:"parent_id" gorm:"index;comment:回复的评论ID"`
+	Content   string         `json:"content" gorm:"type:text;not null"`
+	CreatedAt time.Time      `json:"created_at" gorm:"index:idx_mod_comment_mod,priority:2"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// TableName 指定表名
+func (ModComment) TableName() string {
+	return "mod_comments"
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// ModDownload 登录用户对 mod 的下载汇总，每个用户每个 mod 一行，用于计算"下载了这个的用户也下载了"
+// 超出统计窗口的记录在离线计算时清理
+type ModDownload struct {
+	ID               uint      `json:"id" gorm:"primaryKey"`
+	UserID           uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_mod_download_user_mod,priority:1"`
+	ModID            uint      `json:"mod_id" gorm:"not null;uniqueIndex:idx_mod_download_user_mod,priority:2;index"`
+	GameID           uint      `json:"game_id" gorm:"not null;index:idx_mod_download_game,priority:1"`
+	Downloads        int       `json:"downloads" gorm:"not null;default:1;comment:下载次数"`
+	LastDownloadedAt time.Time `json:"last_downloaded_at" gorm:"not null;index:idx_mod_download_game,priority:2;index;comment:最近下载时间"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// TableName 指定表名，按用户和 mod 汇总后与旧的逐次下载表 mod_downloads 区分
+func (ModDownload) TableName() string {
+	return "mod_user_downloads"
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// ModFollow 用户关注的 mod，发布新版本时通知关注者
+type ModFollow struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_mod_follow_user_mod,priority:1"`
+	ModID     uint      `json:"mod_id" gorm:"not null;uniqueIndex:idx_mod_follow_user_mod,priority:2;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (ModFollow) TableName() string {
+	return "mod_follows"
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	RecommendSourceCoDownload = "co_download"
+	RecommendSourceCategory   = "category"
+)
+
+// ModRecommendation 离线计算的相关 mod，每个 mod 保留 top-K
+type ModRecommendation struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	ModID        uint      `json:"mod_id" gorm:"not null;uniqueIndex:idx_mod_recommendation,priority:1"`
+	RelatedModID uint      `json:"related_mod_id" gorm:"not null;uniqueIndex:idx_mod_recommendation,priority:2"`
+	Score        float64   `json:"score" gorm:"not null;default:0"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (ModRecommendation) TableName() string {
+	return "mod_recommendations"
+}
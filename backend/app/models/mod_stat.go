@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	StatGranularityDay  = "day"
+	StatGranularityHour = "hour"
+)
+
+// ModStat mod 浏览、下载按天/小时聚合的统计
+type ModStat struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	ModID       uint      `json:"mod_id" gorm:"not null;uniqueIndex:idx_mod_stat_period,priority:1"`
+	Granularity string    `json:"granularity" gorm:"size:10;not null;uniqueIndex:idx_mod_stat_period,priority:2"`
+	PeriodStart time.Time `json:"period_start" gorm:"not null;uniqueIndex:idx_mod_stat_period,priority:3"`
+	Version     string    `json:"version" gorm:"size:50;not null;default:'';uniqueIndex:idx_mod_stat_period,priority:4"`
+	Views       int64     `json:"views" gorm:"not null;default:0"`
+	Downloads   int64     `json:"downloads" gorm:"not null;default:0"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (ModStat) TableName() string {
+	return "mod_stats"
+}
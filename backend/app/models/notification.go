@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+)
+
+// 通知类型
+const (
+	NotificationTypeCommentReply = "comment_reply"     // 评论被回复
+	NotificationTypeModVersion   = "mod_new_version"   // 关注的 mod 发布新版本
+	NotificationTypeModeration   = "moderation_result" // 审核结果
+)
+
+// NotificationTypes 全部通知类型，用于展示偏好设置
+var NotificationTypes = []string{
+	NotificationTypeCommentReply,
+	NotificationTypeModVersion,
+	NotificationTypeModeration,
+}
+
+// Notification 站内通知
+type Notification struct {
+	ID
+	UserID    uint       `json:"user_id" gorm:"not null;index:idx_notification_user_read,priority:1;uniqueIndex:idx_notification_event,priority:2;comment:接收用户ID"`
+	Type      string     `json:"type" gorm:"size:50;not null;comment:通知类型"`
+	Title     string     `json:"title" gorm:"size:255;not null;default:'';comment:标题"`
+	Content   string     `json:"content" gorm:"type:text;comment:内容"`
+	Data      string     `json:"data" gorm:"type:text;comment:跳转等附加数据(JSON)"`
+	EventID   *string    `json:"-" gorm:"size:64;uniqueIndex:idx_notification_event,priority:1;comment:来源事件ID，同一事件对同一用户只通知一次"`
+	ReadAt    *time.Time `json:"read_at" gorm:"index:idx_notification_user_read,priority:2;comment:已读时间"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// NotificationPreference 用户按类型的通知开关，无记录时默认接收
+type NotificationPreference struct {
+	ID
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_notification_preference,priority:1"`
+	Type      string    `json:"type" gorm:"size:50;not null;uniqueIndex:idx_notification_preference,priority:2"`
+	Enabled   bool      `json:"enabled" gorm:"not null"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// OutboxMessage 待发布的领域事件，与业务数据在同一事务中写入，由 relay 任务投递到消息队列
+type OutboxMessage struct {
+	ID
+	MessageID     string     `json:"message_id" gorm:"size:64;not null;uniqueIndex;comment:消息ID，重复投递时供消费方去重"`
+	AggregateType string     `json:"aggregate_type" gorm:"size:50;not null;index:idx_outbox_aggregate,priority:1;comment:聚合类型"`
+	AggregateID   string     `json:"aggregate_id" gorm:"size:64;not null;index:idx_outbox_aggregate,priority:2;comment:聚合ID，同一聚合的消息按写入顺序投递"`
+	Queue         string     `json:"queue" gorm:"size:255;not null;comment:目标队列"`
+	Event         string     `json:"event" gorm:"size:100;not null;comment:事件名称"`
+	SchemaVersion int        `json:"schema_version" gorm:"not null;default:1;comment:消息体 schema 版本"`
+	Body          string     `json:"body" gorm:"type:mediumtext;comment:消息体"`
+	Attempts      int        `json:"attempts" gorm:"not null;default:0;comment:投递失败次数"`
+	LastError     string     `json:"last_error" gorm:"size:500;not null;default:'';comment:最近一次投递失败原因"`
+	SentAt        *time.Time `json:"sent_at" gorm:"index;comment:投递成功时间"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
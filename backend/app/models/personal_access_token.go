@@ -0,0 +1,69 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// 个人访问令牌权限范围，admin 包含 publish，publish 包含 read
+const (
+	TokenScopeRead    = "read"
+	TokenScopePublish = "publish"
+	TokenScopeAdmin   = "admin" // 审核类接口，只有拥有审核权限的用户可以创建
+)
+
+// TokenScopes 按权限从低到高排列
+var TokenScopes = []string{TokenScopeRead, TokenScopePublish, TokenScopeAdmin}
+
+// TokenScopeAdminPermissions 创建 admin 范围令牌需拥有其中任一权限
+var TokenScopeAdminPermissions = []string{PermissionModModerate, PermissionCommentModerate}
+
+// PersonalAccessToken 个人访问令牌，用于 CI 等自动化场景，只保存令牌哈希
+type PersonalAccessToken struct {
+	ID
+	UserID     uint       `json:"user_id" gorm:"not null;index;comment:用户ID"`
+	Name       string     `json:"name" gorm:"size:100;not null;comment:令牌名称"`
+	TokenHash  string     `json:"-" gorm:"size:64;not null;uniqueIndex;comment:令牌哈希"`
+	Hint       string     `json:"hint" gorm:"size:20;not null;default:'';comment:令牌末尾几位，便于用户辨认"`
+	Scopes     string     `json:"-" gorm:"size:100;not null;comment:权限范围，逗号分隔"`
+	ExpiresAt  *time.Time `json:"expires_at" gorm:"comment:过期时间，为空表示永不过期"`
+	LastUsedAt *time.Time `json:"last_used_at" gorm:"comment:最后使用时间"`
+	LastUsedIp string     `json:"last_used_ip" gorm:"size:64;not null;default:'';comment:最后使用IP"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// ScopeList 权限范围列表
+func (token PersonalAccessToken) ScopeList() []string {
+	if token.Scopes == "" {
+		return []string{}
+	}
+	return strings.Split(token.Scopes, ",")
+}
+
+// HasScope 是否包含指定权限范围，高等级范围包含低等级范围
+func (token PersonalAccessToken) HasScope(scope string) bool {
+	required := scopeLevel(scope)
+	if required < 0 {
+		return false
+	}
+	for _, s := range token.ScopeList() {
+		if scopeLevel(s) >= required {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTokenScope 是否为已定义的权限范围
+func IsTokenScope(scope string) bool {
+	return scopeLevel(scope) >= 0
+}
+
+func scopeLevel(scope string) int {
+	for i, s := range TokenScopes {
+		if s == scope {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+)
+
+// 内置角色
+const (
+	RoleUser      = "user"
+	RoleAuthor    = "author"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// 内置权限
+const (
+	PermissionModPublish      = "mod.publish"      // 发布、更新自己的 mod
+	PermissionModModerate     = "mod.moderate"     // 审核、下架任意 mod
+	PermissionCommentModerate = "comment.moderate" // 审核、删除评论
+	PermissionUserManage      = "user.manage"      // 管理用户
+	PermissionRoleAssign      = "role.assign"      // 分配角色
+	PermissionQueueManage     = "queue.manage"     // 查看、重放死信及消费统计
+)
+
+// Permission 权限
+type Permission struct {
+	ID
+	Name        string    `json:"name" gorm:"size:100;not null;uniqueIndex;comment:权限标识"`
+	DisplayName string    `json:"display_name" gorm:"size:100;not null;default:'';comment:权限名称"`
+	CreatedAt   time.Time `json:"-"`
+}
+
+// Role 角色
+type Role struct {
+	ID
+	Name        string       `json:"name" gorm:"size:50;not null;uniqueIndex;comment:角色标识"`
+	DisplayName string       `json:"display_name" gorm:"size:100;not null;default:'';comment:角色名称"`
+	Permissions []Permission `json:"permissions,omitempty" gorm:"many2many:role_permissions;"`
+	CreatedAt   time.Time    `json:"-"`
+	UpdatedAt   time.Time    `json:"-"`
+}
+
+// DefaultPermissions 内置权限及名称
+var DefaultPermissions = map[string]string{
+	PermissionModPublish:      "发布 mod",
+	PermissionModModerate:     "审核 mod",
+	PermissionCommentModerate: "审核评论",
+	PermissionUserManage:      "管理用户",
+	PermissionRoleAssign:      "分配角色",
+	PermissionQueueManage:     "管理消息队列",
+}
+
+// DefaultRoles 内置角色及其权限，启动时同步到数据库；前台用户和后台账号共用同一套角色
+var DefaultRoles = []struct {
+	Name        string
+	DisplayName string
+	Permissions []string
+}{
+	{RoleUser, "普通用户", []string{}},
+	{RoleAuthor, "作者", []string{PermissionModPublish}},
+	{RoleModerator, "版主", []string{PermissionModPublish, PermissionModModerate, PermissionCommentModerate}},
+	{RoleAdmin, "管理员", []string{PermissionModPublish, PermissionModModerate, PermissionCommentModerate, PermissionUserManage, PermissionRoleAssign, PermissionQueueManage}},
+}
@@ -2,13 +2,21 @@ package models
 
 import (
 	"strconv"
+	"time"
 )
 
 type User struct {
 	ID
-	Name     string `json:"name1" gorm:"not null;comment:用户名称"`
-	Mobile   string `json:"mobile2" gorm:"not null;index;comment:用户手机号"`
-	Password string `json:"-" gorm:"not null;default:'';comment:用户密码"` //密码不反回
+	Name                string     `json:"name" gorm:"not null;comment:用户名称"`
+	Mobile              string     `json:"mobile" gorm:"not null;index;comment:用户手机号"`
+	Password            string     `json:"-" gorm:"not null;default:'';comment:用户密码"` //密码不反回
+	Email               *string    `json:"email" gorm:"size:255;uniqueIndex;comment:邮箱"`
+	EmailVerifiedAt     *time.Time `json:"email_verified_at" gorm:"comment:邮箱验证时间"`
+	Avatar              string     `json:"avatar" gorm:"size:500;not null;default:'';comment:头像地址"`
+	Bio                 string     `json:"bio" gorm:"type:text;comment:个人简介"`
+	AuthorAlias         *string    `json:"author_alias" gorm:"size:100;uniqueIndex;comment:已认领的作者署名"` // 认领后历史 mod 的 author 字段归属到该用户
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at" gorm:"index;comment:计划注销时间"`         // 冷静期内可撤销，到期后由定时任务清除账号数据
+	Roles               []Role     `json:"roles,omitempty" gorm:"many2many:user_roles;"`
 	Timestamps
 	SoftDeletes
 }
@@ -0,0 +1,12 @@
+package models
+
+// UserIdentity 第三方身份与用户的绑定关系，同一 provider 下 subject 唯一
+type UserIdentity struct {
+	ID
+	UserID   uint   `json:"user_id" gorm:"not null;index;comment:用户ID"`
+	Provider string `json:"provider" gorm:"size:50;not null;uniqueIndex:idx_provider_subject;comment:身份提供方"`
+	Subject  string `json:"-" gorm:"size:255;not null;uniqueIndex:idx_provider_subject;comment:提供方用户唯一标识(sub)"`
+	Email    string `json:"email" gorm:"size:255;not null;default:'';comment:提供方返回的邮箱"`
+	Name     string `json:"name" gorm:"size:100;not null;default:'';comment:提供方返回的名称"`
+	Timestamps
+}
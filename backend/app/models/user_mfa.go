@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// UserMfa 两步验证（TOTP）设置，EnabledAt 为空表示已生成密钥但尚未完成绑定
+// 前台用户和后台账号的 ID 相互独立，按 guard 区分
+type UserMfa struct {
+	ID
+	Guard        string     `json:"guard" gorm:"size:20;not null;default:'app';uniqueIndex:idx_user_mfa_guard_user,priority:1;comment:账号类型 app/admin"`
+	UserID       uint       `json:"user_id" gorm:"not null;uniqueIndex:idx_user_mfa_guard_user,priority:2;comment:用户ID"`
+	Secret       string     `json:"-" gorm:"size:64;not null;comment:TOTP 密钥(base32)"`
+	EnabledAt    *time.Time `json:"enabled_at" gorm:"comment:启用时间"`
+	LastUsedStep int64      `json:"-" gorm:"not null;default:0;comment:最近一次通过校验的时间步，防止验证码重放"`
+	Timestamps
+}
+
+// UserRecoveryCode 两步验证恢复码，只保存哈希，每个只能使用一次
+type UserRecoveryCode struct {
+	ID
+	Guard    string     `json:"guard" gorm:"size:20;not null;default:'app';index:idx_user_recovery_code_guard_user,priority:1;comment:账号类型 app/admin"`
+	UserID   uint       `json:"user_id" gorm:"not null;index:idx_user_recovery_code_guard_user,priority:2;comment:用户ID"`
+	CodeHash string     `json:"-" gorm:"size:64;not null;comment:恢复码哈希"`
+	UsedAt   *time.Time `json:"used_at" gorm:"comment:使用时间"`
+}
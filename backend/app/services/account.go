@@ -0,0 +1,393 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"gin-web/app/common/request"
+	"gin-web/app/common/response"
+	"gin-web/app/models"
+	"gin-web/global"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 短信验证码场景：更换手机号、未设置密码时确认身份
+const (
+	SmsSceneChangeMobile   = "change_mobile"
+	SmsSceneVerifyIdentity = "verify_identity"
+)
+
+const (
+	defaultDeletionGracePeriod = 14 * 24 * 3600
+	// 每次清理的账号数上限，避免单次任务过长
+	accountPurgeBatchSize = 100
+	// 注销后保留的展示名称
+	deletedUserName = "已注销用户"
+	// 重新通过第三方账号验证身份后，敏感操作的有效期
+	identityReauthTtl = 5 * time.Minute
+)
+
+type accountService struct{}
+
+var AccountService = new(accountService)
+
+var (
+	ErrPasswordRequired   = errors.New("请输入当前密码")
+	ErrPasswordInvalid    = errors.New("当前密码错误")
+	ErrMfaCodeRequired    = errors.New("请输入两步验证码")
+	ErrVerifyCodeRequired = errors.New("请输入发送到当前手机号的验证码")
+	ErrReauthRequired     = errors.New("请重新通过第三方账号验证身份")
+)
+
+// getUser 获取当前用户
+func (s *accountService) getUser(userID uint) (user models.User, err error) {
+	if err = global.App.DB.First(&user, userID).Error; err != nil {
+		err = errors.New("用户不存在")
+	}
+	return
+}
+
+// verifyIdentity 敏感操作前的身份确认：已设置密码时校验当前密码，已开启两步验证时校验验证码
+// 两者都没有（短信、第三方登录注册）时，校验发送到当前手机号的验证码，未绑定手机号时需先重新通过第三方账号验证
+// 密码错误计入登录失败次数，避免借助已登录的 token 暴力尝试密码
+func (s *accountService) verifyIdentity(user models.User, password string, mfaCode string, verifyCode string, ip string) error {
+	mfaEnabled := MfaService.IsEnabled(AppGuardName, user.ID.ID)
+	if user.Password != "" {
+		if password == "" {
+			return ErrPasswordRequired
+		}
+		if err := LoginThrottleService.Check(AppGuardName, user.Mobile, ip); err != nil {
+			return err
+		}
+		if !PasswordService.Verify(password, user.Password) {
+			LoginThrottleService.RecordFailure(AppGuardName, user.Mobile, ip)
+			return ErrPasswordInvalid
+		}
+	} else if !mfaEnabled {
+		if err := s.verifyWithoutCredential(user, verifyCode); err != nil {
+			return err
+		}
+	}
+	if mfaEnabled {
+		if mfaCode == "" {
+			return ErrMfaCodeRequired
+		}
+		if err := MfaService.Verify(AppGuardName, user.ID.ID, mfaCode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyWithoutCredential 没有密码和两步验证的账号：优先使用近期的第三方账号验证，否则校验当前手机号验证码
+func (s *accountService) verifyWithoutCredential(user models.User, verifyCode string) error {
+	if s.consumeReauth(user.ID.ID) {
+		return nil
+	}
+	if user.Mobile == "" {
+		return ErrReauthRequired
+	}
+	if verifyCode == "" {
+		return ErrVerifyCodeRequired
+	}
+	return SmsCodeService.VerifyCode(SmsSceneVerifyIdentity, user.Mobile, verifyCode)
+}
+
+// SendIdentityCode 向当前手机号发送身份确认验证码
+func (s *accountService) SendIdentityCode(userID uint) error {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return err
+	}
+	if user.Mobile == "" {
+		return ErrReauthRequired
+	}
+	return SmsCodeService.SendCode(SmsSceneVerifyIdentity, user.Mobile)
+}
+
+// 获取第三方账号重新验证标记缓存 key
+func (s *accountService) getReauthKey(userID uint) string {
+	return "account_reauth:" + strconv.Itoa(int(userID))
+}
+
+// markReauth 已登录用户通过已绑定的第三方账号重新验证身份，短时间内可完成一次敏感操作
+func (s *accountService) markReauth(userID uint) {
+	if err := global.App.Redis.Set(context.Background(), s.getReauthKey(userID), 1, identityReauthTtl).Err(); err != nil {
+		global.App.Log.Error("mark account reauth failed", zap.Uint("user_id", userID), zap.Error(err))
+	}
+}
+
+// consumeReauth 取出并删除重新验证标记，保证只能使用一次
+func (s *accountService) consumeReauth(userID uint) bool {
+	return global.App.Redis.Del(context.Background(), s.getReauthKey(userID)).Val() == 1
+}
+
+// UpdateProfile 修改个人资料
+func (s *accountService) UpdateProfile(userID uint, params request.UpdateProfile) (err error, user models.User) {
+	if user, err = s.getUser(userID); err != nil {
+		return
+	}
+	columns := map[string]interface{}{}
+	if params.Name != nil {
+		columns["name"] = *params.Name
+	}
+	if params.Avatar != nil {
+		columns["avatar"] = *params.Avatar
+	}
+	if params.Bio != nil {
+		columns["bio"] = *params.Bio
+	}
+	if len(columns) == 0 {
+		return
+	}
+	if err = global.App.DB.Model(&user).Updates(columns).Error; err != nil {
+		return
+	}
+	user, err = s.getUser(userID)
+	return
+}
+
+// ChangePassword 修改密码，成功后下线除当前会话外的全部会话
+func (s *accountService) ChangePassword(userID uint, params request.ChangePassword, ip string, currentFamilyID string) error {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return err
+	}
+	if err = s.verifyIdentity(user, params.OldPassword, params.MfaCode, params.VerifyCode, ip); err != nil {
+		return err
+	}
+
+	hash, err := PasswordService.Hash(params.Password)
+	if err != nil {
+		return err
+	}
+	if err = global.App.DB.Model(&user).UpdateColumn("password", hash).Error; err != nil {
+		return err
+	}
+
+	if _, revokeErr := SessionService.RevokeOthers(AppGuardName, user.GetUid(), currentFamilyID); revokeErr != nil {
+		global.App.Log.Error("revoke sessions after password change failed", zap.Uint("user_id", userID), zap.Error(revokeErr))
+	}
+	// 尚未使用的重置密码链接随之作废
+	EmailService.invalidateReset(userID)
+	return nil
+}
+
+// SendChangeMobileCode 向新手机号发送验证码
+func (s *accountService) SendChangeMobileCode(userID uint, mobile string) error {
+	if err := s.checkMobileAvailable(userID, mobile); err != nil {
+		return err
+	}
+	return SmsCodeService.SendCode(SmsSceneChangeMobile, mobile)
+}
+
+// checkMobileAvailable 新手机号不能与当前相同，也不能已被其他账号使用
+func (s *accountService) checkMobileAvailable(userID uint, mobile string) error {
+	var user models.User
+	result := global.App.DB.Where("mobile = ?", mobile).Select("id").Limit(1).Find(&user)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+	if user.ID.ID == userID {
+		return errors.New("新手机号不能与当前手机号相同")
+	}
+	return errors.New("手机号已被其他账号使用")
+}
+
+// ChangeMobile 校验当前身份和新手机号验证码后更换手机号
+func (s *accountService) ChangeMobile(userID uint, params request.ChangeMobile, ip string) (err error, user models.User) {
+	if user, err = s.getUser(userID); err != nil {
+		return
+	}
+	if err = s.verifyIdentity(user, params.Password, params.MfaCode, params.VerifyCode, ip); err != nil {
+		return
+	}
+	if err = s.checkMobileAvailable(userID, params.Mobile); err != nil {
+		return
+	}
+	if err = SmsCodeService.VerifyCode(SmsSceneChangeMobile, params.Mobile, params.Code); err != nil {
+		return
+	}
+	if err = global.App.DB.Model(&user).UpdateColumn("mobile", params.Mobile).Error; err != nil {
+		return
+	}
+	global.App.Log.Info("security event",
+		zap.String("event", "mobile_changed"), zap.Uint("user_id", userID), zap.String("ip", ip))
+	user.Mobile = params.Mobile
+	return
+}
+
+func (s *accountService) deletionGracePeriod() time.Duration {
+	period := global.App.Config.Account.DeletionGracePeriod
+	if period <= 0 {
+		period = defaultDeletionGracePeriod
+	}
+	return time.Duration(period) * time.Second
+}
+
+// RequestDeletion 申请注销账号，冷静期内可撤销，同时下线其他会话并吊销个人访问令牌
+func (s *accountService) RequestDeletion(userID uint, params request.DeleteAccount, ip string, currentFamilyID string) (err error, result response.AccountDeletion) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return
+	}
+	if user.DeletionScheduledAt != nil {
+		result.ScheduledAt = *user.DeletionScheduledAt
+		return
+	}
+	if err = s.verifyIdentity(user, params.Password, params.MfaCode, params.VerifyCode, ip); err != nil {
+		return
+	}
+
+	scheduledAt := time.Now().Add(s.deletionGracePeriod())
+	if err = global.App.DB.Model(&user).UpdateColumn("deletion_scheduled_at", scheduledAt).Error; err != nil {
+		return
+	}
+	global.App.DB.Where("user_id = ?", userID).Delete(&models.PersonalAccessToken{})
+	SessionService.RevokeOthers(AppGuardName, user.GetUid(), currentFamilyID)
+	global.App.Log.Info("security event",
+		zap.String("event", "account_deletion_requested"), zap.Uint("user_id", userID), zap.String("ip", ip))
+
+	result.ScheduledAt = scheduledAt
+	return
+}
+
+// CancelDeletion 撤销注销申请
+func (s *accountService) CancelDeletion(userID uint) error {
+	result := global.App.DB.Model(&models.User{}).
+		Where("id = ? AND deletion_scheduled_at IS NOT NULL", userID).
+		UpdateColumn("deletion_scheduled_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("没有待处理的注销申请")
+	}
+	return nil
+}
+
+// PurgeDeleted 清除冷静期已过的账号：删除账号关联数据，用户记录匿名化后软删除
+// 已发布的 mod 保留，仅解除与账号的关联；多实例部署时通过分布式锁保证只有一个实例执行
+func (s *accountService) PurgeDeleted() {
+	lock := global.Lock("account_purge_lock", 300)
+	if !lock.Get() {
+		return
+	}
+	defer lock.Release()
+
+	var users []models.User
+	if err := global.App.DB.Where("deletion_scheduled_at <= ?", time.Now()).
+		Limit(accountPurgeBatchSize).Find(&users).Error; err != nil {
+		global.App.Log.Error("query accounts to purge failed", zap.Error(err))
+		return
+	}
+	for _, user := range users {
+		if err := s.purge(user); err != nil {
+			global.App.Log.Error("purge account failed", zap.Uint("user_id", user.ID.ID), zap.Error(err))
+			continue
+		}
+		global.App.Log.Info("security event", zap.String("event", "account_purged"), zap.Uint("user_id", user.ID.ID))
+	}
+}
+
+func (s *accountService) purge(user models.User) error {
+	userID := user.ID.ID
+	err := global.App.DB.Transaction(func(tx *gorm.DB) error {
+		if err := MfaService.delete(tx, AppGuardName, userID); err != nil {
+			return err
+		}
+		for _, model := range []interface{}{
+			&models.UserIdentity{},
+			&models.PersonalAccessToken{},
+			&models.ModDownload{},
+			&models.Notification{},
+			&models.NotificationPreference{},
+			&models.ModFollow{},
+			&models.AuthorClaim{},
+		} {
+			if err := tx.Where("user_id = ?", userID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&models.Mod{}).Where("user_id = ?", userID).UpdateColumn("user_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&user).Association("Roles").Clear(); err != nil {
+			return err
+		}
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"name":                  deletedUserName,
+			"mobile":                "",
+			"password":              "",
+			"email":                 nil,
+			"email_verified_at":     nil,
+			"avatar":                "",
+			"bio":                   "",
+			"author_alias":          nil,
+			"deletion_scheduled_at": nil,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&user).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	SessionService.RevokeOthers(AppGuardName, user.GetUid(), "")
+	RbacService.Invalidate(userID)
+	EmailService.invalidateReset(userID)
+	return nil
+}
+
+// Export 导出用户的全部数据
+func (s *accountService) Export(userID uint, currentFamilyID string) (err error, export response.UserExport) {
+	if export.Profile, err = s.getUser(userID); err != nil {
+		return
+	}
+	export.ExportedAt = time.Now()
+
+	if accessErr, access := RbacService.GetUserAccess(userID); accessErr == nil {
+		export.Roles = access.Roles
+	}
+	if err, export.Identities = OauthService.Identities(userID); err != nil {
+		return
+	}
+	if err, export.Mfa = MfaService.Status(AppGuardName, userID); err != nil {
+		return
+	}
+	if export.Sessions, err = SessionService.List(AppGuardName, strconv.Itoa(int(userID)), currentFamilyID); err != nil {
+		return
+	}
+	if err, export.PersonalAccessTokens = PersonalAccessTokenService.List(userID); err != nil {
+		return
+	}
+
+	db := global.App.DB
+	if err = db.Where("user_id = ?", userID).Order("id").Find(&export.Mods).Error; err != nil {
+		return
+	}
+	if err = db.Where("user_id = ?", userID).Order("id").Find(&export.Downloads).Error; err != nil {
+		return
+	}
+	if err = db.Where("user_id = ?", userID).Order("id").Find(&export.Notifications).Error; err != nil {
+		return
+	}
+	if err = db.Where("user_id = ?", userID).Order("id").Find(&export.NotificationPreferences).Error; err != nil {
+		return
+	}
+	if err = db.Where("user_id = ?", userID).Order("id").Find(&export.Follows).Error; err != nil {
+		return
+	}
+	if err = db.Where("user_id = ?", userID).Order("id").Find(&export.Comments).Error; err != nil {
+		return
+	}
+	err = db.Where("user_id = ?", userID).Order("id").Find(&export.AuthorClaims).Error
+	return
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"gin-web/app/common/request"
+	"gin-web/app/models"
+	"gin-web/app/sms"
+	"gin-web/global"
+	"testing"
+)
+
+// recordingSms 记录发送的短信，便于取出验证码
+type recordingSms struct {
+	messages []sms.Message
+}
+
+func (r *recordingSms) Send(ctx context.Context, msg sms.Message) error {
+	r.messages = append(r.messages, msg)
+	return nil
+}
+
+// lastCode 最近一条发往 mobile 的验证码
+func (r *recordingSms) lastCode(t *testing.T, mobile string) string {
+	t.Helper()
+	for i := len(r.messages) - 1; i >= 0; i-- {
+		if r.messages[i].Mobile == mobile {
+			return r.messages[i].Params["code"]
+		}
+	}
+	t.Fatalf("no sms sent to %s", mobile)
+	return ""
+}
+
+func setupAccountTest(t *testing.T) *recordingSms {
+	setupServiceTest(t)
+	sender := &recordingSms{}
+	global.App.Sms = sender
+	return sender
+}
+
+func TestAccountPasswordRequired(t *testing.T) {
+	setupAccountTest(t)
+	user := createTestUser(t, "13800000000")
+	hash, _ := PasswordService.Hash("Old-password1")
+	global.App.DB.Model(&user).UpdateColumn("password", hash)
+
+	params := request.ChangePassword{Password: "New-password1"}
+	if err := AccountService.ChangePassword(user.ID.ID, params, "10.0.0.1", ""); !errors.Is(err, ErrPasswordRequired) {
+		t.Fatalf("err = %v, want ErrPasswordRequired", err)
+	}
+	params.OldPassword = "wrong"
+	if err := AccountService.ChangePassword(user.ID.ID, params, "10.0.0.1", ""); !errors.Is(err, ErrPasswordInvalid) {
+		t.Fatalf("err = %v, want ErrPasswordInvalid", err)
+	}
+	params.OldPassword = "Old-password1"
+	if err := AccountService.ChangePassword(user.ID.ID, params, "10.0.0.1", ""); err != nil {
+		t.Fatal(err)
+	}
+	global.App.DB.First(&user, user.ID.ID)
+	if !PasswordService.Verify("New-password1", user.Password) {
+		t.Fatal("password not changed")
+	}
+}
+
+func TestAccountPasswordlessRequiresMobileCode(t *testing.T) {
+	sender := setupAccountTest(t)
+	user := createTestUser(t, "13800000000")
+
+	// 没有密码和两步验证时，不能只凭登录 token 注销账号
+	params := request.DeleteAccount{}
+	if err, _ := AccountService.RequestDeletion(user.ID.ID, params, "10.0.0.1", ""); !errors.Is(err, ErrVerifyCodeRequired) {
+		t.Fatalf("err = %v, want ErrVerifyCodeRequired", err)
+	}
+	params.VerifyCode = "000000"
+	if err, _ := AccountService.RequestDeletion(user.ID.ID, params, "10.0.0.1", ""); !errors.Is(err, ErrSmsCodeInvalid) {
+		t.Fatalf("err = %v, want ErrSmsCodeInvalid", err)
+	}
+
+	if err := AccountService.SendIdentityCode(user.ID.ID); err != nil {
+		t.Fatal(err)
+	}
+	params.VerifyCode = sender.lastCode(t, user.Mobile)
+	err, result := AccountService.RequestDeletion(user.ID.ID, params, "10.0.0.1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ScheduledAt.IsZero() {
+		t.Fatal("deletion not scheduled")
+	}
+}
+
+func TestAccountChangeMobileVerifiesCurrentMobile(t *testing.T) {
+	sender := setupAccountTest(t)
+	user := createTestUser(t, "13800000000")
+
+	if err := AccountService.SendChangeMobileCode(user.ID.ID, "13900000000"); err != nil {
+		t.Fatal(err)
+	}
+	params := request.ChangeMobile{Mobile: "13900000000", Code: sender.lastCode(t, "13900000000")}
+	// 只验证新手机号不够，还需确认当前手机号
+	if err, _ := AccountService.ChangeMobile(user.ID.ID, params, "10.0.0.1"); !errors.Is(err, ErrVerifyCodeRequired) {
+		t.Fatalf("err = %v, want ErrVerifyCodeRequired", err)
+	}
+	// 更换手机号的验证码与身份确认验证码不通用
+	params.VerifyCode = params.Code
+	if err, _ := AccountService.ChangeMobile(user.ID.ID, params, "10.0.0.1"); !errors.Is(err, ErrSmsCodeInvalid) {
+		t.Fatalf("err = %v, want ErrSmsCodeInvalid", err)
+	}
+
+	AccountService.SendIdentityCode(user.ID.ID)
+	params.VerifyCode = sender.lastCode(t, "13800000000")
+	err, updated := AccountService.ChangeMobile(user.ID.ID, params, "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Mobile != "13900000000" {
+		t.Fatalf("mobile = %s, want 13900000000", updated.Mobile)
+	}
+}
+
+func TestAccountWithoutMobileRequiresReauth(t *testing.T) {
+	setupAccountTest(t)
+	user := models.User{Name: "oidc"}
+	global.App.DB.Create(&user)
+
+	if err := AccountService.SendIdentityCode(user.ID.ID); !errors.Is(err, ErrReauthRequired) {
+		t.Fatalf("err = %v, want ErrReauthRequired", err)
+	}
+	params := request.ChangePassword{Password: "New-password1"}
+	if err := AccountService.ChangePassword(user.ID.ID, params, "10.0.0.1", ""); !errors.Is(err, ErrReauthRequired) {
+		t.Fatalf("err = %v, want ErrReauthRequired", err)
+	}
+
+	// 新绑定的身份不算重新验证
+	claims := oidcClaims{Subject: "sub-1"}
+	if err, _ := OauthService.bindIdentity("github", claims, user.ID.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := AccountService.ChangePassword(user.ID.ID, params, "10.0.0.1", ""); !errors.Is(err, ErrReauthRequired) {
+		t.Fatalf("err = %v, want ErrReauthRequired", err)
+	}
+
+	// 登录状态下通过已绑定的身份重新授权后，可以完成一次敏感操作
+	if err, _ := OauthService.bindIdentity("github", claims, user.ID.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err, _ := AccountService.RequestDeletion(user.ID.ID, request.DeleteAccount{}, "10.0.0.1", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := AccountService.ChangePassword(user.ID.ID, params, "10.0.0.1", ""); !errors.Is(err, ErrReauthRequired) {
+		t.Fatalf("reauth used twice: err = %v, want ErrReauthRequired", err)
+	}
+}
+
+func TestAccountMfaWithoutPassword(t *testing.T) {
+	setupAccountTest(t)
+	user := createTestUser(t, "13800000000")
+	secret, _ := enableTestMfa(t, AppGuardName, user.ID.ID)
+
+	// 已开启两步验证时以验证码确认身份，无需短信验证码
+	if err, _ := AccountService.RequestDeletion(user.ID.ID, request.DeleteAccount{}, "10.0.0.1", ""); !errors.Is(err, ErrMfaCodeRequired) {
+		t.Fatalf("err = %v, want ErrMfaCodeRequired", err)
+	}
+	params := request.DeleteAccount{MfaCode: totpCode(t, secret, 0)}
+	if err, _ := AccountService.RequestDeletion(user.ID.ID, params, "10.0.0.1", ""); err != nil {
+		t.Fatal(err)
+	}
+}
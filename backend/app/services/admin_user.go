@@ -0,0 +1,96 @@
+package services
+
+import (
+	"errors"
+	"gin-web/app/common/request"
+	"gin-web/app/models"
+	"gin-web/global"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type adminUserService struct {
+}
+
+var AdminUserService = new(adminUserService)
+
+// Login 管理后台登录，失败次数过多时按账号和 IP 锁定
+func (adminUserService *adminUserService) Login(params request.AdminLogin, ip string) (err error, adminUser *models.AdminUser) {
+	if err = LoginThrottleService.Check(AdminGuardName, params.Username, ip); err != nil {
+		return
+	}
+
+	err = global.App.DB.Where("username = ?", params.Username).First(&adminUser).Error
+	if err != nil {
+		PasswordService.VerifyDummy(params.Password)
+	}
+	if err != nil || !PasswordService.Verify(params.Password, adminUser.Password) {
+		LoginThrottleService.RecordFailure(AdminGuardName, params.Username, ip)
+		err = errors.New("账号不存在或密码错误")
+		return
+	}
+	LoginThrottleService.RecordSuccess(AdminGuardName, params.Username)
+	if adminUser.Status != models.AdminUserStatusEnabled {
+		err = errors.New("账号已被禁用")
+		return
+	}
+	now := time.Now()
+	adminUser.LastLoginAt = &now
+	columns := map[string]interface{}{"last_login_at": now}
+	// 哈希算法或参数已过时则按当前配置重新计算
+	if PasswordService.NeedsRehash(adminUser.Password) {
+		if hash, hashErr := PasswordService.Hash(params.Password); hashErr == nil {
+			columns["password"] = hash
+			adminUser.Password = hash
+		} else {
+			global.App.Log.Error("password rehash failed", zap.Uint("admin_id", adminUser.ID.ID), zap.Error(hashErr))
+		}
+	}
+	global.App.DB.Model(adminUser).UpdateColumns(columns)
+	return
+}
+
+// GetAdminInfo 获取管理员信息，禁用的账号视为不存在
+func (adminUserService *adminUserService) GetAdminInfo(id string) (err error, adminUser models.AdminUser) {
+	intId, err := strconv.Atoi(id)
+	err = global.App.DB.Preload("Roles").Where("status = ?", models.AdminUserStatusEnabled).First(&adminUser, intId).Error
+	if err != nil {
+		err = errors.New("数据不存在")
+	}
+	return
+}
+
+// GetByUsername 按登录名查找管理员账号
+func (adminUserService *adminUserService) GetByUsername(username string) (err error, adminUser models.AdminUser) {
+	err = global.App.DB.Where("username = ?", username).First(&adminUser).Error
+	if err != nil {
+		err = errors.New("账号不存在")
+	}
+	return
+}
+
+// Create 创建管理员账号
+func (adminUserService *adminUserService) Create(username string, password string, name string) (err error, adminUser models.AdminUser) {
+	var result = global.App.DB.Where("username = ?", username).Select("id").First(&models.AdminUser{})
+	if result.RowsAffected != 0 {
+		err = errors.New("账号已存在")
+		return
+	}
+	if err = PasswordService.CheckPolicy(password); err != nil {
+		return
+	}
+	hash, err := PasswordService.Hash(password)
+	if err != nil {
+		return
+	}
+	adminUser = models.AdminUser{
+		Username: username,
+		Name:     name,
+		Password: hash,
+		Status:   models.AdminUserStatusEnabled,
+	}
+	err = global.App.DB.Create(&adminUser).Error
+	return
+}
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"errors"
+	"gin-web/app/ampq/event"
+	"gin-web/app/common/request"
+	"gin-web/app/common/response"
+	"gin-web/app/models"
+	"gin-web/global"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type authorService struct{}
+
+var AuthorService = &authorService{}
+
+// authorStats 作者作品聚合数据
+type authorStats struct {
+	ModCount       int64
+	TotalDownloads int64
+	AverageRating  float64
+}
+
+// GetAuthorProfile 获取作者主页
+func (s *authorService) GetAuthorProfile(userID uint) (*response.AuthorProfileResponse, error) {
+	var user models.User
+	if err := global.App.DB.First(&user, userID).Error; err != nil {
+		return nil, errors.New("作者不存在")
+	}
+
+	var mods []models.Mod
+	if err := global.App.DB.Preload("Game").Preload("Categories").
+		Where("user_id = ?", userID).
+		Order("download_count desc").
+		Find(&mods).Error; err != nil {
+		return nil, err
+	}
+
+	// 评分为 0 表示暂无评分，不参与平均值计算
+	var stats authorStats
+	if err := global.App.DB.Model(&models.Mod{}).
+		Select("COUNT(*) AS mod_count, COALESCE(SUM(download_count), 0) AS total_downloads, "+
+			"COALESCE(AVG(NULLIF(rating, 0)), 0) AS average_rating").
+		Where("user_id = ?", userID).
+		Scan(&stats).Error; err != nil {
+		return nil, err
+	}
+
+	modItems := make([]response.ModItem, len(mods))
+	for i, mod := range mods {
+		modItems[i] = toModItem(mod)
+	}
+
+	alias := ""
+	if user.AuthorAlias != nil {
+		alias = *user.AuthorAlias
+	}
+
+	return &response.AuthorProfileResponse{
+		ID:             user.ID.ID,
+		Name:           user.Name,
+		Avatar:         user.Avatar,
+		Bio:            user.Bio,
+		Alias:          alias,
+		ModCount:       stats.ModCount,
+		TotalDownloads: stats.TotalDownloads,
+		AverageRating:  stats.AverageRating,
+		Mods:           modItems,
+		CreatedAt:      user.CreateTime,
+	}, nil
+}
+
+// checkAliasClaimable 署名未被认领且仍有未关联用户的历史 mod，申请人尚未认领过署名
+func (s *authorService) checkAliasClaimable(db *gorm.DB, userID uint, alias string) error {
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil {
+		return errors.New("用户不存在")
+	}
+	if user.AuthorAlias != nil {
+		return errors.New("已认领过作者署名")
+	}
+
+	var owner int64
+	if err := db.Model(&models.User{}).Where("author_alias = ?", alias).Count(&owner).Error; err != nil {
+		return err
+	}
+	if owner > 0 {
+		return errors.New("该作者署名已被认领")
+	}
+
+	var mods int64
+	if err := db.Model(&models.Mod{}).Where("author = ? AND user_id IS NULL", alias).Count(&mods).Error; err != nil {
+		return err
+	}
+	if mods == 0 {
+		return errors.New("没有可认领的作品")
+	}
+	return nil
+}
+
+// ClaimAlias 提交作者署名认领申请，版主核实所有权证明并审核通过后才归属历史 mod
+// 每个用户同一时间只能有一个待审核的申请
+func (s *authorService) ClaimAlias(userID uint, alias string, evidence string) (*models.AuthorClaim, error) {
+	if err := s.checkAliasClaimable(global.App.DB, userID, alias); err != nil {
+		return nil, err
+	}
+
+	var pending int64
+	if err := global.App.DB.Model(&models.AuthorClaim{}).
+		Where("user_id = ? AND status = ?", userID, models.AuthorClaimStatusPending).
+		Count(&pending).Error; err != nil {
+		return nil, err
+	}
+	if pending > 0 {
+		return nil, errors.New("已有待审核的认领申请")
+	}
+
+	claim := models.AuthorClaim{
+		UserID:   userID,
+		Alias:    alias,
+		Evidence: evidence,
+		Status:   models.AuthorClaimStatusPending,
+	}
+	if err := global.App.DB.Create(&claim).Error; err != nil {
+		return nil, err
+	}
+	return &claim, nil
+}
+
+// MyClaims 当前用户的认领申请
+func (s *authorService) MyClaims(userID uint) ([]models.AuthorClaim, error) {
+	claims := []models.AuthorClaim{}
+	err := global.App.DB.Where("user_id = ?", userID).Order("id desc").Find(&claims).Error
+	return claims, err
+}
+
+// ListClaims 审核用的认领申请列表，默认只看待审核
+func (s *authorService) ListClaims(req request.AuthorClaimQuery) (*response.AuthorClaimListResponse, error) {
+	page := req.Page
+	if page < 1 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	status := req.Status
+	if status == 0 {
+		status = models.AuthorClaimStatusPending
+	}
+
+	db := global.App.DB.Model(&models.AuthorClaim{}).Where("status = ?", status)
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, err
+	}
+	claims := []models.AuthorClaim{}
+	if err := db.Order("id").Offset((page - 1) * pageSize).Limit(pageSize).Find(&claims).Error; err != nil {
+		return nil, err
+	}
+	return &response.AuthorClaimListResponse{
+		List:     claims,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+// ReviewClaim 审核认领申请，通过时将该署名下尚未关联用户的历史 mod 归属到申请人，
+// 同一署名的其他待审核申请一并拒绝
+func (s *authorService) ReviewClaim(reviewerID uint, claimID uint, approve bool, note string) (*response.ClaimAuthorResponse, error) {
+	var claim models.AuthorClaim
+	var claimed int64
+	err := global.App.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&claim, claimID).Error; err != nil {
+			return errors.New("认领申请不存在")
+		}
+		if claim.Status != models.AuthorClaimStatusPending {
+			return errors.New("认领申请已审核")
+		}
+		if claim.UserID == reviewerID {
+			return errors.New("不能审核自己的认领申请")
+		}
+
+		now := time.Now()
+		review := map[string]interface{}{
+			"status":      models.AuthorClaimStatusRejected,
+			"reviewer_id": reviewerID,
+			"review_note": note,
+			"reviewed_at": now,
+		}
+		if !approve {
+			if err := tx.Model(&claim).Updates(review).Error; err != nil {
+				return err
+			}
+			return s.notifyClaimDecided(tx, claim, "拒绝", note)
+		}
+
+		if err := s.checkAliasClaimable(tx, claim.UserID, claim.Alias); err != nil {
+			return err
+		}
+		result := tx.Model(&models.Mod{}).
+			Where("author = ? AND user_id IS NULL", claim.Alias).
+			UpdateColumn("user_id", claim.UserID)
+		if result.Error != nil {
+			return result.Error
+		}
+		claimed = result.RowsAffected
+		if err := tx.Model(&models.User{}).Where("id = ?", claim.UserID).
+			UpdateColumn("author_alias", claim.Alias).Error; err != nil {
+			return err
+		}
+
+		review["status"] = models.AuthorClaimStatusApproved
+		if err := tx.Model(&claim).Updates(review).Error; err != nil {
+			return err
+		}
+		if err := s.notifyClaimDecided(tx, claim, "通过", note); err != nil {
+			return err
+		}
+
+		// 同一署名的其他待审核申请一并驳回，并通知申请人
+		var others []models.AuthorClaim
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("alias = ? AND status = ? AND id <> ?", claim.Alias, models.AuthorClaimStatusPending, claim.ID.ID).
+			Find(&others).Error; err != nil {
+			return err
+		}
+		const otherNote = "该作者署名已被其他用户认领"
+		for _, other := range others {
+			if err := tx.Model(&other).Updates(map[string]interface{}{
+				"status":      models.AuthorClaimStatusRejected,
+				"reviewer_id": reviewerID,
+				"review_note": otherNote,
+				"reviewed_at": now,
+			}).Error; err != nil {
+				return err
+			}
+			if err := s.notifyClaimDecided(tx, other, "拒绝", otherNote); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.ClaimAuthorResponse{
+		Alias:      claim.Alias,
+		ClaimedMod: claimed,
+	}, nil
+}
+
+// notifyClaimDecided 在审核事务内写入审核结果事件，由通知消费者告知申请人
+func (s *authorService) notifyClaimDecided(tx *gorm.DB, claim models.AuthorClaim, decision string, reason string) error {
+	return OutboxService.Add(tx, event.QueueNotification, "author_claim", claim.ID.ID, event.EventModerationDecided, event.ModerationDecidedEvent{
+		UserID:   claim.UserID,
+		Target:   "作者署名认领",
+		TargetID: claim.ID.ID,
+		Decision: decision,
+		Reason:   reason,
+	})
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"errors"
+	"gin-web/app/ampq/event"
+	"gin-web/app/common/request"
+	"gin-web/app/common/response"
+	"gin-web/app/models"
+	"gin-web/global"
+
+	"gorm.io/gorm"
+)
+
+// 回复通知中引用的评论内容长度上限（字符）
+const commentExcerptLength = 200
+
+type commentService struct{}
+
+var CommentService = new(commentService)
+
+func toCommentItem(comment models.ModComment) response.CommentItem {
+	userName := deletedUserName
+	if comment.User != nil {
+		userName = comment.User.Name
+	}
+	return response.CommentItem{
+		ID:        comment.ID,
+		ModID:     comment.ModID,
+		UserID:    comment.UserID,
+		UserName:  userName,
+		ParentID:  comment.ParentID,
+		Content:   comment.Content,
+		CreatedAt: comment.CreatedAt,
+	}
+}
+
+// List mod 评论列表，按发表时间倒序
+func (s *commentService) List(modID uint, req request.CommentList) (*response.CommentListResponse, error) {
+	page := req.Page
+	if page < 1 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize < 1 {
+		pageSize = 20
+	}
+
+	db := global.App.DB.Model(&models.ModComment{}).Where("mod_id = ?", modID)
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, err
+	}
+	var comments []models.ModComment
+	if err := db.Preload("User").Order("id desc").
+		Offset((page - 1) * pageSize).Limit(pageSize).
+		Find(&comments).Error; err != nil {
+		return nil, err
+	}
+
+	items := make([]response.CommentItem, len(comments))
+	for i, comment := range comments {
+		items[i] = toCommentItem(comment)
+	}
+	return &response.CommentListResponse{
+		List:     items,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+// Create 发表评论，回复他人评论时通过 outbox 通知被回复者
+func (s *commentService) Create(userID uint, modID uint, req request.CreateComment) (*response.CommentItem, error) {
+	var user models.User
+	if err := global.App.DB.First(&user, userID).Error; err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	comment := models.ModComment{ModID: modID, UserID: userID, ParentID: req.ParentID, Content: req.Content}
+
+	err := global.App.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Mod{}).Where("id = ?", modID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return errors.New("mod不存在")
+		}
+
+		var parent models.ModComment
+		if req.ParentID != nil {
+			if err := tx.Where("mod_id = ?", modID).First(&parent, *req.ParentID).Error; err != nil {
+				return errors.New("回复的评论不存在")
+			}
+		}
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+		if req.ParentID == nil || parent.UserID == userID {
+			return nil
+		}
+
+		excerpt := []rune(comment.Content)
+		if len(excerpt) > commentExcerptLength {
+			excerpt = excerpt[:commentExcerptLength]
+		}
+		return OutboxService.Add(tx, event.QueueNotification, "mod", modID, event.EventCommentReplied, event.CommentRepliedEvent{
+			RecipientID: parent.UserID,
+			ReplierName: user.Name,
+			ModID:       modID,
+			CommentID:   comment.ID,
+			Content:     string(excerpt),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	comment.User = &user
+	item := toCommentItem(comment)
+	return &item, nil
+}
+
+// Delete 版主删除评论，通过 outbox 将审核结果通知评论作者
+func (s *commentService) Delete(moderatorID uint, commentID uint, reason string) error {
+	return global.App.DB.Transaction(func(tx *gorm.DB) error {
+		var comment models.ModComment
+		if err := tx.First(&comment, commentID).Error; err != nil {
+			return errors.New("评论不存在")
+		}
+		if err := tx.Delete(&comment).Error; err != nil {
+			return err
+		}
+		if comment.UserID == moderatorID {
+			return nil
+		}
+		return OutboxService.Add(tx, event.QueueNotification, "comment", comment.ID, event.EventModerationDecided, event.ModerationDecidedEvent{
+			UserID:   comment.UserID,
+			Target:   "评论",
+			TargetID: comment.ID,
+			Decision: "已删除",
+			Reason:   reason,
+		})
+	})
+}
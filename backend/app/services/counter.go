@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"gin-web/app/models"
+	"gin-web/global"
+	"gin-web/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type counterService struct{}
+
+var CounterService = new(counterService)
+
+const (
+	CounterView     = "view"
+	CounterDownload = "download"
+
+	defaultCounterDedupWindow   = 1800
+	defaultCounterFlushInterval = 60
+	defaultCounterBatchSize     = 500
+)
+
+// counterColumns 计数类型对应的 mods 表字段
+var counterColumns = map[string]string{
+	CounterView:     "view_count",
+	CounterDownload: "download_count",
+}
+
+// takeCountersLuaScript 读取并删除一批增量 key，返回各 key 的值，不存在时为 0
+const takeCountersLuaScript = `
+local result = {}
+for i, key in ipairs(KEYS) do
+    result[i] = tonumber(redis.call("GET", key) or "0")
+    redis.call("DEL", key)
+end
+return result
+`
+
+// hitCounterLuaScript 窗口期内首次访问时写入去重标记并累加增量，去重和计数同时成功或失败
+const hitCounterLuaScript = `
+if redis.call("SET", KEYS[1], 1, "NX", "PX", ARGV[1]) == false then
+    return 0
+end
+redis.call("INCR", KEYS[2])
+redis.call("SADD", KEYS[3], ARGV[2])
+return 1
+`
+
+var (
+	takeCountersScript = redis.NewScript(takeCountersLuaScript)
+	hitCounterScript   = redis.NewScript(hitCounterLuaScript)
+)
+
+// 去重 key：同一访客在窗口期内对同一 mod 只计一次
+func (counterService *counterService) getDedupKey(kind string, modID uint, visitor string) string {
+	return "mod_counter:dedup:" + kind + ":" + strconv.Itoa(int(modID)) + ":" + utils.MD5([]byte(visitor))
+}
+
+// 待回写的增量 key
+func (counterService *counterService) getCountKey(kind string, modID uint) string {
+	return "mod_counter:" + kind + ":" + strconv.Itoa(int(modID))
+}
+
+// 有待回写增量的 mod 集合 key
+func (counterService *counterService) getDirtyKey(kind string) string {
+	return "mod_counter:dirty:" + kind
+}
+
+func (counterService *counterService) dedupWindow() time.Duration {
+	window := global.App.Config.Counter.DedupWindow
+	if window <= 0 {
+		window = defaultCounterDedupWindow
+	}
+	return time.Duration(window) * time.Second
+}
+
+// FlushInterval 回写间隔
+func (counterService *counterService) FlushInterval() time.Duration {
+	interval := global.App.Config.Counter.FlushInterval
+	if interval <= 0 {
+		interval = defaultCounterFlushInterval
+	}
+	return time.Duration(interval) * time.Second
+}
+
+func (counterService *counterService) batchSize() int64 {
+	size := global.App.Config.Counter.BatchSize
+	if size <= 0 {
+		size = defaultCounterBatchSize
+	}
+	return size
+}
+
+// Hit 记录一次浏览或下载，visitor 为用户或 IP 标识，返回本次是否计数
+func (counterService *counterService) Hit(kind string, modID uint, visitor string) bool {
+	hit, err := hitCounterScript.Run(context.Background(), global.App.Redis,
+		[]string{counterService.getDedupKey(kind, modID, visitor), counterService.getCountKey(kind, modID), counterService.getDirtyKey(kind)},
+		counterService.dedupWindow().Milliseconds(), modID).Int()
+	if err != nil {
+		global.App.Log.Error("mod counter incr failed", zap.String("kind", kind), zap.Uint("mod_id", modID), zap.Error(err))
+		return false
+	}
+	return hit == 1
+}
+
+// Pending 尚未回写到 MySQL 的增量
+func (counterService *counterService) Pending(kind string, modID uint) int {
+	pending, _ := global.App.Redis.Get(context.Background(), counterService.getCountKey(kind, modID)).Int()
+	return pending
+}
+
+// Flush 将 Redis 中累计的增量批量回写 MySQL
+// 多实例部署时通过分布式锁保证同一时刻只有一个实例在回写
+func (counterService *counterService) Flush() {
+	lock := global.Lock("mod_counter_flush_lock", int64(counterService.FlushInterval()/time.Second))
+	if !lock.Get() {
+		return
+	}
+	defer lock.Release()
+
+	for kind := range counterColumns {
+		for {
+			flushed, err := counterService.flushBatch(kind)
+			if err != nil {
+				global.App.Log.Error("mod counter flush failed", zap.String("kind", kind), zap.Error(err))
+				break
+			}
+			if flushed < counterService.batchSize() {
+				break
+			}
+		}
+	}
+}
+
+// flushBatch 回写一批 mod 的增量，返回本批处理的 mod 数量
+func (counterService *counterService) flushBatch(kind string) (int64, error) {
+	ctx := context.Background()
+	dirtyKey := counterService.getDirtyKey(kind)
+
+	members, err := global.App.Redis.SPopN(ctx, dirtyKey, counterService.batchSize()).Result()
+	if err != nil || len(members) == 0 {
+		return 0, err
+	}
+
+	ids := make([]uint, 0, len(members))
+	keys := make([]string, 0, len(members))
+	for _, member := range members {
+		id, err := strconv.ParseUint(member, 10, 32)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, uint(id))
+		keys = append(keys, counterService.getCountKey(kind, uint(id)))
+	}
+	if len(keys) == 0 {
+		return int64(len(members)), nil
+	}
+
+	// 回写前原子取走增量，回写后无需再扣减，避免扣减失败导致同一增量重复回写
+	counts, err := takeCountersScript.Run(ctx, global.App.Redis, keys).Int64Slice()
+	if err != nil {
+		global.App.Redis.SAdd(ctx, dirtyKey, members)
+		return 0, err
+	}
+	increments := make(map[uint]int64, len(ids))
+	for i, id := range ids {
+		if counts[i] > 0 {
+			increments[id] = counts[i]
+		}
+	}
+	if len(increments) == 0 {
+		return int64(len(members)), nil
+	}
+
+	if err = counterService.applyIncrements(counterColumns[kind], increments); err != nil {
+		// 回写失败，增量放回 Redis 等待下次重试
+		pipe := global.App.Redis.TxPipeline()
+		for id, count := range increments {
+			pipe.IncrBy(ctx, counterService.getCountKey(kind, id), count)
+		}
+		pipe.SAdd(ctx, dirtyKey, members)
+		if _, restoreErr := pipe.Exec(ctx); restoreErr != nil {
+			global.App.Log.Error("mod counter restore failed", zap.String("kind", kind),
+				zap.Any("increments", increments), zap.Error(restoreErr))
+		}
+		return 0, err
+	}
+	return int64(len(members)), nil
+}
+
+// applyIncrements 单条 UPDATE ... CASE 批量累加
+func (counterService *counterService) applyIncrements(column string, increments map[uint]int64) error {
+	var caseSQL strings.Builder
+	args := make([]interface{}, 0, len(increments)*2)
+	ids := make([]uint, 0, len(increments))
+	caseSQL.WriteString(fmt.Sprintf("%s + CASE id", column))
+	for id, count := range increments {
+		caseSQL.WriteString(" WHEN ? THEN ?")
+		args = append(args, id, count)
+		ids = append(ids, id)
+	}
+	caseSQL.WriteString(" ELSE 0 END")
+
+	return global.App.DB.Model(&models.Mod{}).
+		Where("id IN ?", ids).
+		UpdateColumn(column, gorm.Expr(caseSQL.String(), args...)).Error
+}
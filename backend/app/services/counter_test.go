@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"gin-web/app/models"
+	"gin-web/global"
+	"testing"
+)
+
+func createTestMod(t *testing.T, name string) models.Mod {
+	t.Helper()
+	mod := models.Mod{Name: name, GameID: 1}
+	if err := global.App.DB.Create(&mod).Error; err != nil {
+		t.Fatal(err)
+	}
+	return mod
+}
+
+func TestCounterHitDedup(t *testing.T) {
+	mr := setupServiceTest(t)
+	s := CounterService
+
+	if !s.Hit(CounterView, 1, "user:1") {
+		t.Fatal("first hit not counted")
+	}
+	if s.Hit(CounterView, 1, "user:1") {
+		t.Fatal("duplicate hit counted")
+	}
+	// 不同访客、不同计数类型分别计数
+	if !s.Hit(CounterView, 1, "user:2") || !s.Hit(CounterDownload, 1, "user:1") {
+		t.Fatal("distinct hit not counted")
+	}
+	if s.Pending(CounterView, 1) != 2 || s.Pending(CounterDownload, 1) != 1 {
+		t.Fatalf("pending = %d/%d, want 2/1", s.Pending(CounterView, 1), s.Pending(CounterDownload, 1))
+	}
+
+	// 去重窗口过后再次计数
+	mr.FastForward(s.dedupWindow())
+	if !s.Hit(CounterView, 1, "user:1") {
+		t.Fatal("hit after dedup window not counted")
+	}
+}
+
+func TestCounterHitRedisFailure(t *testing.T) {
+	mr := setupServiceTest(t)
+	s := CounterService
+
+	// 计数失败时不留下去重标记，恢复后同一访客可以重新计数
+	mr.SetError("READONLY")
+	if s.Hit(CounterView, 1, "user:1") {
+		t.Fatal("hit counted while redis failing")
+	}
+	mr.SetError("")
+	if mr.Exists(s.getDedupKey(CounterView, 1, "user:1")) {
+		t.Fatal("dedup key left after failure")
+	}
+	if !s.Hit(CounterView, 1, "user:1") {
+		t.Fatal("hit not counted after recovery")
+	}
+}
+
+func TestCounterFlush(t *testing.T) {
+	setupServiceTest(t)
+	s := CounterService
+	first := createTestMod(t, "first")
+	second := createTestMod(t, "second")
+
+	s.Hit(CounterView, first.ID, "user:1")
+	s.Hit(CounterView, first.ID, "user:2")
+	s.Hit(CounterView, second.ID, "user:1")
+	s.Hit(CounterDownload, second.ID, "user:1")
+	s.Flush()
+
+	var mods []models.Mod
+	global.App.DB.Order("id").Find(&mods)
+	if mods[0].ViewCount != 2 || mods[0].DownloadCount != 0 || mods[1].ViewCount != 1 || mods[1].DownloadCount != 1 {
+		t.Fatalf("unexpected counts %+v", mods)
+	}
+	if s.Pending(CounterView, first.ID) != 0 || s.Pending(CounterDownload, second.ID) != 0 {
+		t.Fatal("pending increments left after flush")
+	}
+
+	// 再次回写不会重复累加
+	s.Flush()
+	global.App.DB.First(&mods[0], first.ID)
+	if mods[0].ViewCount != 2 {
+		t.Fatalf("view count = %d after second flush, want 2", mods[0].ViewCount)
+	}
+}
+
+func TestCounterFlushRestoresOnFailure(t *testing.T) {
+	setupServiceTest(t)
+	s := CounterService
+	mod := createTestMod(t, "mod")
+	s.Hit(CounterView, mod.ID, "user:1")
+
+	// 回写失败时增量放回 Redis
+	if err := global.App.DB.Migrator().RenameTable("mods", "mods_backup"); err != nil {
+		t.Fatal(err)
+	}
+	s.Flush()
+	if s.Pending(CounterView, mod.ID) != 1 {
+		t.Fatalf("pending = %d after failed flush, want 1", s.Pending(CounterView, mod.ID))
+	}
+
+	global.App.DB.Migrator().RenameTable("mods_backup", "mods")
+	global.App.Redis.Del(context.Background(), "mod_counter_flush_lock")
+	s.Flush()
+	var saved models.Mod
+	global.App.DB.First(&saved, mod.ID)
+	if saved.ViewCount != 1 {
+		t.Fatalf("view count = %d, want 1", saved.ViewCount)
+	}
+}
+
+func TestCounterFlushBatches(t *testing.T) {
+	setupServiceTest(t)
+	global.App.Config.Counter.BatchSize = 2
+	s := CounterService
+	ids := make([]uint, 5)
+	for i := range ids {
+		ids[i] = createTestMod(t, "mod").ID
+		s.Hit(CounterDownload, ids[i], "user:1")
+	}
+
+	s.Flush()
+	var total int64
+	global.App.DB.Model(&models.Mod{}).Select("SUM(download_count)").Scan(&total)
+	if total != 5 {
+		t.Fatalf("flushed %d downloads, want 5", total)
+	}
+}
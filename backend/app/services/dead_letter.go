@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"gin-web/app/ampq/broker"
+	"gin-web/app/common/response"
+	"gin-web/config"
+	"gin-web/global"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const defaultDeadLetterLimit = 20
+
+type deadLetterService struct{}
+
+var DeadLetterService = new(deadLetterService)
+
+// checkQueue 只允许操作 consumer.yaml 中配置的消费队列，避免在 MQ 中创建任意队列
+func (s *deadLetterService) checkQueue(queue string) error {
+	cfg, err := config.LoadConfig(config.ConsumerConfigPath)
+	if err != nil {
+		return err
+	}
+	for _, consumer := range cfg.Consumers {
+		if consumer.Queue == queue {
+			return nil
+		}
+	}
+	return errors.New("队列未配置消费者: " + queue)
+}
+
+func (s *deadLetterService) limit(limit int) int {
+	if limit <= 0 {
+		return defaultDeadLetterLimit
+	}
+	return limit
+}
+
+// List 查看死信队列中最早的消息，查看后消息按原顺序退回死信队列
+func (s *deadLetterService) List(queue string, limit int) ([]response.DeadLetter, error) {
+	if err := s.checkQueue(queue); err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	dlq := broker.DeadLetterQueue(queue)
+
+	var messages []*broker.Message
+	// 倒序退回，保证内存实现中的顺序不变（RabbitMQ 会放回原位置）
+	defer func() {
+		for i := len(messages) - 1; i >= 0; i-- {
+			global.App.Broker.Nack(messages[i], true)
+		}
+	}()
+
+	result := []response.DeadLetter{}
+	for len(messages) < s.limit(limit) {
+		msg, err := global.App.Broker.Get(ctx, dlq)
+		if err != nil {
+			return nil, err
+		}
+		if msg == nil {
+			break
+		}
+		messages = append(messages, msg)
+		result = append(result, toDeadLetterResponse(msg))
+	}
+	return result, nil
+}
+
+func toDeadLetterResponse(msg *broker.Message) response.DeadLetter {
+	deadLetter := response.DeadLetter{
+		ID:            msg.ID,
+		OriginalQueue: msg.HeaderString(broker.HeaderOriginalQueue),
+		ContentType:   msg.ContentType,
+		Body:          string(msg.Body),
+		Headers:       msg.Headers,
+		RetryCount:    msg.RetryCount(),
+		LastError:     msg.HeaderString(broker.HeaderLastError),
+	}
+	if failedAt := msg.HeaderInt(broker.HeaderFailedAt); failedAt > 0 {
+		t := time.Unix(int64(failedAt), 0)
+		deadLetter.FailedAt = &t
+	}
+	return deadLetter
+}
+
+// Replay 将死信队列中最早的消息重新投递到原队列，重试次数清零
+func (s *deadLetterService) Replay(queue string, limit int) (response.ReplayDeadLetters, error) {
+	result := response.ReplayDeadLetters{}
+	if err := s.checkQueue(queue); err != nil {
+		return result, err
+	}
+	ctx := context.Background()
+	dlq := broker.DeadLetterQueue(queue)
+
+	for result.Replayed < s.limit(limit) {
+		msg, err := global.App.Broker.Get(ctx, dlq)
+		if err != nil {
+			return result, err
+		}
+		if msg == nil {
+			break
+		}
+
+		headers := make(map[string]interface{}, len(msg.Headers))
+		for k, v := range msg.Headers {
+			headers[k] = v
+		}
+		delete(headers, broker.HeaderRetryCount)
+		delete(headers, broker.HeaderOriginalQueue)
+		delete(headers, broker.HeaderFailedAt)
+
+		err = global.App.Broker.Publish(ctx, queue, broker.Message{
+			ID:          msg.ID,
+			ContentType: msg.ContentType,
+			Headers:     headers,
+			Body:        msg.Body,
+			Timestamp:   msg.Timestamp,
+		})
+		if err != nil {
+			global.App.Broker.Nack(msg, true)
+			return result, err
+		}
+		global.App.Broker.Ack(msg)
+		result.Replayed++
+	}
+	global.App.Log.Info("dead letters replayed", zap.String("queue", queue), zap.Int("count", result.Replayed))
+	return result, nil
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gin-web/app/mailer"
+	"gin-web/app/models"
+	"gin-web/global"
+	"gin-web/utils"
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultEmailVerifyTtl   = 24 * 3600
+	defaultPasswordResetTtl = 30 * 60
+	emailSendCooldown       = time.Minute // 同一邮箱两次发送的最小间隔
+)
+
+type emailService struct{}
+
+var EmailService = new(emailService)
+
+var (
+	ErrEmailTokenInvalid = errors.New("链接无效或已过期")
+	ErrEmailSendTooOften = errors.New("发送过于频繁，请稍后再试")
+)
+
+func (s *emailService) verifyTtl() time.Duration {
+	ttl := global.App.Config.Mail.VerifyTtl
+	if ttl <= 0 {
+		ttl = defaultEmailVerifyTtl
+	}
+	return time.Duration(ttl) * time.Second
+}
+
+func (s *emailService) resetTtl() time.Duration {
+	ttl := global.App.Config.Mail.ResetTtl
+	if ttl <= 0 {
+		ttl = defaultPasswordResetTtl
+	}
+	return time.Duration(ttl) * time.Second
+}
+
+// 获取邮箱验证 token 缓存 key，只保存 token 的哈希
+func (s *emailService) getVerifyKey(token string) string {
+	return "email_verify:" + utils.Sha256([]byte(token))
+}
+
+// 获取重置密码 token 缓存 key
+func (s *emailService) getResetKey(token string) string {
+	return "password_reset:" + utils.Sha256([]byte(token))
+}
+
+// 获取用户当前有效的重置密码 token 缓存 key，重新申请时旧链接失效
+func (s *emailService) getUserResetKey(userID uint) string {
+	return "password_reset_user:" + strconv.Itoa(int(userID))
+}
+
+// 获取发送冷却缓存 key
+func (s *emailService) getCooldownKey(kind string, email string) string {
+	return "email_cooldown:" + kind + ":" + utils.MD5([]byte(email))
+}
+
+// cooldown 同一邮箱同类邮件发送限频
+func (s *emailService) cooldown(kind string, email string) bool {
+	ok, err := global.App.Redis.SetNX(context.Background(), s.getCooldownKey(kind, email), 1, emailSendCooldown).Result()
+	return err == nil && ok
+}
+
+// consume 取出并删除 token，保证只能使用一次
+func (s *emailService) consume(key string) (string, error) {
+	ctx := context.Background()
+	pipe := global.App.Redis.TxPipeline()
+	get := pipe.Get(ctx, key)
+	pipe.Del(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", ErrEmailTokenInvalid
+	}
+	return get.Val(), nil
+}
+
+// buildLink 拼接前端页面链接
+func (s *emailService) buildLink(base string, token string) string {
+	sep := "?"
+	if strings.Contains(base, "?") {
+		sep = "&"
+	}
+	return base + sep + "token=" + url.QueryEscape(token)
+}
+
+// SendVerification 发送邮箱验证邮件
+func (s *emailService) SendVerification(user models.User) error {
+	if user.Email == nil || *user.Email == "" {
+		return errors.New("未设置邮箱")
+	}
+	if user.EmailVerifiedAt != nil {
+		return errors.New("邮箱已验证")
+	}
+	if !s.cooldown("verify", *user.Email) {
+		return ErrEmailSendTooOften
+	}
+
+	token := utils.RandToken(32)
+	ctx := context.Background()
+	// 绑定邮箱地址，邮箱变更后旧链接自动失效
+	value := strconv.Itoa(int(user.ID.ID)) + ":" + *user.Email
+	if err := global.App.Redis.Set(ctx, s.getVerifyKey(token), value, s.verifyTtl()).Err(); err != nil {
+		return err
+	}
+
+	link := s.buildLink(global.App.Config.Mail.VerifyUrl, token)
+	return global.App.Mailer.Send(ctx, mailer.Message{
+		To:      *user.Email,
+		Subject: "请验证你的邮箱",
+		Body: fmt.Sprintf(`<p>%s，你好：</p><p>请点击以下链接完成邮箱验证，链接 %d 小时内有效：</p><p><a href="%s">%s</a></p>`,
+			html.EscapeString(user.Name), int(s.verifyTtl().Hours()), html.EscapeString(link), html.EscapeString(link)),
+	})
+}
+
+// ResendVerification 重新发送当前用户的验证邮件
+func (s *emailService) ResendVerification(userID uint) error {
+	var user models.User
+	if err := global.App.DB.First(&user, userID).Error; err != nil {
+		return errors.New("用户不存在")
+	}
+	return s.SendVerification(user)
+}
+
+// Verify 校验邮箱验证 token
+func (s *emailService) Verify(token string) error {
+	value, err := s.consume(s.getVerifyKey(token))
+	if err != nil {
+		return err
+	}
+	parts := strings.SplitN(value, ":", 2)
+	if len(parts) != 2 {
+		return ErrEmailTokenInvalid
+	}
+
+	result := global.App.DB.Model(&models.User{}).
+		Where("id = ? AND email = ?", parts[0], parts[1]).
+		UpdateColumn("email_verified_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrEmailTokenInvalid
+	}
+	return nil
+}
+
+// ForgotPassword 发送重置密码邮件，邮箱未注册时同样返回成功，避免泄露注册信息
+// 邮件在后台发送，响应时间不因邮箱是否注册而不同
+func (s *emailService) ForgotPassword(email string) error {
+	email = strings.ToLower(email)
+	if !s.cooldown("reset", email) {
+		return ErrEmailSendTooOften
+	}
+
+	var user models.User
+	result := global.App.DB.Where("email = ?", email).Limit(1).Find(&user)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	go func() {
+		if err := s.sendReset(user, email); err != nil {
+			global.App.Log.Error("send password reset mail failed", zap.Uint("user_id", user.ID.ID), zap.Error(err))
+		}
+	}()
+	return nil
+}
+
+// sendReset 生成重置密码 token 并发送邮件
+func (s *emailService) sendReset(user models.User, email string) error {
+	ctx := context.Background()
+	token := utils.RandToken(32)
+	userKey := s.getUserResetKey(user.ID.ID)
+	// 作废上一次申请的链接
+	s.invalidateReset(user.ID.ID)
+	resetKey := s.getResetKey(token)
+	pipe := global.App.Redis.TxPipeline()
+	pipe.Set(ctx, resetKey, user.ID.ID, s.resetTtl())
+	pipe.Set(ctx, userKey, resetKey, s.resetTtl())
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	link := s.buildLink(global.App.Config.Mail.ResetUrl, token)
+	return global.App.Mailer.Send(ctx, mailer.Message{
+		To:      email,
+		Subject: "重置密码",
+		Body: fmt.Sprintf(`<p>%s，你好：</p><p>我们收到了重置密码的申请，请点击以下链接设置新密码，链接 %d 分钟内有效且只能使用一次：</p><p><a href="%s">%s</a></p><p>如果不是你本人操作，请忽略本邮件。</p>`,
+			html.EscapeString(user.Name), int(s.resetTtl().Minutes()), html.EscapeString(link), html.EscapeString(link)),
+	})
+}
+
+// invalidateReset 作废用户尚未使用的重置密码链接
+func (s *emailService) invalidateReset(userID uint) {
+	ctx := context.Background()
+	userKey := s.getUserResetKey(userID)
+	if resetKey, err := global.App.Redis.Get(ctx, userKey).Result(); err == nil {
+		global.App.Redis.Del(ctx, resetKey, userKey)
+	}
+}
+
+// ResetPassword 使用重置 token 设置新密码
+func (s *emailService) ResetPassword(token string, password string) error {
+	value, err := s.consume(s.getResetKey(token))
+	if err != nil {
+		return err
+	}
+	userID, err := strconv.Atoi(value)
+	if err != nil {
+		return ErrEmailTokenInvalid
+	}
+	global.App.Redis.Del(context.Background(), s.getUserResetKey(uint(userID)))
+
+	hash, err := PasswordService.Hash(password)
+	if err != nil {
+		return err
+	}
+
+	var user models.User
+	if err = global.App.DB.Select("id", "mobile").First(&user, userID).Error; err != nil {
+		return ErrEmailTokenInvalid
+	}
+
+	now := time.Now()
+	// 能收到重置邮件说明邮箱可用，顺便标记为已验证
+	err = global.App.DB.Model(&user).Updates(map[string]interface{}{
+		"password":          hash,
+		"email_verified_at": gorm.Expr("COALESCE(email_verified_at, ?)", now),
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	// 密码可能已泄露，下线全部会话，并解除因密码错误产生的登录限制
+	if _, revokeErr := SessionService.RevokeOthers(AppGuardName, user.GetUid(), ""); revokeErr != nil {
+		global.App.Log.Error("revoke sessions after password reset failed", zap.Uint("user_id", user.ID.ID), zap.Error(revokeErr))
+	}
+	LoginThrottleService.RecordSuccess(AppGuardName, user.Mobile)
+	return nil
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"gin-web/app/mailer"
+	"gin-web/global"
+	"strings"
+	"testing"
+	"time"
+)
+
+// blockingMailer 发送时阻塞到 release 关闭，记录发出的邮件
+type blockingMailer struct {
+	release chan struct{}
+	sent    chan mailer.Message
+}
+
+func (m *blockingMailer) Send(ctx context.Context, msg mailer.Message) error {
+	<-m.release
+	m.sent <- msg
+	return nil
+}
+
+func TestForgotPasswordSendsInBackground(t *testing.T) {
+	setupServiceTest(t)
+	m := &blockingMailer{release: make(chan struct{}), sent: make(chan mailer.Message, 1)}
+	global.App.Mailer = m
+	global.App.Config.Mail.ResetUrl = "http://localhost/reset"
+
+	user := createTestUser(t, "13800000000")
+	email := "user@example.com"
+	global.App.DB.Model(&user).UpdateColumn("email", email)
+
+	// 邮件服务阻塞时接口仍立即返回
+	done := make(chan error, 1)
+	go func() { done <- EmailService.ForgotPassword("User@Example.com") }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("ForgotPassword blocked on the mailer")
+	}
+
+	close(m.release)
+	select {
+	case msg := <-m.sent:
+		if msg.To != email || !strings.Contains(msg.Body, "http://localhost/reset?token=") {
+			t.Fatalf("unexpected mail %+v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("reset mail not sent")
+	}
+
+	// 冷却期内再次申请被拒绝
+	if err := EmailService.ForgotPassword(email); err != ErrEmailSendTooOften {
+		t.Fatalf("err = %v, want ErrEmailSendTooOften", err)
+	}
+}
+
+func TestForgotPasswordUnknownEmail(t *testing.T) {
+	setupServiceTest(t)
+	m := &blockingMailer{release: make(chan struct{}), sent: make(chan mailer.Message, 1)}
+	close(m.release)
+	global.App.Mailer = m
+
+	if err := EmailService.ForgotPassword("nobody@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case msg := <-m.sent:
+		t.Fatalf("unexpected mail %+v", msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"errors"
+	"gin-web/app/models"
+	"gin-web/global"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type followService struct{}
+
+var FollowService = new(followService)
+
+// Follow 关注 mod，重复关注忽略
+func (s *followService) Follow(userID uint, modID uint) error {
+	var count int64
+	if err := global.App.DB.Model(&models.Mod{}).Where("id = ?", modID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New("mod不存在")
+	}
+	return global.App.DB.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.ModFollow{UserID: userID, ModID: modID}).Error
+}
+
+// Unfollow 取消关注
+func (s *followService) Unfollow(userID uint, modID uint) error {
+	return global.App.DB.Where("user_id = ? AND mod_id = ?", userID, modID).Delete(&models.ModFollow{}).Error
+}
+
+// followerIDs mod 的全部关注者，不含 excludeUserID
+func (s *followService) followerIDs(db *gorm.DB, modID uint, excludeUserID uint) (ids []uint, err error) {
+	err = db.Model(&models.ModFollow{}).
+		Where("mod_id = ? AND user_id <> ?", modID, excludeUserID).
+		Order("id").Pluck("user_id", &ids).Error
+	return
+}
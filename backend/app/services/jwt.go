@@ -5,7 +5,9 @@ import (
 	"errors"
 	"gin-web/global"
 	"gin-web/utils"
-	"github.com/dgrijalva/jwt-go"
+	"github.com/go-redis/redis/v8"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
 	"strconv"
 	"time"
 )
@@ -22,61 +24,208 @@ type JwtUser interface {
 
 // CustomClaims 自定义 Claims
 type CustomClaims struct {
-	jwt.StandardClaims
+	jwt.RegisteredClaims
+	FamilyID string `json:"fid,omitempty"` // 所属 refresh token 家族，家族被吊销后 access token 同时失效
 }
 
 const (
-	TokenType    = "bearer"
-	AppGuardName = "app"
+	TokenType      = "bearer"
+	AppGuardName   = "app"
+	AdminGuardName = "admin"
 )
 
+// 未配置 refresh_ttl 时的 refresh token 有效期，避免以 0 写入 Redis 导致 token 立即失效
+const defaultRefreshTtl = 30 * 24 * time.Hour
+
 type TokenOutPut struct {
-	AccessToken string `json:"access_token"`
-	ExpiresIn   int    `json:"expires_in"`
-	TokenType   string `json:"token_type"`
-}
-
-// CreateToken 生成 Token
-func (jwtService *jwtService) CreateToken(GuardName string, user JwtUser) (tokenData TokenOutPut, err error, token *jwt.Token) {
-	token = jwt.NewWithClaims(
-		jwt.SigningMethodHS256,
-		CustomClaims{
-			StandardClaims: jwt.StandardClaims{
-				ExpiresAt: time.Now().Unix() + global.App.Config.Jwt.JwtTtl,
-				Id:        user.GetUid(),
-				Issuer:    GuardName, // 用于在中间件中区分不同客户端颁发的 token，避免 token 跨端使用
-				NotBefore: time.Now().Unix() - 1000,
-			},
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	TokenType        string `json:"token_type"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresIn int    `json:"refresh_expires_in"`
+}
+
+// rotateRefreshTokenLuaScript 原子地校验并标记 refresh token 已使用
+// 返回 {状态, uid, family}，状态为 missing（不存在或已过期）、reused（重复使用）、ok
+const rotateRefreshTokenLuaScript = `
+local v = redis.call("HMGET", KEYS[1], "used", "uid", "family")
+if not v[2] then
+    return {"missing", "", ""}
+end
+if v[1] == "1" then
+    return {"reused", v[2], v[3]}
+end
+redis.call("HSET", KEYS[1], "used", "1")
+return {"ok", v[2], v[3]}
+`
+
+var (
+	ErrRefreshTokenInvalid = errors.New("refresh token 无效或已过期")
+	ErrRefreshTokenReused  = errors.New("refresh token 已被使用，该登录已失效，请重新登录")
+)
+
+// CreateToken 登录成功后生成 access token 和 refresh token，开启一个新的 token 家族（即一个登录会话）
+func (jwtService *jwtService) CreateToken(GuardName string, user JwtUser, client SessionClient) (tokenData TokenOutPut, err error, token *jwt.Token) {
+	familyID := utils.RandToken(16)
+	if err = SessionService.create(GuardName, user.GetUid(), familyID, client, jwtService.refreshTtl(GuardName)); err != nil {
+		return
+	}
+	return jwtService.issueToken(GuardName, user.GetUid(), familyID)
+}
+
+// issueToken 在指定家族下签发一对新的 token
+func (jwtService *jwtService) issueToken(GuardName string, uid string, familyID string) (tokenData TokenOutPut, err error, token *jwt.Token) {
+	now := time.Now()
+	token, tokenStr, err := JwtKeyService.Sign(CustomClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(jwtService.jwtTtl(GuardName))),
+			ID:        uid,
+			Issuer:    GuardName, // 用于在中间件中区分不同客户端颁发的 token，避免 token 跨端使用
+			NotBefore: jwt.NewNumericDate(now.Add(-1000 * time.Second)),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
-	)
+		FamilyID: familyID,
+	})
+	if err != nil {
+		return
+	}
 
-	tokenStr, err := token.SignedString([]byte(global.App.Config.Jwt.Secret))
+	// refresh token 使用后保留到过期，用于识别重复使用
+	refreshToken := utils.RandToken(32)
+	refreshKey := jwtService.getRefreshTokenKey(GuardName, refreshToken)
+	ctx := context.Background()
+	pipe := global.App.Redis.TxPipeline()
+	pipe.HSet(ctx, refreshKey, "uid", uid, "family", familyID, "used", "0")
+	pipe.Expire(ctx, refreshKey, jwtService.refreshTtl(GuardName))
+	if _, err = pipe.Exec(ctx); err != nil {
+		return
+	}
 
 	tokenData = TokenOutPut{
 		tokenStr,
-		int(global.App.Config.Jwt.JwtTtl),
+		int(jwtService.jwtTtl(GuardName) / time.Second),
 		TokenType,
+		refreshToken,
+		int(jwtService.refreshTtl(GuardName) / time.Second),
 	}
 	return
 }
 
-// 获取黑名单缓存 key
-func (jwtService *jwtService) getBlackListKey(tokenStr string) string {
-	return "jwt_black_list:" + utils.MD5([]byte(tokenStr))
+// Refresh 使用 refresh token 换取新的 token 对，旧 refresh token 随即失效
+// 已使用过的 refresh token 再次出现说明可能被盗用，吊销整个家族
+func (jwtService *jwtService) Refresh(GuardName string, refreshToken string) (err error, tokenData TokenOutPut) {
+	ctx := context.Background()
+	result, err := redis.NewScript(rotateRefreshTokenLuaScript).Run(ctx, global.App.Redis,
+		[]string{jwtService.getRefreshTokenKey(GuardName, refreshToken)}).StringSlice()
+	if err != nil || len(result) != 3 {
+		err = ErrRefreshTokenInvalid
+		return
+	}
+
+	status, uid, familyID := result[0], result[1], result[2]
+	switch status {
+	case "reused":
+		jwtService.RevokeFamily(GuardName, familyID)
+		global.App.Log.Warn("refresh token reused, family revoked",
+			zap.String("guard", GuardName), zap.String("uid", uid), zap.String("family", familyID))
+		err = ErrRefreshTokenReused
+		return
+	case "ok":
+	default:
+		err = ErrRefreshTokenInvalid
+		return
+	}
+
+	// 家族已被吊销（登出、重复使用检测）
+	if !jwtService.IsFamilyActive(GuardName, familyID) {
+		err = ErrRefreshTokenInvalid
+		return
+	}
+
+	// 用户可能已被删除
+	if err, _ = jwtService.GetUserInfo(GuardName, uid); err != nil {
+		jwtService.RevokeFamily(GuardName, familyID)
+		return
+	}
+
+	SessionService.extend(GuardName, uid, familyID, jwtService.refreshTtl(GuardName))
+	tokenData, err, _ = jwtService.issueToken(GuardName, uid, familyID)
+	return
+}
+
+// jwtTtl access token 有效期，guard 未单独配置时使用全局配置
+func (jwtService *jwtService) jwtTtl(GuardName string) time.Duration {
+	ttl := global.App.Config.Jwt.JwtTtl
+	if guard, ok := global.App.Config.Jwt.Guards[GuardName]; ok && guard.JwtTtl > 0 {
+		ttl = guard.JwtTtl
+	}
+	return time.Duration(ttl) * time.Second
+}
+
+// maxJwtTtl 所有 guard 中最长的 access token 有效期
+func (jwtService *jwtService) maxJwtTtl() time.Duration {
+	ttl := jwtService.jwtTtl(AppGuardName)
+	for guardName := range global.App.Config.Jwt.Guards {
+		if t := jwtService.jwtTtl(guardName); t > ttl {
+			ttl = t
+		}
+	}
+	return ttl
+}
+
+// refreshTtl refresh token 有效期，guard 未单独配置时使用全局配置，均未配置时使用默认值
+func (jwtService *jwtService) refreshTtl(GuardName string) time.Duration {
+	ttl := global.App.Config.Jwt.RefreshTtl
+	if guard, ok := global.App.Config.Jwt.Guards[GuardName]; ok && guard.RefreshTtl > 0 {
+		ttl = guard.RefreshTtl
+	}
+	if ttl <= 0 {
+		return defaultRefreshTtl
+	}
+	return time.Duration(ttl) * time.Second
+}
+
+// 获取 refresh token 缓存 key，只保存 token 的哈希
+func (jwtService *jwtService) getRefreshTokenKey(GuardName string, refreshToken string) string {
+	return "refresh_token:" + GuardName + ":" + utils.Sha256([]byte(refreshToken))
+}
+
+// IsFamilyActive token 家族是否有效
+func (jwtService *jwtService) IsFamilyActive(GuardName string, familyID string) bool {
+	if familyID == "" {
+		return false
+	}
+	return global.App.Redis.Exists(context.Background(), SessionService.getSessionKey(GuardName, familyID)).Val() > 0
+}
+
+// RevokeFamily 吊销 token 家族，家族下的 refresh token 和 access token 全部失效
+func (jwtService *jwtService) RevokeFamily(GuardName string, familyID string) {
+	SessionService.revoke(GuardName, familyID)
+}
+
+// ParseToken 解析并验签 token
+func (jwtService *jwtService) ParseToken(tokenStr string) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenStr, &CustomClaims{}, JwtKeyService.Keyfunc,
+		jwt.WithValidMethods(JwtKeyService.ValidMethods()))
+}
+
+// 获取黑名单缓存 key，按 guard 隔离
+func (jwtService *jwtService) getBlackListKey(GuardName string, tokenStr string) string {
+	return "jwt_black_list:" + GuardName + ":" + utils.MD5([]byte(tokenStr))
 }
 
 // JoinBlackList token 加入黑名单
-func (jwtService *jwtService) JoinBlackList(token *jwt.Token) (err error) {
+func (jwtService *jwtService) JoinBlackList(GuardName string, token *jwt.Token) (err error) {
 	nowUnix := time.Now().Unix()
-	timer := time.Duration(token.Claims.(*CustomClaims).ExpiresAt-nowUnix) * time.Second
+	timer := time.Duration(token.Claims.(*CustomClaims).ExpiresAt.Unix()-nowUnix) * time.Second
 	// 将 token 剩余时间设置为缓存有效期，并将当前时间作为缓存 value 值
-	err = global.App.Redis.SetNX(context.Background(), jwtService.getBlackListKey(token.Raw), nowUnix, timer).Err()
+	err = global.App.Redis.SetNX(context.Background(), jwtService.getBlackListKey(GuardName, token.Raw), nowUnix, timer).Err()
 	return
 }
 
 // IsInBlacklist token 是否在黑名单中
-func (jwtService *jwtService) IsInBlacklist(tokenStr string) bool {
-	joinUnixStr, err := global.App.Redis.Get(context.Background(), jwtService.getBlackListKey(tokenStr)).Result()
+func (jwtService *jwtService) IsInBlacklist(GuardName string, tokenStr string) bool {
+	joinUnixStr, err := global.App.Redis.Get(context.Background(), jwtService.getBlackListKey(GuardName, tokenStr)).Result()
 	joinUnix, err := strconv.ParseInt(joinUnixStr, 10, 64)
 	if joinUnixStr == "" || err != nil {
 		return false
@@ -92,6 +241,8 @@ func (jwtService *jwtService) GetUserInfo(GuardName string, id string) (err erro
 	switch GuardName {
 	case AppGuardName:
 		return UserService.GetUserInfo(id)
+	case AdminGuardName:
+		return AdminUserService.GetAdminInfo(id)
 	default:
 		err = errors.New("guard " + GuardName + " does not exist")
 	}
@@ -0,0 +1,370 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"gin-web/app/models"
+	"gin-web/global"
+	"gin-web/utils"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	SigningMethodHS256 = "HS256"
+	SigningMethodRS256 = "RS256"
+	SigningMethodEdDSA = "EdDSA"
+
+	defaultKeyRotationInterval = 7 * 24 * 3600
+	// JwksCacheTtl JWKS 响应允许外部验签方缓存的时长
+	JwksCacheTtl = 300 * time.Second
+	// 新密钥提前公开的时长，需超过 JWKS 缓存时长，外部验签方在新密钥签发前已刷新到它
+	jwtKeyPublishLead = 2 * JwksCacheTtl
+	// 未知 kid 时从数据库重新加载密钥的最小间隔，避免伪造 kid 打满数据库
+	jwtKeyReloadInterval = 10 * time.Second
+)
+
+type jwtKeyService struct {
+	mu         sync.RWMutex
+	signingKey *signingKey            // 当前签发用的密钥
+	verifyKeys map[string]*signingKey // kid => 可用于验签的密钥（含已停止签发但未过期的）
+	loadedAt   time.Time
+}
+
+var JwtKeyService = &jwtKeyService{verifyKeys: map[string]*signingKey{}}
+
+// signingKey 解析后的密钥
+type signingKey struct {
+	kid         string
+	algorithm   string
+	private     crypto.Signer
+	public      crypto.PublicKey
+	activatesAt *time.Time
+	retiredAt   *time.Time
+}
+
+// signing 在 at 时刻是否可用于签发
+func (key *signingKey) signing(at time.Time) bool {
+	return key.retiredAt == nil && (key.activatesAt == nil || !key.activatesAt.After(at))
+}
+
+// Jwk JSON Web Key
+type Jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// Jwks JSON Web Key Set
+type Jwks struct {
+	Keys []Jwk `json:"keys"`
+}
+
+// signingMethod 当前配置的签名算法
+func (s *jwtKeyService) signingMethod() string {
+	switch global.App.Config.Jwt.SigningMethod {
+	case SigningMethodRS256, SigningMethodEdDSA:
+		return global.App.Config.Jwt.SigningMethod
+	default:
+		return SigningMethodHS256
+	}
+}
+
+// isAsymmetric 是否使用非对称签名
+func (s *jwtKeyService) isAsymmetric() bool {
+	return s.signingMethod() != SigningMethodHS256
+}
+
+func (s *jwtKeyService) rotationInterval() time.Duration {
+	interval := global.App.Config.Jwt.KeyRotationInterval
+	if interval <= 0 {
+		interval = defaultKeyRotationInterval
+	}
+	return time.Duration(interval) * time.Second
+}
+
+// Sign 使用当前密钥签发 token
+func (s *jwtKeyService) Sign(claims jwt.Claims) (*jwt.Token, string, error) {
+	if !s.isAsymmetric() {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		tokenStr, err := token.SignedString([]byte(global.App.Config.Jwt.Secret))
+		return token, tokenStr, err
+	}
+
+	s.mu.RLock()
+	key := s.signingKey
+	s.mu.RUnlock()
+	if key == nil {
+		// 首次启动或还没加载到密钥
+		s.Rotate()
+		s.mu.RLock()
+		key = s.signingKey
+		s.mu.RUnlock()
+		if key == nil {
+			return nil, "", errors.New("jwt signing key not available")
+		}
+	}
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.algorithm), claims)
+	token.Header["kid"] = key.kid
+	tokenStr, err := token.SignedString(key.private)
+	return token, tokenStr, err
+}
+
+// Keyfunc 验签时按 token header 中的 kid 选择公钥
+func (s *jwtKeyService) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if !s.isAsymmetric() {
+		if token.Method.Alg() != SigningMethodHS256 {
+			return nil, errors.New("unexpected signing method")
+		}
+		return []byte(global.App.Config.Jwt.Secret), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key := s.verifyKey(kid)
+	if key == nil {
+		// 其他实例可能刚轮换了密钥
+		s.reloadIfStale()
+		if key = s.verifyKey(kid); key == nil {
+			return nil, errors.New("unknown kid")
+		}
+	}
+	if token.Method.Alg() != key.algorithm {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.public, nil
+}
+
+// ValidMethods 允许的签名算法，防止算法替换攻击
+func (s *jwtKeyService) ValidMethods() []string {
+	if !s.isAsymmetric() {
+		return []string{SigningMethodHS256}
+	}
+	return []string{SigningMethodRS256, SigningMethodEdDSA}
+}
+
+func (s *jwtKeyService) verifyKey(kid string) *signingKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.verifyKeys[kid]
+}
+
+// Jwks 公开的验签公钥集合，包含尚未开始签发的下一个密钥
+func (s *jwtKeyService) Jwks() Jwks {
+	jwks := Jwks{Keys: []Jwk{}}
+	if !s.isAsymmetric() {
+		return jwks
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, key := range s.verifyKeys {
+		jwk := Jwk{Kid: key.kid, Use: "sig", Alg: key.algorithm}
+		switch pub := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks
+}
+
+// Rotate 到期时生成新密钥并停用旧密钥，清理已过验签期的密钥，最后刷新内存中的密钥
+// 多实例部署时通过分布式锁保证只有一个实例执行轮换
+func (s *jwtKeyService) Rotate() {
+	if !s.isAsymmetric() {
+		return
+	}
+
+	lock := global.Lock("jwt_key_rotate_lock", 30)
+	if lock.Get() {
+		if err := s.rotateIfDue(); err != nil {
+			global.App.Log.Error("jwt key rotate failed", zap.Error(err))
+		}
+		lock.Release()
+	}
+
+	if err := s.load(); err != nil {
+		global.App.Log.Error("jwt key load failed", zap.Error(err))
+	}
+}
+
+// rotateIfDue 当前密钥临近到期或算法变更时生成下一个密钥，先只在 JWKS 中公开，jwtKeyPublishLead 后才开始签发
+// 没有任何可用密钥时（首次启动）新密钥立即签发；下一个密钥开始签发后停用旧密钥
+func (s *jwtKeyService) rotateIfDue() error {
+	algorithm := s.signingMethod()
+	now := time.Now()
+
+	var keys []models.JwtKey
+	if err := global.App.DB.Where("retired_at IS NULL").Order("id").Find(&keys).Error; err != nil {
+		return err
+	}
+	var active, next *models.JwtKey
+	for i := range keys {
+		if keys[i].ActivatesAt == nil || !keys[i].ActivatesAt.After(now) {
+			active = &keys[i]
+		} else {
+			next = &keys[i]
+		}
+	}
+
+	switch {
+	case active == nil && next == nil:
+		if err := s.createKey(algorithm, now); err != nil {
+			return err
+		}
+	case active != nil && next == nil:
+		activatedAt := active.CreatedAt
+		if active.ActivatesAt != nil {
+			activatedAt = *active.ActivatesAt
+		}
+		// 提前一个公开时长生成，使新密钥恰好在当前密钥到期时开始签发
+		if active.Algorithm != algorithm || now.Sub(activatedAt) >= s.rotationInterval()-jwtKeyPublishLead {
+			if err := s.createKey(algorithm, now.Add(jwtKeyPublishLead)); err != nil {
+				return err
+			}
+		}
+	}
+	if active != nil {
+		global.App.DB.Model(&models.JwtKey{}).
+			Where("retired_at IS NULL AND id < ?", active.ID.ID).
+			UpdateColumn("retired_at", now)
+	}
+
+	// 停用超过最长 access token 有效期的密钥已不可能再验签成功
+	expiredBefore := now.Add(-JwtService.maxJwtTtl() - time.Hour)
+	return global.App.DB.Where("retired_at < ?", expiredBefore).Delete(&models.JwtKey{}).Error
+}
+
+// createKey 生成密钥，activatesAt 起开始签发
+func (s *jwtKeyService) createKey(algorithm string, activatesAt time.Time) error {
+	privatePem, publicPem, err := generateKeyPair(algorithm)
+	if err != nil {
+		return err
+	}
+	return global.App.DB.Create(&models.JwtKey{
+		Kid:         utils.RandToken(12),
+		Algorithm:   algorithm,
+		PrivateKey:  privatePem,
+		PublicKey:   publicPem,
+		ActivatesAt: &activatesAt,
+	}).Error
+}
+
+// reloadIfStale 距上次加载超过最小间隔时重新加载
+func (s *jwtKeyService) reloadIfStale() {
+	s.mu.RLock()
+	stale := time.Since(s.loadedAt) > jwtKeyReloadInterval
+	s.mu.RUnlock()
+	if stale {
+		if err := s.load(); err != nil {
+			global.App.Log.Error("jwt key load failed", zap.Error(err))
+		}
+	}
+}
+
+// load 从数据库加载全部可用密钥
+func (s *jwtKeyService) load() error {
+	var keys []models.JwtKey
+	if err := global.App.DB.Order("id").Find(&keys).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	verifyKeys := make(map[string]*signingKey, len(keys))
+	var active *signingKey
+	for _, k := range keys {
+		key, err := parseKeyPair(k)
+		if err != nil {
+			global.App.Log.Error("jwt key parse failed", zap.String("kid", k.Kid), zap.Error(err))
+			continue
+		}
+		verifyKeys[key.kid] = key
+		// 按 id 升序，取最新一个已到签发时间的密钥；算法变更后旧算法密钥继续签发到新密钥生效
+		if key.signing(now) {
+			active = key
+		}
+	}
+
+	s.mu.Lock()
+	s.verifyKeys = verifyKeys
+	s.signingKey = active
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+// generateKeyPair 生成密钥对，返回 PEM 编码的私钥和公钥
+func generateKeyPair(algorithm string) (privatePem string, publicPem string, err error) {
+	var private crypto.Signer
+	switch algorithm {
+	case SigningMethodRS256:
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case SigningMethodEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		err = errors.New("unsupported signing method: " + algorithm)
+	}
+	if err != nil {
+		return
+	}
+
+	privateDer, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return
+	}
+	publicDer, err := x509.MarshalPKIXPublicKey(private.Public())
+	if err != nil {
+		return
+	}
+	privatePem = string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDer}))
+	publicPem = string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDer}))
+	return
+}
+
+// parseKeyPair 解析数据库中的 PEM 密钥
+func parseKeyPair(k models.JwtKey) (*signingKey, error) {
+	block, _ := pem.Decode([]byte(k.PrivateKey))
+	if block == nil {
+		return nil, errors.New("invalid private key pem")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	private, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("private key is not a signer")
+	}
+	return &signingKey{
+		kid:         k.Kid,
+		algorithm:   k.Algorithm,
+		private:     private,
+		public:      private.Public(),
+		activatesAt: k.ActivatesAt,
+		retiredAt:   k.RetiredAt,
+	}, nil
+}
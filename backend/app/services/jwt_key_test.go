@@ -0,0 +1,127 @@
+package services
+
+import (
+	"gin-web/app/models"
+	"gin-web/global"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func setupJwtKeyTest(t *testing.T) *jwtKeyService {
+	setupServiceTest(t)
+	global.App.Config.Jwt.SigningMethod = SigningMethodEdDSA
+	global.App.Config.Jwt.JwtTtl = 900
+	return &jwtKeyService{verifyKeys: map[string]*signingKey{}}
+}
+
+// signedKid 签发一个 token 并返回其 kid
+func signedKid(t *testing.T, s *jwtKeyService) (string, string) {
+	t.Helper()
+	token, tokenStr, err := s.Sign(jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token.Header["kid"].(string), tokenStr
+}
+
+func jwksKids(s *jwtKeyService) map[string]bool {
+	kids := map[string]bool{}
+	for _, key := range s.Jwks().Keys {
+		kids[key.Kid] = true
+	}
+	return kids
+}
+
+// activateKeys 将密钥的签发时间提前 d，模拟时间流逝
+func activateKeys(t *testing.T, d time.Duration) {
+	t.Helper()
+	var keys []models.JwtKey
+	global.App.DB.Find(&keys)
+	for _, key := range keys {
+		activatesAt := key.CreatedAt
+		if key.ActivatesAt != nil {
+			activatesAt = *key.ActivatesAt
+		}
+		global.App.DB.Model(&key).UpdateColumn("activates_at", activatesAt.Add(-d))
+	}
+}
+
+func TestJwtKeyFirstKeySignsImmediately(t *testing.T) {
+	s := setupJwtKeyTest(t)
+	s.Rotate()
+
+	kid, _ := signedKid(t, s)
+	if kids := jwksKids(s); len(kids) != 1 || !kids[kid] {
+		t.Fatalf("jwks kids = %v, want only %s", kids, kid)
+	}
+	// 未到期时不轮换
+	s.Rotate()
+	if len(jwksKids(s)) != 1 {
+		t.Fatal("rotated before due")
+	}
+}
+
+func TestJwtKeyPublishedBeforeSigning(t *testing.T) {
+	s := setupJwtKeyTest(t)
+	s.Rotate()
+	oldKid, oldToken := signedKid(t, s)
+
+	// 当前密钥临近到期，生成的新密钥只公开不签发
+	activateKeys(t, s.rotationInterval()-jwtKeyPublishLead)
+	s.Rotate()
+	kids := jwksKids(s)
+	if len(kids) != 2 || !kids[oldKid] {
+		t.Fatalf("jwks kids = %v, want old and next key", kids)
+	}
+	if kid, _ := signedKid(t, s); kid != oldKid {
+		t.Fatalf("signed with %s before next key activates, want %s", kid, oldKid)
+	}
+	var next models.JwtKey
+	global.App.DB.Where("kid <> ?", oldKid).First(&next)
+	if next.ActivatesAt == nil || time.Until(*next.ActivatesAt) < JwksCacheTtl {
+		t.Fatalf("next key activates at %v, want at least %v later", next.ActivatesAt, JwksCacheTtl)
+	}
+
+	// 提前公开期过后新密钥开始签发，旧密钥停用但仍可验签
+	activateKeys(t, jwtKeyPublishLead)
+	s.Rotate()
+	if kid, _ := signedKid(t, s); kid != next.Kid {
+		t.Fatalf("signed with %s, want %s", kid, next.Kid)
+	}
+	var old models.JwtKey
+	global.App.DB.Where("kid = ?", oldKid).First(&old)
+	if old.RetiredAt == nil {
+		t.Fatal("old key not retired")
+	}
+	if _, err := jwt.Parse(oldToken, s.Keyfunc, jwt.WithValidMethods(s.ValidMethods())); err != nil {
+		t.Fatalf("token signed by retired key rejected: %v", err)
+	}
+}
+
+func TestJwtKeyAlgorithmChange(t *testing.T) {
+	s := setupJwtKeyTest(t)
+	s.Rotate()
+	oldKid, _ := signedKid(t, s)
+
+	// 算法变更后旧算法密钥继续签发，直到新密钥公开足够久
+	global.App.Config.Jwt.SigningMethod = SigningMethodRS256
+	s.Rotate()
+	if len(jwksKids(s)) != 2 {
+		t.Fatalf("jwks kids = %v, want 2", jwksKids(s))
+	}
+	if kid, _ := signedKid(t, s); kid != oldKid {
+		t.Fatalf("signed with %s, want %s", kid, oldKid)
+	}
+
+	activateKeys(t, jwtKeyPublishLead)
+	s.Rotate()
+	token, _, err := s.Sign(jwt.RegisteredClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Method.Alg() != SigningMethodRS256 {
+		t.Fatalf("alg = %s, want RS256", token.Method.Alg())
+	}
+}
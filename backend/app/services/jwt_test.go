@@ -0,0 +1,130 @@
+package services
+
+import (
+	"errors"
+	"gin-web/app/models"
+	"gin-web/global"
+	"testing"
+	"time"
+)
+
+func createTestUser(t *testing.T, mobile string) models.User {
+	t.Helper()
+	user := models.User{Name: "user" + mobile, Mobile: mobile}
+	if err := global.App.DB.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// setupJwtTest 使用 HS256 签发 token
+func setupJwtTest(t *testing.T) models.User {
+	setupServiceTest(t)
+	global.App.Config.Jwt.Secret = "test-secret"
+	global.App.Config.Jwt.JwtTtl = 3600
+	global.App.Config.Jwt.RefreshTtl = 86400
+	return createTestUser(t, "13800000000")
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	user := setupJwtTest(t)
+	s := JwtService
+
+	first, err, _ := s.CreateToken(AppGuardName, user, SessionClient{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err, second := s.Refresh(AppGuardName, first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.RefreshToken == first.RefreshToken || second.AccessToken == "" {
+		t.Fatalf("token not rotated: %+v", second)
+	}
+
+	token, err := s.ParseToken(second.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := token.Claims.(*CustomClaims)
+	if claims.ID != user.GetUid() || !s.IsFamilyActive(AppGuardName, claims.FamilyID) {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+	if err, _ = s.Refresh(AppGuardName, second.RefreshToken); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	user := setupJwtTest(t)
+	s := JwtService
+
+	first, _, _ := s.CreateToken(AppGuardName, user, SessionClient{})
+	err, second := s.Refresh(AppGuardName, first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, _ := s.ParseToken(second.AccessToken)
+	familyID := token.Claims.(*CustomClaims).FamilyID
+
+	// 已使用的 refresh token 再次出现，整个家族失效
+	if err, _ = s.Refresh(AppGuardName, first.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("err = %v, want ErrRefreshTokenReused", err)
+	}
+	if s.IsFamilyActive(AppGuardName, familyID) {
+		t.Fatal("family still active after reuse")
+	}
+	if err, _ = s.Refresh(AppGuardName, second.RefreshToken); !errors.Is(err, ErrRefreshTokenInvalid) {
+		t.Fatalf("latest token: err = %v, want ErrRefreshTokenInvalid", err)
+	}
+
+	// 其他会话不受影响
+	other, _, _ := s.CreateToken(AppGuardName, user, SessionClient{})
+	if err, _ = s.Refresh(AppGuardName, other.RefreshToken); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRefreshRejectsInvalidToken(t *testing.T) {
+	user := setupJwtTest(t)
+	s := JwtService
+
+	if err, _ := s.Refresh(AppGuardName, "unknown"); !errors.Is(err, ErrRefreshTokenInvalid) {
+		t.Fatalf("unknown token: err = %v, want ErrRefreshTokenInvalid", err)
+	}
+
+	// refresh token 按 guard 隔离
+	tokens, _, _ := s.CreateToken(AppGuardName, user, SessionClient{})
+	if err, _ := s.Refresh(AdminGuardName, tokens.RefreshToken); !errors.Is(err, ErrRefreshTokenInvalid) {
+		t.Fatalf("other guard: err = %v, want ErrRefreshTokenInvalid", err)
+	}
+
+	// 用户删除后不能续期
+	tokens, _, _ = s.CreateToken(AppGuardName, user, SessionClient{})
+	global.App.DB.Delete(&user)
+	if err, _ := s.Refresh(AppGuardName, tokens.RefreshToken); err == nil {
+		t.Fatal("refreshed token of deleted user")
+	}
+}
+
+func TestRefreshTokenExpires(t *testing.T) {
+	mr := setupServiceTest(t)
+	global.App.Config.Jwt.Secret = "test-secret"
+	user := createTestUser(t, "13800000000")
+	s := JwtService
+
+	// 未配置 refresh_ttl 时使用默认有效期
+	if s.refreshTtl(AppGuardName) != defaultRefreshTtl {
+		t.Fatalf("refreshTtl = %v, want %v", s.refreshTtl(AppGuardName), defaultRefreshTtl)
+	}
+	tokens, _, _ := s.CreateToken(AppGuardName, user, SessionClient{})
+	mr.FastForward(defaultRefreshTtl - time.Minute)
+	err, tokens := s.Refresh(AppGuardName, tokens.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mr.FastForward(defaultRefreshTtl + time.Minute)
+	if err, _ = s.Refresh(AppGuardName, tokens.RefreshToken); !errors.Is(err, ErrRefreshTokenInvalid) {
+		t.Fatalf("expired token: err = %v, want ErrRefreshTokenInvalid", err)
+	}
+}
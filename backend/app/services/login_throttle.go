@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gin-web/global"
+	"gin-web/utils"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultMaxAccountFailures = 5
+	defaultMaxIpFailures      = 20
+	defaultFailureWindow      = 900
+	defaultLockoutDuration    = 900
+	defaultLoginDelayAfter    = 3
+	defaultLoginMaxDelay      = 30
+)
+
+type loginThrottleService struct{}
+
+var LoginThrottleService = new(loginThrottleService)
+
+// recordLoginFailureLuaScript 累加失败次数，达到上限时锁定，超过阈值后设置下次允许尝试的等待时间
+// KEYS: 失败计数、锁定标记、等待标记；ARGV: 统计窗口、失败上限、锁定时长、开始等待的次数、最长等待
+// 返回 1 表示本次失败触发了锁定
+const recordLoginFailureLuaScript = `
+local n = redis.call("INCR", KEYS[1])
+if n == 1 then
+    redis.call("EXPIRE", KEYS[1], ARGV[1])
+end
+if n >= tonumber(ARGV[2]) then
+    redis.call("SET", KEYS[2], 1, "EX", ARGV[3])
+    redis.call("DEL", KEYS[1], KEYS[3])
+    return 1
+end
+local over = n - tonumber(ARGV[4])
+if over >= 0 then
+    local delay = math.min(2 ^ over, tonumber(ARGV[5]))
+    redis.call("SET", KEYS[3], 1, "EX", delay)
+end
+return 0
+`
+
+var recordLoginFailureScript = redis.NewScript(recordLoginFailureLuaScript)
+
+// ErrLoginThrottled 锁定和等待使用相同的提示，且与账号是否存在无关
+type ErrLoginThrottled struct {
+	RetryAfter time.Duration
+}
+
+func (e ErrLoginThrottled) Error() string {
+	if e.RetryAfter >= time.Minute {
+		return fmt.Sprintf("尝试次数过多，请 %d 分钟后再试", int((e.RetryAfter+time.Minute-1)/time.Minute))
+	}
+	return fmt.Sprintf("尝试过于频繁，请 %d 秒后再试", int((e.RetryAfter+time.Second-1)/time.Second))
+}
+
+func (s *loginThrottleService) config() (maxAccount, maxIp, window, lockout, delayAfter, maxDelay int64) {
+	conf := global.App.Config.LoginThrottle
+	pick := func(v int64, d int64) int64 {
+		if v <= 0 {
+			return d
+		}
+		return v
+	}
+	return pick(conf.MaxAccountFailures, defaultMaxAccountFailures),
+		pick(conf.MaxIpFailures, defaultMaxIpFailures),
+		pick(conf.FailureWindow, defaultFailureWindow),
+		pick(conf.LockoutDuration, defaultLockoutDuration),
+		pick(conf.DelayAfter, defaultLoginDelayAfter),
+		pick(conf.MaxDelay, defaultLoginMaxDelay)
+}
+
+// 获取计数相关缓存 key，账号按 guard 隔离，账号只保存哈希避免日志和缓存中出现明文手机号
+func (s *loginThrottleService) getKey(kind string, subject string) string {
+	return "login_throttle:" + kind + ":" + subject
+}
+
+func (s *loginThrottleService) accountSubject(GuardName string, account string) string {
+	return GuardName + ":" + utils.MD5([]byte(account))
+}
+
+// Check 登录前检查账号和 IP 是否被锁定或需要等待
+func (s *loginThrottleService) Check(GuardName string, account string, ip string) error {
+	ctx := context.Background()
+	accountSubject := s.accountSubject(GuardName, account)
+	pipe := global.App.Redis.Pipeline()
+	cmds := []*redis.DurationCmd{
+		pipe.TTL(ctx, s.getKey("lock", accountSubject)),
+		pipe.TTL(ctx, s.getKey("lock", "ip:"+ip)),
+		pipe.TTL(ctx, s.getKey("wait", accountSubject)),
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		// Redis 不可用时不阻断登录
+		global.App.Log.Error("login throttle check failed", zap.Error(err))
+		return nil
+	}
+
+	var retryAfter time.Duration
+	for _, cmd := range cmds {
+		if ttl := cmd.Val(); ttl > retryAfter {
+			retryAfter = ttl
+		}
+	}
+	if retryAfter > 0 {
+		return ErrLoginThrottled{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// RecordFailure 记录一次登录失败
+func (s *loginThrottleService) RecordFailure(GuardName string, account string, ip string) {
+	maxAccount, maxIp, window, lockout, delayAfter, maxDelay := s.config()
+	ctx := context.Background()
+	accountSubject := s.accountSubject(GuardName, account)
+
+	accountLocked, err := recordLoginFailureScript.Run(ctx, global.App.Redis,
+		[]string{s.getKey("fail", accountSubject), s.getKey("lock", accountSubject), s.getKey("wait", accountSubject)},
+		window, maxAccount, lockout, delayAfter, maxDelay).Int()
+	if err != nil {
+		global.App.Log.Error("login throttle record failed", zap.Error(err))
+		return
+	}
+	// IP 维度只做锁定，不做逐次等待，避免同一出口 IP 的正常用户互相影响
+	ipLocked, err := recordLoginFailureScript.Run(ctx, global.App.Redis,
+		[]string{s.getKey("fail", "ip:"+ip), s.getKey("lock", "ip:"+ip), s.getKey("wait", "ip:"+ip)},
+		window, maxIp, lockout, maxIp, 0).Int()
+	if err != nil {
+		global.App.Log.Error("login throttle record failed", zap.Error(err))
+		return
+	}
+
+	global.App.Log.Warn("security event: login failed",
+		zap.String("guard", GuardName), zap.String("account", utils.MaskAccount(account)), zap.String("ip", ip))
+	if accountLocked == 1 {
+		global.App.Log.Warn("security event: account locked",
+			zap.String("guard", GuardName), zap.String("account", utils.MaskAccount(account)), zap.String("ip", ip))
+	}
+	if ipLocked == 1 {
+		global.App.Log.Warn("security event: ip locked", zap.String("guard", GuardName), zap.String("ip", ip))
+	}
+}
+
+// RecordSuccess 登录成功后清除账号的失败记录，IP 记录保留到窗口结束
+func (s *loginThrottleService) RecordSuccess(GuardName string, account string) {
+	accountSubject := s.accountSubject(GuardName, account)
+	global.App.Redis.Del(context.Background(), s.getKey("fail", accountSubject), s.getKey("wait", accountSubject))
+}
+
+// Unlock 管理员解除账号或 IP 锁定
+func (s *loginThrottleService) Unlock(GuardName string, account string, ip string) error {
+	if account == "" && ip == "" {
+		return errors.New("请指定账号或 IP")
+	}
+	ctx := context.Background()
+	var keys []string
+	if account != "" {
+		accountSubject := s.accountSubject(GuardName, account)
+		keys = append(keys, s.getKey("fail", accountSubject), s.getKey("lock", accountSubject), s.getKey("wait", accountSubject))
+	}
+	if ip != "" {
+		keys = append(keys, s.getKey("fail", "ip:"+ip), s.getKey("lock", "ip:"+ip), s.getKey("wait", "ip:"+ip))
+	}
+	if err := global.App.Redis.Del(ctx, keys...).Err(); err != nil {
+		return err
+	}
+	global.App.Log.Warn("security event: login unlocked",
+		zap.String("guard", GuardName), zap.String("account", utils.MaskAccount(account)), zap.String("ip", ip))
+	return nil
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"gin-web/global"
+	"testing"
+	"time"
+)
+
+// retryAfter Check 返回的等待时间，未被限制时为 0
+func retryAfter(t *testing.T, err error) time.Duration {
+	t.Helper()
+	if err == nil {
+		return 0
+	}
+	var throttled ErrLoginThrottled
+	if !errors.As(err, &throttled) {
+		t.Fatalf("err = %v, want ErrLoginThrottled", err)
+	}
+	return throttled.RetryAfter
+}
+
+func TestLoginThrottleAccountDelayAndLock(t *testing.T) {
+	mr := setupServiceTest(t)
+	s := LoginThrottleService
+	const account = "13800000000"
+
+	// 前 delay_after-1 次失败不需要等待，之后等待时间逐次翻倍
+	wants := []time.Duration{0, 0, time.Second, 2 * time.Second}
+	for i, want := range wants {
+		s.RecordFailure(AppGuardName, account, "10.0.0.1")
+		if got := retryAfter(t, s.Check(AppGuardName, account, "10.0.0.2")); got != want {
+			t.Fatalf("failure %d: retry after %v, want %v", i+1, got, want)
+		}
+	}
+	mr.FastForward(2 * time.Second)
+	if err := s.Check(AppGuardName, account, "10.0.0.2"); err != nil {
+		t.Fatalf("still throttled after wait: %v", err)
+	}
+
+	// 达到失败上限后锁定
+	s.RecordFailure(AppGuardName, account, "10.0.0.1")
+	if got := retryAfter(t, s.Check(AppGuardName, account, "10.0.0.2")); got != defaultLockoutDuration*time.Second {
+		t.Fatalf("retry after %v, want lockout %v", got, defaultLockoutDuration*time.Second)
+	}
+	// 锁定按 guard 隔离
+	if err := s.Check(AdminGuardName, account, "10.0.0.2"); err != nil {
+		t.Fatalf("admin guard throttled: %v", err)
+	}
+
+	mr.FastForward(defaultLockoutDuration * time.Second)
+	if err := s.Check(AppGuardName, account, "10.0.0.2"); err != nil {
+		t.Fatalf("still locked after lockout: %v", err)
+	}
+}
+
+func TestLoginThrottleSuccessResetsAccount(t *testing.T) {
+	setupServiceTest(t)
+	s := LoginThrottleService
+	const account = "13800000000"
+
+	for i := 0; i < defaultMaxAccountFailures-1; i++ {
+		s.RecordFailure(AppGuardName, account, "10.0.0.1")
+	}
+	s.RecordSuccess(AppGuardName, account)
+	if err := s.Check(AppGuardName, account, "10.0.0.1"); err != nil {
+		t.Fatalf("throttled after success: %v", err)
+	}
+	// 成功后重新计数，不会因之前的失败立即锁定
+	s.RecordFailure(AppGuardName, account, "10.0.0.1")
+	if err := s.Check(AppGuardName, account, "10.0.0.1"); err != nil {
+		t.Fatalf("throttled after one failure: %v", err)
+	}
+}
+
+func TestLoginThrottleIpLock(t *testing.T) {
+	setupServiceTest(t)
+	global.App.Config.LoginThrottle.MaxIpFailures = 3
+	s := LoginThrottleService
+
+	// 不同账号的失败累计到同一 IP，IP 维度没有逐次等待
+	for i := 0; i < 2; i++ {
+		s.RecordFailure(AppGuardName, fmt.Sprintf("1380000000%d", i), "10.0.0.1")
+		if err := s.Check(AppGuardName, "13900000000", "10.0.0.1"); err != nil {
+			t.Fatalf("failure %d: ip throttled: %v", i+1, err)
+		}
+	}
+	s.RecordFailure(AppGuardName, "13800000002", "10.0.0.1")
+	if got := retryAfter(t, s.Check(AppGuardName, "13900000000", "10.0.0.1")); got != defaultLockoutDuration*time.Second {
+		t.Fatalf("retry after %v, want lockout", got)
+	}
+	if err := s.Check(AppGuardName, "13900000000", "10.0.0.2"); err != nil {
+		t.Fatalf("other ip throttled: %v", err)
+	}
+
+	// 管理员解除 IP 锁定
+	if err := s.Unlock(AppGuardName, "", "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Check(AppGuardName, "13900000000", "10.0.0.1"); err != nil {
+		t.Fatalf("throttled after unlock: %v", err)
+	}
+	if err := s.Unlock(AppGuardName, "", ""); err == nil {
+		t.Fatal("unlocked without account or ip")
+	}
+}
+
+func TestLoginThrottleRedisFailure(t *testing.T) {
+	mr := setupServiceTest(t)
+	s := LoginThrottleService
+
+	// Redis 不可用时不阻断登录
+	mr.SetError("LOADING")
+	s.RecordFailure(AppGuardName, "13800000000", "10.0.0.1")
+	if err := s.Check(AppGuardName, "13800000000", "10.0.0.1"); err != nil {
+		t.Fatalf("err = %v, want nil while redis failing", err)
+	}
+}
@@ -0,0 +1,373 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"gin-web/app/common/response"
+	"gin-web/app/models"
+	"gin-web/global"
+	"gin-web/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+)
+
+const (
+	recoveryCodeCount       = 10
+	defaultMfaChallengeTtl  = 300
+	mfaChallengeMaxAttempts = 5
+)
+
+type mfaService struct{}
+
+var MfaService = new(mfaService)
+
+var (
+	ErrMfaCodeInvalid      = errors.New("验证码错误")
+	ErrMfaChallengeInvalid = errors.New("登录已过期，请重新登录")
+)
+
+// checkMfaChallengeLuaScript 累加校验次数，超过上限时作废，返回 {uid, mode}，不存在或已作废返回空
+const checkMfaChallengeLuaScript = `
+local data = redis.call("HMGET", KEYS[1], "uid", "mode")
+if not data[1] then
+    return {}
+end
+local attempts = redis.call("HINCRBY", KEYS[1], "attempts", 1)
+if attempts > tonumber(ARGV[1]) then
+    redis.call("DEL", KEYS[1])
+    return {}
+end
+return data
+`
+
+// 登录第二步凭证类型
+const (
+	mfaChallengeVerify = "verify" // 已开启两步验证，输入验证码
+	mfaChallengeSetup  = "setup"  // 账号要求开启但尚未开启，先完成绑定
+)
+
+// getMfa 获取账号两步验证设置
+func (s *mfaService) getMfa(db *gorm.DB, guard string, userID uint) (mfa models.UserMfa, found bool, err error) {
+	result := db.Where("guard = ? AND user_id = ?", guard, userID).Limit(1).Find(&mfa)
+	return mfa, result.RowsAffected > 0, result.Error
+}
+
+// IsEnabled 账号是否已开启两步验证
+func (s *mfaService) IsEnabled(guard string, userID uint) bool {
+	var count int64
+	global.App.DB.Model(&models.UserMfa{}).Where("guard = ? AND user_id = ? AND enabled_at IS NOT NULL", guard, userID).Count(&count)
+	return count > 0
+}
+
+// Required 按角色判断是否必须开启两步验证
+func (s *mfaService) Required(access UserAccess) bool {
+	return len(global.App.Config.Mfa.EnforceRoles) > 0 && access.HasRole(global.App.Config.Mfa.EnforceRoles...)
+}
+
+// RequiredFor 账号是否必须开启两步验证，后台账号一律要求，前台用户按角色判断
+func (s *mfaService) RequiredFor(guard string, userID uint) bool {
+	if guard == AdminGuardName {
+		return true
+	}
+	err, access := RbacService.GetUserAccess(userID)
+	return err == nil && s.Required(access)
+}
+
+// Status 两步验证状态
+func (s *mfaService) Status(guard string, userID uint) (err error, status response.MfaStatus) {
+	mfa, found, err := s.getMfa(global.App.DB, guard, userID)
+	if err != nil {
+		return
+	}
+	if found && mfa.EnabledAt != nil {
+		status.Enabled = true
+		status.EnabledAt = mfa.EnabledAt
+		global.App.DB.Model(&models.UserRecoveryCode{}).
+			Where("guard = ? AND user_id = ? AND used_at IS NULL", guard, userID).
+			Count(&status.RecoveryCodesLeft)
+	}
+	status.Required = s.RequiredFor(guard, userID)
+	return
+}
+
+// accountName 验证器 App 中显示的账号名称
+func (s *mfaService) accountName(guard string, userID uint) (string, error) {
+	if guard == AdminGuardName {
+		var adminUser models.AdminUser
+		if err := global.App.DB.First(&adminUser, userID).Error; err != nil {
+			return "", errors.New("账号不存在")
+		}
+		return adminUser.Username + " (admin)", nil
+	}
+
+	var user models.User
+	if err := global.App.DB.First(&user, userID).Error; err != nil {
+		return "", errors.New("用户不存在")
+	}
+	account := user.Mobile
+	if user.Email != nil && *user.Email != "" {
+		account = *user.Email
+	}
+	if account == "" {
+		account = user.Name
+	}
+	return account, nil
+}
+
+// Setup 生成新的 TOTP 密钥，需调用 Enable 校验验证码后才生效
+func (s *mfaService) Setup(guard string, userID uint) (err error, setup response.MfaSetup) {
+	account, err := s.accountName(guard, userID)
+	if err != nil {
+		return
+	}
+	mfa, found, err := s.getMfa(global.App.DB, guard, userID)
+	if err != nil {
+		return
+	}
+	if found && mfa.EnabledAt != nil {
+		err = errors.New("已开启两步验证")
+		return
+	}
+
+	secret := utils.TotpSecret()
+	if found {
+		err = global.App.DB.Model(&mfa).Updates(map[string]interface{}{"secret": secret, "last_used_step": 0}).Error
+	} else {
+		err = global.App.DB.Create(&models.UserMfa{Guard: guard, UserID: userID, Secret: secret}).Error
+	}
+	if err != nil {
+		return
+	}
+	setup = response.MfaSetup{Secret: secret, Uri: utils.TotpURI(s.issuer(), account, secret)}
+	return
+}
+
+func (s *mfaService) issuer() string {
+	if global.App.Config.Mfa.Issuer != "" {
+		return global.App.Config.Mfa.Issuer
+	}
+	return global.App.Config.App.AppName
+}
+
+// Enable 校验验证码后开启两步验证，并生成恢复码
+func (s *mfaService) Enable(guard string, userID uint, code string) (err error, codes response.MfaRecoveryCodes) {
+	mfa, found, err := s.getMfa(global.App.DB, guard, userID)
+	if err != nil {
+		return
+	}
+	if !found {
+		err = errors.New("请先获取绑定二维码")
+		return
+	}
+	if mfa.EnabledAt != nil {
+		err = errors.New("已开启两步验证")
+		return
+	}
+	if err = s.checkTotp(mfa, code); err != nil {
+		return
+	}
+
+	err = global.App.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&mfa).UpdateColumn("enabled_at", time.Now()).Error; err != nil {
+			return err
+		}
+		var txErr error
+		codes.RecoveryCodes, txErr = s.resetRecoveryCodes(tx, guard, userID)
+		return txErr
+	})
+	return
+}
+
+// Disable 关闭两步验证，账号要求开启时不允许关闭
+func (s *mfaService) Disable(guard string, userID uint, code string) error {
+	if s.RequiredFor(guard, userID) {
+		return errors.New("当前账号要求开启两步验证，无法关闭")
+	}
+	if err := s.Verify(guard, userID, code); err != nil {
+		return err
+	}
+	return global.App.DB.Transaction(func(tx *gorm.DB) error {
+		return s.delete(tx, guard, userID)
+	})
+}
+
+// delete 删除账号的两步验证设置和恢复码
+func (s *mfaService) delete(tx *gorm.DB, guard string, userID uint) error {
+	if err := tx.Where("guard = ? AND user_id = ?", guard, userID).Delete(&models.UserRecoveryCode{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("guard = ? AND user_id = ?", guard, userID).Delete(&models.UserMfa{}).Error
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧恢复码全部作废
+func (s *mfaService) RegenerateRecoveryCodes(guard string, userID uint, code string) (err error, codes response.MfaRecoveryCodes) {
+	if err = s.Verify(guard, userID, code); err != nil {
+		return
+	}
+	err = global.App.DB.Transaction(func(tx *gorm.DB) error {
+		var txErr error
+		codes.RecoveryCodes, txErr = s.resetRecoveryCodes(tx, guard, userID)
+		return txErr
+	})
+	return
+}
+
+// resetRecoveryCodes 删除旧恢复码并生成新恢复码
+func (s *mfaService) resetRecoveryCodes(tx *gorm.DB, guard string, userID uint) ([]string, error) {
+	if err := tx.Where("guard = ? AND user_id = ?", guard, userID).Delete(&models.UserRecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	codes := make([]string, recoveryCodeCount)
+	records := make([]models.UserRecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		raw := utils.RandDigits(10)
+		codes[i] = raw[:5] + "-" + raw[5:]
+		records[i] = models.UserRecoveryCode{Guard: guard, UserID: userID, CodeHash: s.hashRecoveryCode(raw)}
+	}
+	return codes, tx.Create(&records).Error
+}
+
+func (s *mfaService) hashRecoveryCode(code string) string {
+	return utils.Sha256([]byte(strings.ReplaceAll(strings.TrimSpace(code), "-", "")))
+}
+
+// checkTotp 校验 TOTP 验证码，同一时间步的验证码只能使用一次
+func (s *mfaService) checkTotp(mfa models.UserMfa, code string) error {
+	step, ok := utils.TotpValidate(mfa.Secret, strings.TrimSpace(code), time.Now())
+	if !ok {
+		return ErrMfaCodeInvalid
+	}
+	result := global.App.DB.Model(&models.UserMfa{}).
+		Where("id = ? AND last_used_step < ?", mfa.ID.ID, step).
+		UpdateColumn("last_used_step", step)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrMfaCodeInvalid
+	}
+	return nil
+}
+
+// Verify 校验已开启两步验证账号的 TOTP 验证码或恢复码
+func (s *mfaService) Verify(guard string, userID uint, code string) error {
+	mfa, found, err := s.getMfa(global.App.DB, guard, userID)
+	if err != nil {
+		return err
+	}
+	if !found || mfa.EnabledAt == nil {
+		return errors.New("未开启两步验证")
+	}
+	// 恢复码格式为 xxxxx-xxxxx，与 6 位 TOTP 验证码区分
+	if len(strings.ReplaceAll(strings.TrimSpace(code), "-", "")) != utils.TotpDigits {
+		result := global.App.DB.Model(&models.UserRecoveryCode{}).
+			Where("guard = ? AND user_id = ? AND code_hash = ? AND used_at IS NULL", guard, userID, s.hashRecoveryCode(code)).
+			UpdateColumn("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrMfaCodeInvalid
+		}
+		return nil
+	}
+	return s.checkTotp(mfa, code)
+}
+
+func (s *mfaService) challengeTtl() time.Duration {
+	ttl := global.App.Config.Mfa.ChallengeTtl
+	if ttl <= 0 {
+		ttl = defaultMfaChallengeTtl
+	}
+	return time.Duration(ttl) * time.Second
+}
+
+// 获取登录第二步缓存 key，按 guard 区分，前台凭证不能用于后台登录
+func (s *mfaService) getChallengeKey(guard string, token string) string {
+	return "mfa_challenge:" + guard + ":" + utils.Sha256([]byte(token))
+}
+
+// LoginChallenge 身份校验通过后判断是否需要两步验证
+// 已开启时返回输入验证码的凭证，账号要求开启但尚未开启时返回绑定凭证，都不需要时返回 nil
+func (s *mfaService) LoginChallenge(guard string, userID uint) (err error, challenge *response.MfaChallenge) {
+	mode := ""
+	switch {
+	case s.IsEnabled(guard, userID):
+		mode = mfaChallengeVerify
+	case s.RequiredFor(guard, userID):
+		mode = mfaChallengeSetup
+	default:
+		return
+	}
+
+	token := utils.RandToken(32)
+	key := s.getChallengeKey(guard, token)
+	ctx := context.Background()
+	pipe := global.App.Redis.TxPipeline()
+	pipe.HSet(ctx, key, "uid", userID, "mode", mode, "attempts", 0)
+	pipe.Expire(ctx, key, s.challengeTtl())
+	if _, err = pipe.Exec(ctx); err != nil {
+		return
+	}
+	challenge = &response.MfaChallenge{
+		MfaRequired:   true,
+		SetupRequired: mode == mfaChallengeSetup,
+		MfaToken:      token,
+		ExpiresIn:     int(s.challengeTtl() / time.Second),
+	}
+	return
+}
+
+// checkChallenge 校验凭证是否存在且类型匹配，每次校验计入尝试次数
+func (s *mfaService) checkChallenge(guard string, token string, mode string) (uint, error) {
+	data, err := redis.NewScript(checkMfaChallengeLuaScript).Run(context.Background(), global.App.Redis,
+		[]string{s.getChallengeKey(guard, token)}, mfaChallengeMaxAttempts).StringSlice()
+	if err != nil || len(data) != 2 || data[1] != mode {
+		return 0, ErrMfaChallengeInvalid
+	}
+	userID, err := strconv.Atoi(data[0])
+	if err != nil {
+		return 0, ErrMfaChallengeInvalid
+	}
+	return uint(userID), nil
+}
+
+// VerifyChallenge 校验登录第二步，成功后凭证失效
+func (s *mfaService) VerifyChallenge(guard string, token string, code string) (err error, user JwtUser) {
+	userID, err := s.checkChallenge(guard, token, mfaChallengeVerify)
+	if err != nil {
+		return
+	}
+	if err = s.Verify(guard, userID, code); err != nil {
+		return
+	}
+	global.App.Redis.Del(context.Background(), s.getChallengeKey(guard, token))
+	return JwtService.GetUserInfo(guard, strconv.Itoa(int(userID)))
+}
+
+// SetupChallenge 登录时强制绑定：凭绑定凭证生成 TOTP 密钥
+func (s *mfaService) SetupChallenge(guard string, token string) (err error, setup response.MfaSetup) {
+	userID, err := s.checkChallenge(guard, token, mfaChallengeSetup)
+	if err != nil {
+		return
+	}
+	return s.Setup(guard, userID)
+}
+
+// EnableChallenge 登录时强制绑定：校验验证码开启两步验证，成功后凭证失效
+func (s *mfaService) EnableChallenge(guard string, token string, code string) (err error, user JwtUser, codes response.MfaRecoveryCodes) {
+	userID, err := s.checkChallenge(guard, token, mfaChallengeSetup)
+	if err != nil {
+		return
+	}
+	if err, codes = s.Enable(guard, userID, code); err != nil {
+		return
+	}
+	global.App.Redis.Del(context.Background(), s.getChallengeKey(guard, token))
+	err, user = JwtService.GetUserInfo(guard, strconv.Itoa(int(userID)))
+	return
+}
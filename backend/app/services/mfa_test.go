@@ -0,0 +1,186 @@
+package services
+
+import (
+	"errors"
+	"gin-web/app/models"
+	"gin-web/global"
+	"gin-web/utils"
+	"testing"
+	"time"
+)
+
+// totpCode 当前时间步偏移 offset 的验证码
+func totpCode(t *testing.T, secret string, offset int64) string {
+	t.Helper()
+	code, err := utils.TotpCode(secret, utils.TotpStep(time.Now())+offset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+// enableTestMfa 为账号开启两步验证，返回 TOTP 密钥和恢复码
+func enableTestMfa(t *testing.T, guard string, userID uint) (string, []string) {
+	t.Helper()
+	err, setup := MfaService.Setup(guard, userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err, codes := MfaService.Enable(guard, userID, totpCode(t, setup.Secret, -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return setup.Secret, codes.RecoveryCodes
+}
+
+func createTestAdmin(t *testing.T, username string) models.AdminUser {
+	t.Helper()
+	adminUser := models.AdminUser{Username: username, Status: models.AdminUserStatusEnabled}
+	if err := global.App.DB.Create(&adminUser).Error; err != nil {
+		t.Fatal(err)
+	}
+	return adminUser
+}
+
+func TestMfaEnableAndVerify(t *testing.T) {
+	setupServiceTest(t)
+	user := createTestUser(t, "13800000000")
+	s := MfaService
+
+	secret, recoveryCodes := enableTestMfa(t, AppGuardName, user.ID.ID)
+	if !s.IsEnabled(AppGuardName, user.ID.ID) || len(recoveryCodes) != recoveryCodeCount {
+		t.Fatalf("mfa not enabled, recovery codes %v", recoveryCodes)
+	}
+
+	// 同一时间步及更早的验证码不能重复使用
+	if err := s.Verify(AppGuardName, user.ID.ID, totpCode(t, secret, -1)); !errors.Is(err, ErrMfaCodeInvalid) {
+		t.Fatalf("reused code: err = %v, want ErrMfaCodeInvalid", err)
+	}
+	if err := s.Verify(AppGuardName, user.ID.ID, totpCode(t, secret, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Verify(AppGuardName, user.ID.ID, "bad-code"); !errors.Is(err, ErrMfaCodeInvalid) {
+		t.Fatalf("wrong code: err = %v, want ErrMfaCodeInvalid", err)
+	}
+
+	// 恢复码只能使用一次
+	if err := s.Verify(AppGuardName, user.ID.ID, recoveryCodes[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Verify(AppGuardName, user.ID.ID, recoveryCodes[0]); !errors.Is(err, ErrMfaCodeInvalid) {
+		t.Fatalf("reused recovery code: err = %v, want ErrMfaCodeInvalid", err)
+	}
+	_, status := s.Status(AppGuardName, user.ID.ID)
+	if !status.Enabled || status.RecoveryCodesLeft != recoveryCodeCount-1 {
+		t.Fatalf("unexpected status %+v", status)
+	}
+}
+
+func TestMfaSeparatedByGuard(t *testing.T) {
+	setupServiceTest(t)
+	user := createTestUser(t, "13800000000")
+	adminUser := createTestAdmin(t, "admin")
+	if user.ID.ID != adminUser.ID.ID {
+		t.Fatalf("test needs the same id, got user %d admin %d", user.ID.ID, adminUser.ID.ID)
+	}
+
+	_, recoveryCodes := enableTestMfa(t, AppGuardName, user.ID.ID)
+	if MfaService.IsEnabled(AdminGuardName, adminUser.ID.ID) {
+		t.Fatal("front-end mfa enabled the admin account with the same id")
+	}
+	if err := MfaService.Verify(AdminGuardName, adminUser.ID.ID, recoveryCodes[0]); err == nil {
+		t.Fatal("front-end recovery code accepted for admin")
+	}
+	// 同 ID 的后台账号可以独立开启
+	enableTestMfa(t, AdminGuardName, adminUser.ID.ID)
+}
+
+func TestMfaLoginChallengeVerify(t *testing.T) {
+	setupServiceTest(t)
+	user := createTestUser(t, "13800000000")
+	s := MfaService
+
+	// 未开启且不要求时无需第二步
+	if err, challenge := s.LoginChallenge(AppGuardName, user.ID.ID); err != nil || challenge != nil {
+		t.Fatalf("challenge = %+v, err = %v, want nil", challenge, err)
+	}
+
+	secret, _ := enableTestMfa(t, AppGuardName, user.ID.ID)
+	err, challenge := s.LoginChallenge(AppGuardName, user.ID.ID)
+	if err != nil || challenge == nil || challenge.SetupRequired {
+		t.Fatalf("challenge = %+v, err = %v, want verify challenge", challenge, err)
+	}
+
+	// 凭证按 guard 区分，也不能用于绑定流程
+	if err, _ = s.VerifyChallenge(AdminGuardName, challenge.MfaToken, totpCode(t, secret, 0)); !errors.Is(err, ErrMfaChallengeInvalid) {
+		t.Fatalf("other guard: err = %v, want ErrMfaChallengeInvalid", err)
+	}
+	if err, _ = s.SetupChallenge(AppGuardName, challenge.MfaToken); !errors.Is(err, ErrMfaChallengeInvalid) {
+		t.Fatalf("setup with verify challenge: err = %v, want ErrMfaChallengeInvalid", err)
+	}
+
+	err, jwtUser := s.VerifyChallenge(AppGuardName, challenge.MfaToken, totpCode(t, secret, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jwtUser.GetUid() != user.GetUid() {
+		t.Fatalf("uid = %s, want %s", jwtUser.GetUid(), user.GetUid())
+	}
+	// 成功后凭证失效
+	if err, _ = s.VerifyChallenge(AppGuardName, challenge.MfaToken, totpCode(t, secret, 1)); !errors.Is(err, ErrMfaChallengeInvalid) {
+		t.Fatalf("used challenge: err = %v, want ErrMfaChallengeInvalid", err)
+	}
+}
+
+func TestMfaLoginChallengeAttempts(t *testing.T) {
+	setupServiceTest(t)
+	user := createTestUser(t, "13800000000")
+	s := MfaService
+	secret, _ := enableTestMfa(t, AppGuardName, user.ID.ID)
+	_, challenge := s.LoginChallenge(AppGuardName, user.ID.ID)
+
+	for i := 0; i < mfaChallengeMaxAttempts; i++ {
+		if err, _ := s.VerifyChallenge(AppGuardName, challenge.MfaToken, "bad-code"); !errors.Is(err, ErrMfaCodeInvalid) {
+			t.Fatalf("attempt %d: err = %v, want ErrMfaCodeInvalid", i+1, err)
+		}
+	}
+	// 超过尝试次数后正确的验证码也不再接受
+	if err, _ := s.VerifyChallenge(AppGuardName, challenge.MfaToken, totpCode(t, secret, 0)); !errors.Is(err, ErrMfaChallengeInvalid) {
+		t.Fatalf("err = %v, want ErrMfaChallengeInvalid", err)
+	}
+}
+
+func TestMfaAdminSetupAtLogin(t *testing.T) {
+	setupServiceTest(t)
+	adminUser := createTestAdmin(t, "admin")
+	s := MfaService
+
+	// 后台账号未开启时，登录必须先完成绑定
+	err, challenge := s.LoginChallenge(AdminGuardName, adminUser.ID.ID)
+	if err != nil || challenge == nil || !challenge.SetupRequired {
+		t.Fatalf("challenge = %+v, err = %v, want setup challenge", challenge, err)
+	}
+	if err, _ = s.VerifyChallenge(AdminGuardName, challenge.MfaToken, "123456"); !errors.Is(err, ErrMfaChallengeInvalid) {
+		t.Fatalf("verify with setup challenge: err = %v, want ErrMfaChallengeInvalid", err)
+	}
+
+	err, setup := s.SetupChallenge(AdminGuardName, challenge.MfaToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err, jwtUser, codes := s.EnableChallenge(AdminGuardName, challenge.MfaToken, totpCode(t, setup.Secret, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jwtUser.GetUid() != adminUser.GetUid() || len(codes.RecoveryCodes) != recoveryCodeCount {
+		t.Fatalf("unexpected enable result %v %+v", jwtUser.GetUid(), codes)
+	}
+	if !s.IsEnabled(AdminGuardName, adminUser.ID.ID) {
+		t.Fatal("admin mfa not enabled")
+	}
+
+	// 后台账号不能关闭两步验证
+	if err = s.Disable(AdminGuardName, adminUser.ID.ID, totpCode(t, setup.Secret, 1)); err == nil {
+		t.Fatal("admin disabled mfa")
+	}
+}
@@ -1,13 +1,20 @@
 package services
 
 import (
+	"errors"
+	"gin-web/app/ampq/event"
 	"gin-web/app/common/request"
 	"gin-web/app/common/response"
 	"gin-web/app/models"
 	"gin-web/global"
 	"math"
+
+	"gorm.io/gorm"
 )
 
+// 单个新版本事件携带的关注者数量上限
+const versionEventMaxFollowers = 500
+
 type modService struct{}
 
 var ModService = &modService{}
@@ -38,6 +45,9 @@ func (s *modService) SearchMods(req request.ModSearchRequest) (*response.ModList
 	if req.Author != "" {
 		db = db.Where("author LIKE ?", "%"+req.Author+"%")
 	}
+	if req.AuthorID > 0 {
+		db = db.Where("user_id = ?", req.AuthorID)
+	}
 
 	// 分类筛选
 	if req.CategoryID > 0 {
@@ -59,6 +69,7 @@ func (s *modService) SearchMods(req request.ModSearchRequest) (*response.ModList
 	validSortFields := map[string]bool{
 		"rating":         true,
 		"download_count": true,
+		"view_count":     true,
 		"created_at":     true,
 		"updated_at":     true,
 	}
@@ -97,25 +108,7 @@ func (s *modService) SearchMods(req request.ModSearchRequest) (*response.ModList
 	// 转换为响应格式
 	modItems := make([]response.ModItem, len(mods))
 	for i, mod := range mods {
-		// 获取分类名称
-		categoryNames := []string{}
-		for _, category := range mod.Categories {
-			categoryNames = append(categoryNames, category.Name)
-		}
-
-		modItems[i] = response.ModItem{
-			ID:            mod.ID,
-			Name:          mod.Name,
-			Author:        mod.Author,
-			Version:       mod.Version,
-			Rating:        mod.Rating,
-			DownloadCount: mod.DownloadCount,
-			FileSize:      mod.FileSize,
-			GameName:      mod.Game.Name,
-			Categories:    categoryNames,
-			CreatedAt:     mod.CreatedAt,
-			UpdatedAt:     mod.UpdatedAt,
-		}
+		modItems[i] = toModItem(mod)
 	}
 
 	// 计算总页数
@@ -130,6 +123,38 @@ func (s *modService) SearchMods(req request.ModSearchRequest) (*response.ModList
 	}, nil
 }
 
+// toModItem 转换为列表项
+func toModItem(mod models.Mod) response.ModItem {
+	// 获取分类名称
+	categoryNames := []string{}
+	for _, category := range mod.Categories {
+		categoryNames = append(categoryNames, category.Name)
+	}
+
+	return response.ModItem{
+		ID:            mod.ID,
+		Name:          mod.Name,
+		Author:        mod.Author,
+		AuthorID:      authorID(mod),
+		Version:       mod.Version,
+		Rating:        mod.Rating,
+		DownloadCount: mod.DownloadCount,
+		FileSize:      mod.FileSize,
+		GameName:      mod.Game.Name,
+		Categories:    categoryNames,
+		CreatedAt:     mod.CreatedAt,
+		UpdatedAt:     mod.UpdatedAt,
+	}
+}
+
+// authorID 发布者用户ID，未关联用户的历史数据返回 0
+func authorID(mod models.Mod) uint {
+	if mod.UserID == nil {
+		return 0
+	}
+	return *mod.UserID
+}
+
 // GetModDetail 获取mod详情
 func (s *modService) GetModDetail(id uint) (*response.ModDetailResponse, error) {
 	var mod models.Mod
@@ -139,9 +164,6 @@ func (s *modService) GetModDetail(id uint) (*response.ModDetailResponse, error)
 		return nil, err
 	}
 
-	// 增加下载次数
-	global.App.DB.Model(&mod).UpdateColumn("download_count", mod.DownloadCount+1)
-
 	// 转换分类为数组格式
 	categories := []models.Category{}
 	for _, category := range mod.Categories {
@@ -153,10 +175,12 @@ func (s *modService) GetModDetail(id uint) (*response.ModDetailResponse, error)
 		Name:          mod.Name,
 		Description:   mod.Description,
 		Author:        mod.Author,
+		AuthorID:      authorID(mod),
 		Version:       mod.Version,
 		DownloadURL:   mod.DownloadURL,
 		Rating:        mod.Rating,
-		DownloadCount: mod.DownloadCount + 1, // 返回更新后的值
+		DownloadCount: mod.DownloadCount + CounterService.Pending(CounterDownload, mod.ID), // 加上尚未回写的增量
+		ViewCount:     mod.ViewCount + CounterService.Pending(CounterView, mod.ID),
 		FileSize:      mod.FileSize,
 		Game:          mod.Game,
 		Categories:    categories,
@@ -165,6 +189,57 @@ func (s *modService) GetModDetail(id uint) (*response.ModDetailResponse, error)
 	}, nil
 }
 
+// PublishRelease 发布者发布mod新版本
+func (s *modService) PublishRelease(userID uint, modID uint, req request.ModReleaseRequest) (*response.ModDetailResponse, error) {
+	var mod models.Mod
+	if err := global.App.DB.First(&mod, modID).Error; err != nil {
+		return nil, errors.New("mod不存在")
+	}
+	if authorID(mod) != userID {
+		return nil, errors.New("只能发布自己的mod")
+	}
+	if mod.Version == req.Version {
+		return nil, errors.New("版本号已存在")
+	}
+
+	// 版本更新与新版本事件在同一事务中写入，事件由 outbox relay 投递
+	err := global.App.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&mod).Updates(map[string]interface{}{
+			"version":      req.Version,
+			"download_url": req.DownloadURL,
+			"file_size":    req.FileSize,
+		}).Error
+		if err != nil {
+			return err
+		}
+		followerIDs, err := FollowService.followerIDs(tx, mod.ID, userID)
+		if err != nil {
+			return err
+		}
+		// 关注者较多时拆分为多个事件，避免单条消息过大
+		for start := 0; start < len(followerIDs); start += versionEventMaxFollowers {
+			end := start + versionEventMaxFollowers
+			if end > len(followerIDs) {
+				end = len(followerIDs)
+			}
+			err = OutboxService.Add(tx, event.QueueNotification, "mod", mod.ID, event.EventModVersionPublished, event.ModVersionPublishedEvent{
+				ModID:       mod.ID,
+				ModName:     mod.Name,
+				Version:     req.Version,
+				FollowerIDs: followerIDs[start:end],
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetModDetail(modID)
+}
+
 // GetGames 获取游戏列表
 func (s *modService) GetGames() (*response.GameListResponse, error) {
 	var games []models.Game
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"gin-web/app/common/request"
+	"gin-web/app/common/response"
+	"gin-web/app/models"
+	"gin-web/global"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type notificationService struct{}
+
+var NotificationService = &notificationService{}
+
+// isNotificationType 是否为已定义的通知类型
+func isNotificationType(notificationType string) bool {
+	for _, t := range models.NotificationTypes {
+		if t == notificationType {
+			return true
+		}
+	}
+	return false
+}
+
+// Notify 给用户发送站内通知，用户关闭了该类型时直接忽略
+// eventID 为来源事件ID，同一事件重复投递时对同一用户只通知一次，为空时不去重
+func (s *notificationService) Notify(eventID string, userID uint, notificationType string, title string, content string, data interface{}) error {
+	if !isNotificationType(notificationType) {
+		return errors.New("unknown notification type: " + notificationType)
+	}
+	if !s.enabled(userID, notificationType) {
+		return nil
+	}
+
+	payload := ""
+	if data != nil {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		payload = string(raw)
+	}
+
+	notification := models.Notification{
+		UserID:  userID,
+		Type:    notificationType,
+		Title:   title,
+		Content: content,
+		Data:    payload,
+	}
+	if eventID != "" {
+		notification.EventID = &eventID
+	}
+	return global.App.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&notification).Error
+}
+
+// enabled 用户是否接收该类型通知，未设置时默认接收
+func (s *notificationService) enabled(userID uint, notificationType string) bool {
+	var preference models.NotificationPreference
+	err := global.App.DB.Where("user_id = ? AND type = ?", userID, notificationType).First(&preference).Error
+	if err != nil {
+		return true
+	}
+	return preference.Enabled
+}
+
+// List 通知列表，未读在前
+func (s *notificationService) List(userID uint, req request.NotificationList) (*response.NotificationListResponse, error) {
+	page := req.Page
+	if page < 1 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize < 1 {
+		pageSize = 20
+	}
+
+	db := global.App.DB.Model(&models.Notification{}).Where("user_id = ?", userID)
+	if req.UnreadOnly {
+		db = db.Where("read_at IS NULL")
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	notifications := []models.Notification{}
+	if err := db.Order("read_at IS NOT NULL, id desc").
+		Offset((page - 1) * pageSize).Limit(pageSize).
+		Find(&notifications).Error; err != nil {
+		return nil, err
+	}
+
+	return &response.NotificationListResponse{
+		List:     notifications,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+// UnreadCount 未读通知数
+func (s *notificationService) UnreadCount(userID uint) (*response.UnreadCountResponse, error) {
+	var count int64
+	if err := global.App.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error; err != nil {
+		return nil, err
+	}
+	return &response.UnreadCountResponse{Count: count}, nil
+}
+
+// MarkRead 标记单条通知已读
+func (s *notificationService) MarkRead(userID uint, id uint) error {
+	var notification models.Notification
+	if err := global.App.DB.Where("id = ? AND user_id = ?", id, userID).First(&notification).Error; err != nil {
+		return errors.New("通知不存在")
+	}
+	if notification.ReadAt != nil {
+		return nil
+	}
+	return global.App.DB.Model(&notification).UpdateColumn("read_at", time.Now()).Error
+}
+
+// MarkAllRead 全部标记已读
+func (s *notificationService) MarkAllRead(userID uint) error {
+	return global.App.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		UpdateColumn("read_at", time.Now()).Error
+}
+
+// GetPreferences 获取全部类型的通知偏好
+func (s *notificationService) GetPreferences(userID uint) ([]response.NotificationPreference, error) {
+	var preferences []models.NotificationPreference
+	if err := global.App.DB.Where("user_id = ?", userID).Find(&preferences).Error; err != nil {
+		return nil, err
+	}
+	enabled := make(map[string]bool, len(preferences))
+	for _, preference := range preferences {
+		enabled[preference.Type] = preference.Enabled
+	}
+
+	result := make([]response.NotificationPreference, len(models.NotificationTypes))
+	for i, t := range models.NotificationTypes {
+		e, ok := enabled[t]
+		result[i] = response.NotificationPreference{Type: t, Enabled: !ok || e}
+	}
+	return result, nil
+}
+
+// UpdatePreferences 更新通知偏好
+func (s *notificationService) UpdatePreferences(userID uint, preferences map[string]bool) ([]response.NotificationPreference, error) {
+	for t := range preferences {
+		if !isNotificationType(t) {
+			return nil, errors.New("不支持的通知类型: " + t)
+		}
+	}
+
+	err := global.App.DB.Transaction(func(tx *gorm.DB) error {
+		for t, enabled := range preferences {
+			err := tx.Clauses(clause.OnConflict{
+				DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+			}).Create(&models.NotificationPreference{
+				UserID:  userID,
+				Type:    t,
+				Enabled: enabled,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPreferences(userID)
+}
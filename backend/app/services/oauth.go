@@ -0,0 +1,337 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"gin-web/app/common/response"
+	"gin-web/app/models"
+	"gin-web/global"
+	"gin-web/utils"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+const defaultOauthStateTtl = 600
+
+type oauthService struct {
+	mu        sync.Mutex
+	providers map[string]*oidcProvider // 已完成发现的 provider，按名称缓存
+}
+
+var OauthService = &oauthService{providers: map[string]*oidcProvider{}}
+
+type oidcProvider struct {
+	config   oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// oauthState 授权请求上下文，保存在 Redis 中，回调时一次性取出
+type oauthState struct {
+	Provider     string `json:"provider"`
+	CodeVerifier string `json:"code_verifier"`
+	Nonce        string `json:"nonce"`
+	BindingHash  string `json:"binding_hash"` // 发起授权的浏览器 cookie 哈希，回调时比对，防止登录 CSRF
+	LinkUserID   uint   `json:"link_user_id"` // 已登录用户发起授权时绑定到该用户
+}
+
+// oidcClaims id token 中使用到的字段
+type oidcClaims struct {
+	Subject           string `json:"sub"`
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+	Picture           string `json:"picture"`
+}
+
+var (
+	ErrOauthProviderNotFound = errors.New("不支持的登录方式")
+	ErrOauthStateInvalid     = errors.New("授权已过期，请重新登录")
+)
+
+// Providers 已配置的第三方登录方式
+func (s *oauthService) Providers() []response.OauthProvider {
+	providers := make([]response.OauthProvider, 0, len(global.App.Config.Oauth.Providers))
+	for name, provider := range global.App.Config.Oauth.Providers {
+		displayName := provider.DisplayName
+		if displayName == "" {
+			displayName = name
+		}
+		providers = append(providers, response.OauthProvider{Name: name, DisplayName: displayName})
+	}
+	sort.Slice(providers, func(i, j int) bool { return providers[i].Name < providers[j].Name })
+	return providers
+}
+
+// provider 获取 provider，首次使用时通过 issuer 发现端点和公钥
+// 发现过程不持有锁，某个 issuer 响应慢不会阻塞其他 provider
+func (s *oauthService) provider(ctx context.Context, name string) (*oidcProvider, error) {
+	s.mu.Lock()
+	p, ok := s.providers[name]
+	s.mu.Unlock()
+	if ok {
+		return p, nil
+	}
+
+	conf, ok := global.App.Config.Oauth.Providers[name]
+	if !ok {
+		return nil, ErrOauthProviderNotFound
+	}
+	// 发现结果在进程内复用，go-oidc 只保留 ctx 中的 http client，不受请求结束影响
+	discovered, err := oidc.NewProvider(ctx, conf.Issuer)
+	if err != nil {
+		global.App.Log.Error("oidc discovery failed", zap.String("provider", name), zap.Error(err))
+		return nil, errors.New("登录服务暂不可用")
+	}
+
+	scopes := conf.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{oidc.ScopeOpenID, "profile", "email"}
+	}
+	p = &oidcProvider{
+		config: oauth2.Config{
+			ClientID:     conf.ClientID,
+			ClientSecret: conf.ClientSecret,
+			Endpoint:     discovered.Endpoint(),
+			RedirectURL:  conf.RedirectURL,
+			Scopes:       scopes,
+		},
+		verifier: discovered.Verifier(&oidc.Config{ClientID: conf.ClientID}),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// 并发发现时保留先完成的结果
+	if existing, ok := s.providers[name]; ok {
+		return existing, nil
+	}
+	s.providers[name] = p
+	return p, nil
+}
+
+func (s *oauthService) stateTtl() time.Duration {
+	ttl := global.App.Config.Oauth.StateTtl
+	if ttl <= 0 {
+		ttl = defaultOauthStateTtl
+	}
+	return time.Duration(ttl) * time.Second
+}
+
+// 获取授权 state 缓存 key
+func (s *oauthService) getStateKey(state string) string {
+	return "oauth_state:" + state
+}
+
+// AuthorizeURL 生成授权地址（授权码模式 + PKCE），linkUserID 不为 0 时回调后将身份绑定到该用户
+// result.Binding 需由调用方写入发起授权的浏览器 cookie，回调时原样带回
+func (s *oauthService) AuthorizeURL(ctx context.Context, name string, linkUserID uint) (err error, result response.OauthAuthorize) {
+	p, err := s.provider(ctx, name)
+	if err != nil {
+		return
+	}
+
+	state := utils.RandToken(24)
+	binding := utils.RandToken(24)
+	data := oauthState{
+		Provider:     name,
+		CodeVerifier: oauth2.GenerateVerifier(),
+		Nonce:        utils.RandToken(16),
+		BindingHash:  utils.Sha256([]byte(binding)),
+		LinkUserID:   linkUserID,
+	}
+	raw, _ := json.Marshal(data)
+	if err = global.App.Redis.Set(ctx, s.getStateKey(state), raw, s.stateTtl()).Err(); err != nil {
+		return
+	}
+
+	result = response.OauthAuthorize{
+		Url:     p.config.AuthCodeURL(state, oauth2.S256ChallengeOption(data.CodeVerifier), oidc.Nonce(data.Nonce)),
+		State:   state,
+		Binding: binding,
+	}
+	return
+}
+
+// consumeState 取出并删除 state，保证只能使用一次
+func (s *oauthService) consumeState(ctx context.Context, state string) (data oauthState, err error) {
+	key := s.getStateKey(state)
+	pipe := global.App.Redis.TxPipeline()
+	get := pipe.Get(ctx, key)
+	pipe.Del(ctx, key)
+	if _, err = pipe.Exec(ctx); err != nil {
+		return data, ErrOauthStateInvalid
+	}
+	if err = json.Unmarshal([]byte(get.Val()), &data); err != nil {
+		return data, ErrOauthStateInvalid
+	}
+	return
+}
+
+// StateTtl 授权 state 有效期，用于设置浏览器绑定 cookie 的过期时间
+func (s *oauthService) StateTtl() time.Duration {
+	return s.stateTtl()
+}
+
+// Callback 用授权码换取 id token，返回绑定的用户，首次登录时自动注册
+// binding 为发起授权时写入浏览器的 cookie，currentUserID 为回调请求携带 token 对应的用户
+func (s *oauthService) Callback(ctx context.Context, name string, code string, state string, binding string, currentUserID uint) (err error, user models.User) {
+	claims, linkUserID, err := s.exchange(ctx, name, code, state, binding)
+	if err != nil {
+		return
+	}
+	// 绑定流程只能由发起绑定的用户完成
+	if linkUserID != 0 && linkUserID != currentUserID {
+		err = ErrOauthStateInvalid
+		return
+	}
+	return s.bindIdentity(name, claims, linkUserID)
+}
+
+// exchange 校验 state 与浏览器绑定，用授权码换取并校验 id token
+func (s *oauthService) exchange(ctx context.Context, name string, code string, state string, binding string) (claims oidcClaims, linkUserID uint, err error) {
+	data, err := s.consumeState(ctx, state)
+	if err != nil {
+		return
+	}
+	if data.Provider != name || binding == "" ||
+		subtle.ConstantTimeCompare([]byte(data.BindingHash), []byte(utils.Sha256([]byte(binding)))) != 1 {
+		err = ErrOauthStateInvalid
+		return
+	}
+	p, err := s.provider(ctx, name)
+	if err != nil {
+		return
+	}
+
+	token, err := p.config.Exchange(ctx, code, oauth2.VerifierOption(data.CodeVerifier))
+	if err != nil {
+		global.App.Log.Warn("oauth code exchange failed", zap.String("provider", name), zap.Error(err))
+		err = errors.New("授权失败，请重新登录")
+		return
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		err = errors.New("授权失败，未返回 id_token")
+		return
+	}
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil || idToken.Nonce != data.Nonce {
+		global.App.Log.Warn("oidc id token invalid", zap.String("provider", name), zap.Error(err))
+		err = errors.New("授权失败，身份校验不通过")
+		return
+	}
+	if err = idToken.Claims(&claims); err != nil || claims.Subject == "" {
+		err = errors.New("授权失败，身份信息不完整")
+		return
+	}
+	linkUserID = data.LinkUserID
+	return
+}
+
+// bindIdentity 查找或创建身份绑定
+func (s *oauthService) bindIdentity(provider string, claims oidcClaims, linkUserID uint) (err error, user models.User) {
+	var identity models.UserIdentity
+	result := global.App.DB.Where("provider = ? AND subject = ?", provider, claims.Subject).Limit(1).Find(&identity)
+	if result.Error != nil {
+		err = result.Error
+		return
+	}
+
+	if result.RowsAffected > 0 {
+		if linkUserID != 0 && identity.UserID != linkUserID {
+			err = errors.New("该账号已绑定其他用户")
+			return
+		}
+		global.App.DB.Model(&identity).Updates(map[string]interface{}{"email": claims.Email, "name": claims.Name})
+		// 已登录用户通过已绑定的身份重新授权，视为重新验证身份，可用于没有密码的账号确认敏感操作
+		if linkUserID != 0 {
+			AccountService.markReauth(linkUserID)
+		}
+		return UserService.GetUserInfo(models.User{ID: models.ID{ID: identity.UserID}}.GetUid())
+	}
+
+	err = global.App.DB.Transaction(func(tx *gorm.DB) error {
+		if linkUserID != 0 {
+			if err := tx.First(&user, linkUserID).Error; err != nil {
+				return errors.New("用户不存在")
+			}
+		} else {
+			user = models.User{Name: claims.displayName(provider), Avatar: claims.Picture}
+			// 提供方已验证的邮箱且未被占用时直接使用
+			if email := strings.ToLower(claims.Email); claims.EmailVerified && email != "" {
+				var count int64
+				tx.Model(&models.User{}).Where("email = ?", email).Count(&count)
+				if count == 0 {
+					now := time.Now()
+					user.Email = &email
+					user.EmailVerifiedAt = &now
+				}
+			}
+			if err := tx.Create(&user).Error; err != nil {
+				return err
+			}
+			// 新用户默认为普通用户角色
+			if err := RbacService.AddRoleTx(tx, user.ID.ID, models.RoleUser); err != nil {
+				return err
+			}
+		}
+		return tx.Create(&models.UserIdentity{
+			UserID:   user.ID.ID,
+			Provider: provider,
+			Subject:  claims.Subject,
+			Email:    claims.Email,
+			Name:     claims.Name,
+		}).Error
+	})
+	return
+}
+
+// displayName 新用户的默认名称
+func (claims oidcClaims) displayName(provider string) string {
+	switch {
+	case claims.Name != "":
+		return claims.Name
+	case claims.PreferredUsername != "":
+		return claims.PreferredUsername
+	default:
+		return provider + "用户"
+	}
+}
+
+// Identities 用户已绑定的第三方身份
+func (s *oauthService) Identities(userID uint) (err error, identities []models.UserIdentity) {
+	identities = []models.UserIdentity{}
+	err = global.App.DB.Where("user_id = ?", userID).Order("id").Find(&identities).Error
+	return
+}
+
+// Unlink 解除第三方身份绑定，没有密码且只剩一个身份时不允许解绑，避免账号无法登录
+func (s *oauthService) Unlink(userID uint, provider string) error {
+	var user models.User
+	if err := global.App.DB.First(&user, userID).Error; err != nil {
+		return errors.New("用户不存在")
+	}
+	var count int64
+	global.App.DB.Model(&models.UserIdentity{}).Where("user_id = ?", userID).Count(&count)
+	if user.Password == "" && count <= 1 {
+		return errors.New("请先设置密码后再解绑")
+	}
+
+	result := global.App.DB.Where("user_id = ? AND provider = ?", userID, provider).Delete(&models.UserIdentity{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("未绑定该账号")
+	}
+	return nil
+}
@@ -0,0 +1,258 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"gin-web/config"
+	"gin-web/global"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	mockClientID = "gin-web"
+	mockKeyID    = "mock-key"
+)
+
+// mockOidcProvider 本地 mock OIDC 身份提供方，校验 PKCE 并签发 id token
+type mockOidcProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]mockAuthorization
+}
+
+// mockAuthorization 用户在提供方完成授权后生成的授权码上下文
+type mockAuthorization struct {
+	challenge string
+	nonce     string
+	subject   string
+}
+
+func newMockOidcProvider(t *testing.T) *mockOidcProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockOidcProvider{key: key, codes: map[string]mockAuthorization{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{
+			"issuer":                                m.server.URL,
+			"authorization_endpoint":                m.server.URL + "/authorize",
+			"token_endpoint":                        m.server.URL + "/token",
+			"jwks_uri":                              m.server.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": mockKeyID,
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", m.token)
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+// authorize 模拟用户在提供方同意授权，返回回调携带的 code
+func (m *mockOidcProvider) authorize(t *testing.T, authURL string, subject string) string {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := u.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		t.Fatalf("authorize url without PKCE: %s", authURL)
+	}
+	if query.Get("client_id") != mockClientID {
+		t.Fatalf("unexpected client_id %q", query.Get("client_id"))
+	}
+
+	code := "code-" + subject + "-" + query.Get("state")
+	m.mu.Lock()
+	m.codes[code] = mockAuthorization{
+		challenge: query.Get("code_challenge"),
+		nonce:     query.Get("nonce"),
+		subject:   subject,
+	}
+	m.mu.Unlock()
+	return code
+}
+
+func (m *mockOidcProvider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	m.mu.Lock()
+	auth, ok := m.codes[r.PostForm.Get("code")]
+	delete(m.codes, r.PostForm.Get("code"))
+	m.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != auth.challenge {
+		w.WriteHeader(http.StatusBadRequest)
+		writeJSON(w, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            m.server.URL,
+		"aud":            mockClientID,
+		"sub":            auth.subject,
+		"nonce":          auth.nonce,
+		"email":          auth.subject + "@example.com",
+		"email_verified": true,
+		"name":           "Mock " + auth.subject,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+	})
+	idToken.Header["kid"] = mockKeyID
+	signed, err := idToken.SignedString(m.key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"access_token": "access-" + auth.subject,
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     signed,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// setupOauthTest 使用 mock 提供方和 miniredis 初始化全局配置，返回独立的 oauthService
+func setupOauthTest(t *testing.T) (*oauthService, *mockOidcProvider) {
+	mock := newMockOidcProvider(t)
+	mr := miniredis.RunT(t)
+
+	previous := *global.App
+	t.Cleanup(func() { *global.App = previous })
+	global.App.Log = zap.NewNop()
+	global.App.Redis = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	global.App.Config.Oauth = config.Oauth{
+		StateTtl: 60,
+		Providers: map[string]config.OauthProvider{
+			"mock": {
+				Issuer:       mock.server.URL,
+				ClientID:     mockClientID,
+				ClientSecret: "secret",
+				RedirectURL:  "http://localhost:3000/oauth/mock/callback",
+			},
+		},
+	}
+	return &oauthService{providers: map[string]*oidcProvider{}}, mock
+}
+
+func TestOauthExchangeWithMockProvider(t *testing.T) {
+	s, mock := setupOauthTest(t)
+	ctx := context.Background()
+
+	err, authorize := s.AuthorizeURL(ctx, "mock", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := mock.authorize(t, authorize.Url, "alice")
+
+	claims, linkUserID, err := s.exchange(ctx, "mock", code, authorize.State, authorize.Binding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "alice" || claims.Email != "alice@example.com" || !claims.EmailVerified {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+	if linkUserID != 0 {
+		t.Fatalf("linkUserID = %d, want 0", linkUserID)
+	}
+
+	// state 只能使用一次
+	if _, _, err = s.exchange(ctx, "mock", code, authorize.State, authorize.Binding); !errors.Is(err, ErrOauthStateInvalid) {
+		t.Fatalf("reused state: err = %v, want ErrOauthStateInvalid", err)
+	}
+}
+
+func TestOauthExchangeRejectsOtherBrowser(t *testing.T) {
+	s, mock := setupOauthTest(t)
+	ctx := context.Background()
+
+	// 攻击者发起授权，把自己的 code、state 交给受害者的浏览器提交
+	err, authorize := s.AuthorizeURL(ctx, "mock", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := mock.authorize(t, authorize.Url, "attacker")
+
+	for _, binding := range []string{"", "victim-cookie"} {
+		if _, _, err = s.exchange(ctx, "mock", code, authorize.State, binding); !errors.Is(err, ErrOauthStateInvalid) {
+			t.Fatalf("binding %q: err = %v, want ErrOauthStateInvalid", binding, err)
+		}
+	}
+}
+
+func TestOauthExchangeRejectsWrongProvider(t *testing.T) {
+	s, mock := setupOauthTest(t)
+	ctx := context.Background()
+
+	err, authorize := s.AuthorizeURL(ctx, "mock", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := mock.authorize(t, authorize.Url, "alice")
+
+	if _, _, err = s.exchange(ctx, "other", code, authorize.State, authorize.Binding); !errors.Is(err, ErrOauthStateInvalid) {
+		t.Fatalf("err = %v, want ErrOauthStateInvalid", err)
+	}
+}
+
+func TestOauthCallbackLinkRequiresInitiatingUser(t *testing.T) {
+	s, mock := setupOauthTest(t)
+	ctx := context.Background()
+
+	err, authorize := s.AuthorizeURL(ctx, "mock", 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := mock.authorize(t, authorize.Url, "alice")
+
+	if err, _ = s.Callback(ctx, "mock", code, authorize.State, authorize.Binding, 7); !errors.Is(err, ErrOauthStateInvalid) {
+		t.Fatalf("err = %v, want ErrOauthStateInvalid", err)
+	}
+}
+
+func TestOauthUnknownProvider(t *testing.T) {
+	s, _ := setupOauthTest(t)
+
+	if err, _ := s.AuthorizeURL(context.Background(), "missing", 0); !errors.Is(err, ErrOauthProviderNotFound) {
+		t.Fatalf("err = %v, want ErrOauthProviderNotFound", err)
+	}
+}
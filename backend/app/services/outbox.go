@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"gin-web/app/ampq/broker"
+	"gin-web/app/ampq/event"
+	"gin-web/app/models"
+	"gin-web/global"
+	"gin-web/utils"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultOutboxRelayInterval = 2
+	defaultOutboxRetention     = 7 * 24 * 3600
+	// 每批读取的待投递消息数
+	outboxRelayBatchSize = 100
+	// relay 锁的有效期（秒）
+	outboxRelayLockSeconds = 60
+	// 锁到期前预留的时间：发布最晚在此之前超时，留给记录投递结果，避免锁过期后其他实例并发投递打乱顺序
+	outboxRelayLockMargin = 10 * time.Second
+	// 每批清理的消息数
+	outboxCleanupBatchSize = 1000
+	outboxLastErrorMaxLen  = 500
+)
+
+type outboxService struct{}
+
+var OutboxService = new(outboxService)
+
+// Add 在业务事务中写入待发布的领域事件，事务提交后由 Relay 投递
+// 同一聚合（aggregateType + aggregateID）的事件按写入顺序投递
+func (s *outboxService) Add(tx *gorm.DB, queue string, aggregateType string, aggregateID uint, name string, data interface{}) error {
+	body, err := event.New(name, data)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&models.OutboxMessage{
+		MessageID:     utils.RandToken(16),
+		AggregateType: aggregateType,
+		AggregateID:   strconv.FormatUint(uint64(aggregateID), 10),
+		Queue:         queue,
+		Event:         name,
+		SchemaVersion: event.Version,
+		Body:          string(body),
+	}).Error
+}
+
+func (s *outboxService) RelayInterval() time.Duration {
+	interval := global.App.Config.Outbox.RelayInterval
+	if interval <= 0 {
+		interval = defaultOutboxRelayInterval
+	}
+	return time.Duration(interval) * time.Second
+}
+
+func (s *outboxService) retention() time.Duration {
+	retention := global.App.Config.Outbox.Retention
+	if retention <= 0 {
+		retention = defaultOutboxRetention
+	}
+	return time.Duration(retention) * time.Second
+}
+
+// Relay 按写入顺序投递未发送的消息；多实例部署时通过分布式锁保证只有一个实例投递
+// 某条消息投递失败时，同一聚合的后续消息本轮不再投递，下一轮从失败的消息重试，其他聚合不受影响
+// 投递成功但标记已发送前进程退出时消息会重复投递，消费方按消息ID去重
+// 每次发布的超时不超过锁的剩余有效期，锁即将到期时停止
+func (s *outboxService) Relay() {
+	// 在加锁前取时间，锁的实际到期时间不会早于此
+	lockExpiresAt := time.Now().Add(outboxRelayLockSeconds * time.Second)
+	lock := global.Lock("outbox_relay_lock", outboxRelayLockSeconds)
+	if !lock.Get() {
+		return
+	}
+	defer lock.Release()
+
+	ctx, cancel := context.WithDeadline(context.Background(), lockExpiresAt.Add(-outboxRelayLockMargin))
+	defer cancel()
+	var lastID uint
+	blocked := map[string]bool{}
+	for ctx.Err() == nil {
+		var messages []models.OutboxMessage
+		if err := global.App.DB.Where("sent_at IS NULL AND id > ?", lastID).
+			Order("id").Limit(outboxRelayBatchSize).Find(&messages).Error; err != nil {
+			global.App.Log.Error("query outbox messages failed", zap.Error(err))
+			return
+		}
+		for _, message := range messages {
+			lastID = message.ID.ID
+			aggregate := message.AggregateType + ":" + message.AggregateID
+			if blocked[aggregate] {
+				continue
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			if err := s.send(ctx, message); err != nil {
+				blocked[aggregate] = true
+				global.App.Log.Error("relay outbox message failed",
+					zap.Uint("id", message.ID.ID), zap.String("queue", message.Queue), zap.String("aggregate", aggregate), zap.Error(err))
+			}
+		}
+		if len(messages) < outboxRelayBatchSize {
+			return
+		}
+	}
+}
+
+// send 投递单条消息并记录结果
+func (s *outboxService) send(ctx context.Context, message models.OutboxMessage) error {
+	err := global.App.Broker.Publish(ctx, message.Queue, broker.Message{
+		ID:          message.MessageID,
+		ContentType: "application/json",
+		Headers:     map[string]interface{}{broker.HeaderSchemaVersion: message.SchemaVersion},
+		Body:        []byte(message.Body),
+		Timestamp:   message.CreatedAt,
+	})
+	if err != nil {
+		lastError := err.Error()
+		if len(lastError) > outboxLastErrorMaxLen {
+			lastError = lastError[:outboxLastErrorMaxLen]
+		}
+		global.App.DB.Model(&message).UpdateColumns(map[string]interface{}{
+			"attempts":   gorm.Expr("attempts + 1"),
+			"last_error": lastError,
+		})
+		return err
+	}
+	// 标记失败不影响已完成的投递，下一轮会重复投递该消息，由消费方去重
+	return global.App.DB.Model(&message).UpdateColumn("sent_at", time.Now()).Error
+}
+
+// Cleanup 清理超过保留时长的已投递消息
+func (s *outboxService) Cleanup() {
+	lock := global.Lock("outbox_cleanup_lock", 300)
+	if !lock.Get() {
+		return
+	}
+	defer lock.Release()
+
+	before := time.Now().Add(-s.retention())
+	for {
+		var ids []uint
+		if err := global.App.DB.Model(&models.OutboxMessage{}).Where("sent_at < ?", before).
+			Order("id").Limit(outboxCleanupBatchSize).Pluck("id", &ids).Error; err != nil {
+			global.App.Log.Error("query outbox messages to clean failed", zap.Error(err))
+			return
+		}
+		if len(ids) == 0 {
+			return
+		}
+		if err := global.App.DB.Delete(&models.OutboxMessage{}, ids).Error; err != nil {
+			global.App.Log.Error("clean outbox messages failed", zap.Error(err))
+			return
+		}
+		if len(ids) < outboxCleanupBatchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"gin-web/app/ampq/broker"
+	"gin-web/app/models"
+	"gin-web/global"
+	"testing"
+	"time"
+)
+
+// recordingBroker 记录发布时的 ctx 截止时间，可按消息ID模拟发布失败
+type recordingBroker struct {
+	broker.Broker
+	deadlines []time.Time
+	published []string
+	fail      map[string]bool
+}
+
+func (b *recordingBroker) Publish(ctx context.Context, queue string, msg broker.Message) error {
+	deadline, _ := ctx.Deadline()
+	b.deadlines = append(b.deadlines, deadline)
+	if b.fail[msg.ID] {
+		return errors.New("publish failed")
+	}
+	b.published = append(b.published, msg.ID)
+	return nil
+}
+
+func setupOutboxTest(t *testing.T) *recordingBroker {
+	setupServiceTest(t)
+	b := &recordingBroker{Broker: broker.NewMemoryBroker(), fail: map[string]bool{}}
+	global.App.Broker = b
+	return b
+}
+
+func addOutboxMessage(t *testing.T, aggregateID uint) string {
+	t.Helper()
+	if err := OutboxService.Add(global.App.DB, "test", "mod", aggregateID, "test", nil); err != nil {
+		t.Fatal(err)
+	}
+	var message models.OutboxMessage
+	global.App.DB.Order("id desc").First(&message)
+	return message.MessageID
+}
+
+func TestOutboxRelayPublishDeadline(t *testing.T) {
+	b := setupOutboxTest(t)
+	addOutboxMessage(t, 1)
+
+	start := time.Now()
+	OutboxService.Relay()
+	end := time.Now()
+	if len(b.deadlines) != 1 || b.deadlines[0].IsZero() {
+		t.Fatalf("publish without deadline: %v", b.deadlines)
+	}
+	// 发布的截止时间在锁到期前，并预留记录结果的时间
+	d := outboxRelayLockSeconds*time.Second - outboxRelayLockMargin
+	if b.deadlines[0].Before(start.Add(d)) || b.deadlines[0].After(end.Add(d)) {
+		t.Fatalf("deadline = %v, want between %v and %v", b.deadlines[0], start.Add(d), end.Add(d))
+	}
+}
+
+func TestOutboxRelayStopsWhenLockHeld(t *testing.T) {
+	b := setupOutboxTest(t)
+	addOutboxMessage(t, 1)
+
+	lock := global.Lock("outbox_relay_lock", outboxRelayLockSeconds)
+	lock.Get()
+	OutboxService.Relay()
+	if len(b.published) != 0 {
+		t.Fatal("relayed while another instance holds the lock")
+	}
+	lock.Release()
+	OutboxService.Relay()
+	if len(b.published) != 1 {
+		t.Fatalf("published %v, want 1 message", b.published)
+	}
+}
+
+func TestOutboxRelayBlocksFailedAggregate(t *testing.T) {
+	b := setupOutboxTest(t)
+	first := addOutboxMessage(t, 1)
+	second := addOutboxMessage(t, 1)
+	other := addOutboxMessage(t, 2)
+	b.fail[first] = true
+
+	// 同一聚合中失败消息之后的消息本轮不投递，其他聚合不受影响
+	OutboxService.Relay()
+	if len(b.published) != 1 || b.published[0] != other {
+		t.Fatalf("published %v, want only %s", b.published, other)
+	}
+	var failed models.OutboxMessage
+	global.App.DB.Where("message_id = ?", first).First(&failed)
+	if failed.Attempts != 1 || failed.SentAt != nil {
+		t.Fatalf("unexpected failed message %+v", failed)
+	}
+
+	delete(b.fail, first)
+	OutboxService.Relay()
+	if len(b.published) != 3 || b.published[1] != first || b.published[2] != second {
+		t.Fatalf("published %v, want %s then %s", b.published, first, second)
+	}
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"gin-web/global"
+	"gin-web/utils"
+	"sync"
+)
+
+type passwordService struct {
+	dummyOnce sync.Once
+	dummyHash string
+}
+
+var PasswordService = new(passwordService)
+
+// Hash 按当前配置计算密码哈希
+func (s *passwordService) Hash(password string) (string, error) {
+	return utils.PasswordHash(password, global.App.Config.Password)
+}
+
+// Verify 校验密码
+func (s *passwordService) Verify(password string, hash string) bool {
+	if hash == "" {
+		return false
+	}
+	return utils.PasswordVerify(password, hash)
+}
+
+// NeedsRehash 哈希是否需要按当前配置重新计算
+func (s *passwordService) NeedsRehash(hash string) bool {
+	return utils.PasswordNeedsRehash(hash, global.App.Config.Password)
+}
+
+// CheckPolicy 校验密码强度
+func (s *passwordService) CheckPolicy(password string) error {
+	return utils.CheckPasswordPolicy(password, global.App.Config.Password)
+}
+
+// VerifyDummy 账号不存在时做一次同等开销的哈希比对，避免通过响应时间判断账号是否存在
+func (s *passwordService) VerifyDummy(password string) {
+	s.dummyOnce.Do(func() {
+		s.dummyHash, _ = s.Hash("dummy-password")
+	})
+	s.Verify(password, s.dummyHash)
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"gin-web/app/common/request"
+	"gin-web/app/common/response"
+	"gin-web/app/models"
+	"gin-web/global"
+	"gin-web/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PersonalAccessTokenPrefix 个人访问令牌前缀，用于和 JWT 区分，也便于密钥扫描工具识别
+const PersonalAccessTokenPrefix = "gwp_"
+
+// 最后使用时间的最小更新间隔，避免每个请求都写库
+const tokenLastUsedInterval = time.Minute
+
+// 每个用户最多持有的令牌数
+const maxPersonalAccessTokens = 50
+
+type personalAccessTokenService struct{}
+
+var PersonalAccessTokenService = new(personalAccessTokenService)
+
+var ErrPersonalAccessTokenInvalid = errors.New("访问令牌无效或已过期")
+
+// IsPersonalAccessToken 是否为个人访问令牌
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, PersonalAccessTokenPrefix)
+}
+
+func toPersonalAccessTokenResponse(token models.PersonalAccessToken) response.PersonalAccessToken {
+	return response.PersonalAccessToken{
+		ID:         token.ID.ID,
+		Name:       token.Name,
+		Hint:       token.Hint,
+		Scopes:     token.ScopeList(),
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+		LastUsedIp: token.LastUsedIp,
+		CreatedAt:  token.CreatedAt,
+	}
+}
+
+// Create 创建令牌，明文只在此时返回
+func (s *personalAccessTokenService) Create(userID uint, params request.CreatePersonalAccessToken) (err error, result response.CreatedPersonalAccessToken) {
+	scopes := make([]string, 0, len(params.Scopes))
+	seen := map[string]bool{}
+	for _, scope := range params.Scopes {
+		if !models.IsTokenScope(scope) {
+			err = errors.New("不支持的权限范围: " + scope)
+			return
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	if seen[models.TokenScopeAdmin] && !s.canGrantAdmin(userID) {
+		err = errors.New("没有审核权限，不能创建 admin 范围的令牌")
+		return
+	}
+
+	var count int64
+	global.App.DB.Model(&models.PersonalAccessToken{}).Where("user_id = ?", userID).Count(&count)
+	if count >= maxPersonalAccessTokens {
+		err = errors.New("令牌数量已达上限，请先删除不再使用的令牌")
+		return
+	}
+
+	plain := PersonalAccessTokenPrefix + utils.RandToken(32)
+	token := models.PersonalAccessToken{
+		UserID:    userID,
+		Name:      params.Name,
+		TokenHash: utils.Sha256([]byte(plain)),
+		Hint:      plain[len(plain)-4:],
+		Scopes:    strings.Join(scopes, ","),
+	}
+	if params.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, params.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+	if err = global.App.DB.Create(&token).Error; err != nil {
+		return
+	}
+
+	result = response.CreatedPersonalAccessToken{
+		PersonalAccessToken: toPersonalAccessTokenResponse(token),
+		Token:               plain,
+	}
+	return
+}
+
+// canGrantAdmin 用户是否拥有审核权限，使用时仍由 RequirePermission 按当前权限校验
+func (s *personalAccessTokenService) canGrantAdmin(userID uint) bool {
+	err, access := RbacService.GetUserAccess(userID)
+	if err != nil {
+		return false
+	}
+	for _, permission := range models.TokenScopeAdminPermissions {
+		if access.HasPermissions(permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// List 用户的令牌列表
+func (s *personalAccessTokenService) List(userID uint) (err error, result []response.PersonalAccessToken) {
+	var tokens []models.PersonalAccessToken
+	if err = global.App.DB.Where("user_id = ?", userID).Order("id desc").Find(&tokens).Error; err != nil {
+		return
+	}
+	result = make([]response.PersonalAccessToken, len(tokens))
+	for i, token := range tokens {
+		result[i] = toPersonalAccessTokenResponse(token)
+	}
+	return
+}
+
+// Revoke 吊销令牌
+func (s *personalAccessTokenService) Revoke(userID uint, id uint) error {
+	result := global.App.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&models.PersonalAccessToken{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("令牌不存在")
+	}
+	return nil
+}
+
+// Authenticate 校验令牌并记录使用情况
+func (s *personalAccessTokenService) Authenticate(plain string, ip string) (err error, token models.PersonalAccessToken) {
+	result := global.App.DB.Where("token_hash = ?", utils.Sha256([]byte(plain))).Limit(1).Find(&token)
+	if result.Error != nil || result.RowsAffected == 0 {
+		err = ErrPersonalAccessTokenInvalid
+		return
+	}
+	now := time.Now()
+	if token.ExpiresAt != nil && token.ExpiresAt.Before(now) {
+		err = ErrPersonalAccessTokenInvalid
+		return
+	}
+
+	// 按最小间隔更新最后使用时间
+	throttleKey := "pat_last_used:" + strconv.Itoa(int(token.ID.ID))
+	if ok, _ := global.App.Redis.SetNX(context.Background(), throttleKey, 1, tokenLastUsedInterval).Result(); ok {
+		global.App.DB.Model(&token).UpdateColumns(map[string]interface{}{"last_used_at": now, "last_used_ip": ip})
+	}
+	return
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"errors"
+	"gin-web/app/common/request"
+	"gin-web/app/models"
+	"gin-web/global"
+	"testing"
+	"time"
+)
+
+// createTestRole 创建带权限的角色
+func createTestRole(t *testing.T, name string, permissions ...string) {
+	t.Helper()
+	role := models.Role{Name: name}
+	for _, permission := range permissions {
+		role.Permissions = append(role.Permissions, models.Permission{Name: permission})
+	}
+	if err := global.App.DB.Create(&role).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPersonalAccessTokenScopes(t *testing.T) {
+	setupServiceTest(t)
+	user := createTestUser(t, "13800000000")
+	s := PersonalAccessTokenService
+
+	err, created := s.Create(user.ID.ID, request.CreatePersonalAccessToken{Name: "ci", Scopes: []string{"publish", "publish"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(created.Scopes) != 1 || created.Token[:len(PersonalAccessTokenPrefix)] != PersonalAccessTokenPrefix {
+		t.Fatalf("unexpected token %+v", created)
+	}
+
+	err, token := s.Authenticate(created.Token, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// publish 包含 read，但不包含 admin
+	if !token.HasScope(models.TokenScopeRead) || !token.HasScope(models.TokenScopePublish) || token.HasScope(models.TokenScopeAdmin) {
+		t.Fatalf("unexpected scopes %v", token.ScopeList())
+	}
+	global.App.DB.First(&token, token.ID.ID)
+	if token.LastUsedAt == nil || token.LastUsedIp != "127.0.0.1" {
+		t.Fatalf("last used not recorded: %v %q", token.LastUsedAt, token.LastUsedIp)
+	}
+
+	if err, _ = s.Create(user.ID.ID, request.CreatePersonalAccessToken{Name: "bad", Scopes: []string{"write"}}); err == nil {
+		t.Fatal("created token with unknown scope")
+	}
+}
+
+func TestPersonalAccessTokenAdminScope(t *testing.T) {
+	setupServiceTest(t)
+	createTestRole(t, models.RoleAuthor, models.PermissionModPublish)
+	createTestRole(t, models.RoleModerator, models.PermissionModPublish, models.PermissionCommentModerate)
+	user := createTestUser(t, "13800000000")
+	s := PersonalAccessTokenService
+	params := request.CreatePersonalAccessToken{Name: "moderation", Scopes: []string{models.TokenScopeAdmin}}
+
+	// 没有审核权限的用户不能创建 admin 范围的令牌
+	if err, _ := s.Create(user.ID.ID, params); err == nil {
+		t.Fatal("created admin token without permission")
+	}
+	if err := RbacService.AddRole(user.ID.ID, models.RoleAuthor); err != nil {
+		t.Fatal(err)
+	}
+	if err, _ := s.Create(user.ID.ID, params); err == nil {
+		t.Fatal("author created admin token")
+	}
+
+	if err := RbacService.AddRole(user.ID.ID, models.RoleModerator); err != nil {
+		t.Fatal(err)
+	}
+	err, created := s.Create(user.ID.ID, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, token := s.Authenticate(created.Token, "127.0.0.1")
+	if !token.HasScope(models.TokenScopeAdmin) || !token.HasScope(models.TokenScopePublish) {
+		t.Fatalf("unexpected scopes %v", token.ScopeList())
+	}
+}
+
+func TestPersonalAccessTokenExpiryAndRevoke(t *testing.T) {
+	setupServiceTest(t)
+	user := createTestUser(t, "13800000000")
+	other := createTestUser(t, "13800000001")
+	s := PersonalAccessTokenService
+
+	_, created := s.Create(user.ID.ID, request.CreatePersonalAccessToken{Name: "ci", Scopes: []string{"read"}, ExpiresInDays: 1})
+	global.App.DB.Model(&models.PersonalAccessToken{}).Where("id = ?", created.ID).
+		UpdateColumn("expires_at", time.Now().Add(-time.Minute))
+	if err, _ := s.Authenticate(created.Token, ""); !errors.Is(err, ErrPersonalAccessTokenInvalid) {
+		t.Fatalf("expired token: err = %v, want ErrPersonalAccessTokenInvalid", err)
+	}
+
+	_, created = s.Create(user.ID.ID, request.CreatePersonalAccessToken{Name: "ci", Scopes: []string{"read"}})
+	// 只能吊销自己的令牌
+	if err := s.Revoke(other.ID.ID, created.ID); err == nil {
+		t.Fatal("revoked token of other user")
+	}
+	if err := s.Revoke(user.ID.ID, created.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err, _ := s.Authenticate(created.Token, ""); !errors.Is(err, ErrPersonalAccessTokenInvalid) {
+		t.Fatalf("revoked token: err = %v, want ErrPersonalAccessTokenInvalid", err)
+	}
+}
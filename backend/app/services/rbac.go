@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gin-web/app/models"
+	"gin-web/global"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type rbacService struct{}
+
+var RbacService = new(rbacService)
+
+// 用户角色权限缓存有效期
+const rbacCacheTtl = 10 * time.Minute
+
+// UserAccess 用户拥有的角色和权限
+type UserAccess struct {
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
+
+// HasRole 是否拥有任一角色
+func (access UserAccess) HasRole(roles ...string) bool {
+	for _, role := range roles {
+		for _, r := range access.Roles {
+			if r == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasPermissions 是否拥有全部权限
+func (access UserAccess) HasPermissions(permissions ...string) bool {
+	for _, permission := range permissions {
+		found := false
+		for _, p := range access.Permissions {
+			if p == permission {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// 获取用户角色权限缓存 key
+func (rbacService *rbacService) getCacheKey(userID uint) string {
+	return "rbac:user_access:" + strconv.Itoa(int(userID))
+}
+
+// 获取后台账号角色权限缓存 key
+func (rbacService *rbacService) getAdminCacheKey(adminID uint) string {
+	return "rbac:admin_access:" + strconv.Itoa(int(adminID))
+}
+
+// buildAccess 汇总角色及其权限
+func (rbacService *rbacService) buildAccess(roles []models.Role) UserAccess {
+	access := UserAccess{Roles: []string{}, Permissions: []string{}}
+	seen := make(map[string]bool)
+	for _, role := range roles {
+		access.Roles = append(access.Roles, role.Name)
+		for _, permission := range role.Permissions {
+			if !seen[permission.Name] {
+				seen[permission.Name] = true
+				access.Permissions = append(access.Permissions, permission.Name)
+			}
+		}
+	}
+	return access
+}
+
+// GetUserAccess 获取用户角色和权限，优先读取 Redis 缓存
+func (rbacService *rbacService) GetUserAccess(userID uint) (err error, access UserAccess) {
+	ctx := context.Background()
+	cacheKey := rbacService.getCacheKey(userID)
+	if cached, cacheErr := global.App.Redis.Get(ctx, cacheKey).Bytes(); cacheErr == nil {
+		if json.Unmarshal(cached, &access) == nil {
+			return
+		}
+	}
+
+	var user models.User
+	err = global.App.DB.Preload("Roles.Permissions").First(&user, userID).Error
+	if err != nil {
+		err = errors.New("用户不存在")
+		return
+	}
+
+	access = rbacService.buildAccess(user.Roles)
+	if data, marshalErr := json.Marshal(access); marshalErr == nil {
+		global.App.Redis.Set(ctx, cacheKey, data, rbacCacheTtl)
+	}
+	return
+}
+
+// GetAdminAccess 获取后台账号角色和权限，禁用的账号视为不存在，优先读取 Redis 缓存
+func (rbacService *rbacService) GetAdminAccess(adminID uint) (err error, access UserAccess) {
+	ctx := context.Background()
+	cacheKey := rbacService.getAdminCacheKey(adminID)
+	if cached, cacheErr := global.App.Redis.Get(ctx, cacheKey).Bytes(); cacheErr == nil {
+		if json.Unmarshal(cached, &access) == nil {
+			return
+		}
+	}
+
+	var adminUser models.AdminUser
+	err = global.App.DB.Preload("Roles.Permissions").
+		Where("status = ?", models.AdminUserStatusEnabled).
+		First(&adminUser, adminID).Error
+	if err != nil {
+		err = errors.New("账号不存在")
+		return
+	}
+
+	access = rbacService.buildAccess(adminUser.Roles)
+	if data, marshalErr := json.Marshal(access); marshalErr == nil {
+		global.App.Redis.Set(ctx, cacheKey, data, rbacCacheTtl)
+	}
+	return
+}
+
+// HasPermissions 用户是否拥有全部权限
+func (rbacService *rbacService) HasPermissions(userID uint, permissions ...string) bool {
+	err, access := rbacService.GetUserAccess(userID)
+	if err != nil {
+		return false
+	}
+	return access.HasPermissions(permissions...)
+}
+
+// AssignRoles 覆盖设置用户角色
+func (rbacService *rbacService) AssignRoles(userID uint, roleNames []string) (err error, access UserAccess) {
+	var user models.User
+	if err = global.App.DB.First(&user, userID).Error; err != nil {
+		err = errors.New("用户不存在")
+		return
+	}
+
+	var roles []models.Role
+	if len(roleNames) > 0 {
+		if err = global.App.DB.Where("name IN ?", roleNames).Find(&roles).Error; err != nil {
+			return
+		}
+		if len(roles) != len(roleNames) {
+			err = errors.New("角色不存在")
+			return
+		}
+	}
+
+	if err = global.App.DB.Model(&user).Association("Roles").Replace(roles); err != nil {
+		return
+	}
+	rbacService.Invalidate(userID)
+	return rbacService.GetUserAccess(userID)
+}
+
+// AddRole 给用户追加角色
+func (rbacService *rbacService) AddRole(userID uint, roleName string) error {
+	if err := rbacService.AddRoleTx(global.App.DB, userID, roleName); err != nil {
+		return err
+	}
+	rbacService.Invalidate(userID)
+	return nil
+}
+
+// AddRoleTx 在调用方事务内给用户追加角色，提交后由调用方清除缓存
+func (rbacService *rbacService) AddRoleTx(tx *gorm.DB, userID uint, roleName string) error {
+	var role models.Role
+	if err := tx.Where("name = ?", roleName).First(&role).Error; err != nil {
+		return errors.New("角色不存在")
+	}
+
+	user := models.User{ID: models.ID{ID: userID}}
+	return tx.Model(&user).Association("Roles").Append(&role)
+}
+
+// AddAdminRole 给后台账号追加角色
+func (rbacService *rbacService) AddAdminRole(adminID uint, roleName string) error {
+	var role models.Role
+	if err := global.App.DB.Where("name = ?", roleName).First(&role).Error; err != nil {
+		return errors.New("角色不存在")
+	}
+
+	adminUser := models.AdminUser{ID: models.ID{ID: adminID}}
+	if err := global.App.DB.Model(&adminUser).Association("Roles").Append(&role); err != nil {
+		return err
+	}
+	rbacService.InvalidateAdmin(adminID)
+	return nil
+}
+
+// InvalidateAdmin 清除后台账号角色权限缓存
+func (rbacService *rbacService) InvalidateAdmin(adminID uint) {
+	global.App.Redis.Del(context.Background(), rbacService.getAdminCacheKey(adminID))
+}
+
+// Invalidate 清除用户角色权限缓存
+func (rbacService *rbacService) Invalidate(userID uint) {
+	global.App.Redis.Del(context.Background(), rbacService.getCacheKey(userID))
+}
+
+// ListRoles 角色列表
+func (rbacService *rbacService) ListRoles() (err error, roles []models.Role) {
+	err = global.App.DB.Preload("Permissions").Order("id").Find(&roles).Error
+	return
+}
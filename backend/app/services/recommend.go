@@ -0,0 +1,286 @@
+package services
+
+import (
+	"errors"
+	"gin-web/app/common/response"
+	"gin-web/app/models"
+	"gin-web/global"
+	"math"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type recommendService struct{}
+
+var RecommendService = &recommendService{}
+
+const (
+	defaultRecommendTopK           = 20
+	defaultRecommendInterval       = 3600
+	defaultRecommendLookbackDays   = 90
+	defaultRecommendMinCoDownloads = 2
+)
+
+// coDownload 两个 mod 的共同下载用户数
+type coDownload struct {
+	ModID        uint
+	RelatedModID uint
+	Users        int64
+}
+
+// modDownloaders 单个 mod 的下载用户数
+type modDownloaders struct {
+	ModID uint
+	Users int64
+}
+
+func (s *recommendService) topK() int {
+	if global.App.Config.Recommend.TopK <= 0 {
+		return defaultRecommendTopK
+	}
+	return global.App.Config.Recommend.TopK
+}
+
+// Interval 离线计算间隔
+func (s *recommendService) Interval() time.Duration {
+	interval := global.App.Config.Recommend.Interval
+	if interval <= 0 {
+		interval = defaultRecommendInterval
+	}
+	return time.Duration(interval) * time.Second
+}
+
+// RecordDownload 记录登录用户的下载，作为共同下载统计的数据源
+// 同一用户重复下载只累加次数并刷新最近下载时间，记录数不随下载次数增长
+func (s *recommendService) RecordDownload(userID uint, modID uint, gameID uint) {
+	now := time.Now()
+	err := global.App.DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "mod_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"downloads":          gorm.Expr("downloads + 1"),
+			"game_id":            gameID,
+			"last_downloaded_at": now,
+		}),
+	}).Create(&models.ModDownload{
+		UserID:           userID,
+		GameID:           gameID,
+		ModID:            modID,
+		Downloads:        1,
+		LastDownloadedAt: now,
+	}).Error
+	if err != nil {
+		global.App.Log.Error("record mod download failed", zap.Uint("mod_id", modID), zap.Error(err))
+	}
+}
+
+// Compute 清理统计窗口外的下载记录后，按游戏计算共同下载，为每个 mod 保存 top-K 相关 mod
+func (s *recommendService) Compute() {
+	lock := global.Lock("mod_recommend_compute_lock", int64(s.Interval()/time.Second))
+	if !lock.Get() {
+		return
+	}
+	defer lock.Release()
+
+	lookbackDays := global.App.Config.Recommend.LookbackDays
+	if lookbackDays <= 0 {
+		lookbackDays = defaultRecommendLookbackDays
+	}
+	since := time.Now().AddDate(0, 0, -lookbackDays)
+
+	if err := global.App.DB.Where("last_downloaded_at < ?", since).Delete(&models.ModDownload{}).Error; err != nil {
+		global.App.Log.Error("prune mod downloads failed", zap.Error(err))
+	}
+
+	var gameIDs []uint
+	if err := global.App.DB.Model(&models.ModDownload{}).
+		Where("last_downloaded_at >= ?", since).
+		Distinct().Pluck("game_id", &gameIDs).Error; err != nil {
+		global.App.Log.Error("mod recommend compute failed", zap.Error(err))
+		return
+	}
+
+	for _, gameID := range gameIDs {
+		if err := s.computeGame(gameID, since); err != nil {
+			global.App.Log.Error("mod recommend compute failed", zap.Uint("game_id", gameID), zap.Error(err))
+		}
+	}
+}
+
+// computeGame 计算单个游戏内的相关 mod，得分为共同下载用户数的余弦相似度
+func (s *recommendService) computeGame(gameID uint, since time.Time) error {
+	minCoDownloads := global.App.Config.Recommend.MinCoDownloads
+	if minCoDownloads <= 0 {
+		minCoDownloads = defaultRecommendMinCoDownloads
+	}
+
+	// 每个用户每个 mod 只有一行，行数即下载用户数
+	var downloaders []modDownloaders
+	if err := global.App.DB.Model(&models.ModDownload{}).
+		Select("mod_id, COUNT(*) AS users").
+		Where("game_id = ? AND last_downloaded_at >= ?", gameID, since).
+		Group("mod_id").
+		Scan(&downloaders).Error; err != nil {
+		return err
+	}
+	usersByMod := make(map[uint]int64, len(downloaders))
+	for _, d := range downloaders {
+		usersByMod[d.ModID] = d.Users
+	}
+
+	var pairs []coDownload
+	table := models.ModDownload{}.TableName()
+	if err := global.App.DB.Table(table+" AS a").
+		Select("a.mod_id AS mod_id, b.mod_id AS related_mod_id, COUNT(*) AS users").
+		Joins("JOIN "+table+" AS b ON a.user_id = b.user_id AND a.game_id = b.game_id AND a.mod_id <> b.mod_id").
+		Where("a.game_id = ? AND a.last_downloaded_at >= ? AND b.last_downloaded_at >= ?", gameID, since, since).
+		Group("a.mod_id, b.mod_id").
+		Having("COUNT(*) >= ?", minCoDownloads).
+		Scan(&pairs).Error; err != nil {
+		return err
+	}
+
+	byMod := make(map[uint][]models.ModRecommendation)
+	for _, pair := range pairs {
+		score := float64(pair.Users) / math.Sqrt(float64(usersByMod[pair.ModID]*usersByMod[pair.RelatedModID]))
+		byMod[pair.ModID] = append(byMod[pair.ModID], models.ModRecommendation{
+			ModID:        pair.ModID,
+			RelatedModID: pair.RelatedModID,
+			Score:        math.Round(score*10000) / 10000,
+		})
+	}
+
+	recommendations := make([]models.ModRecommendation, 0, len(pairs))
+	for _, related := range byMod {
+		sort.Slice(related, func(i, j int) bool {
+			return related[i].Score > related[j].Score
+		})
+		if len(related) > s.topK() {
+			related = related[:s.topK()]
+		}
+		recommendations = append(recommendations, related...)
+	}
+
+	// 整体替换该游戏下 mod 的推荐结果
+	return global.App.DB.Transaction(func(tx *gorm.DB) error {
+		gameMods := tx.Model(&models.Mod{}).Select("id").Where("game_id = ?", gameID)
+		if err := tx.Where("mod_id IN (?)", gameMods).Delete(&models.ModRecommendation{}).Error; err != nil {
+			return err
+		}
+		if len(recommendations) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(recommendations, 500).Error
+	})
+}
+
+// GetRecommendations 获取相关 mod，共同下载数据不足时（如新 mod）按分类相似度补齐
+func (s *recommendService) GetRecommendations(modID uint, limit int) (*response.ModRecommendationResponse, error) {
+	var mod models.Mod
+	if err := global.App.DB.Preload("Categories").First(&mod, modID).Error; err != nil {
+		return nil, errors.New("Mod not found")
+	}
+
+	var stored []models.ModRecommendation
+	if err := global.App.DB.Where("mod_id = ?", modID).
+		Order("score desc").Limit(limit).
+		Find(&stored).Error; err != nil {
+		return nil, err
+	}
+
+	result := &response.ModRecommendationResponse{List: []response.RecommendedMod{}}
+	exclude := []uint{modID}
+	if len(stored) > 0 {
+		relatedIDs := make([]uint, len(stored))
+		for i, r := range stored {
+			relatedIDs[i] = r.RelatedModID
+		}
+		var related []models.Mod
+		if err := global.App.DB.Preload("Game").Preload("Categories").
+			Where("id IN ?", relatedIDs).Find(&related).Error; err != nil {
+			return nil, err
+		}
+		relatedByID := make(map[uint]models.Mod, len(related))
+		for _, m := range related {
+			relatedByID[m.ID] = m
+		}
+		for _, r := range stored {
+			m, ok := relatedByID[r.RelatedModID]
+			if !ok {
+				continue
+			}
+			result.List = append(result.List, response.RecommendedMod{
+				ModItem: toModItem(m),
+				Score:   r.Score,
+				Source:  models.RecommendSourceCoDownload,
+			})
+			exclude = append(exclude, m.ID)
+		}
+	}
+
+	if len(result.List) < limit && len(mod.Categories) > 0 {
+		fallback, err := s.categorySimilar(mod, exclude, limit-len(result.List))
+		if err != nil {
+			return nil, err
+		}
+		result.List = append(result.List, fallback...)
+	}
+	return result, nil
+}
+
+// categorySimilar 同游戏下共享分类最多的 mod（mod 暂无独立标签，分类即标签），得分为共享分类数占比
+func (s *recommendService) categorySimilar(mod models.Mod, exclude []uint, limit int) ([]response.RecommendedMod, error) {
+	categoryIDs := make([]uint, len(mod.Categories))
+	for i, category := range mod.Categories {
+		categoryIDs[i] = category.ID
+	}
+
+	var shared []struct {
+		ModID  uint
+		Shared int64
+	}
+	if err := global.App.DB.Table("gw_mod_categories").
+		Select("gw_mod_categories.mod_id AS mod_id, COUNT(*) AS shared").
+		Joins("JOIN mods ON mods.id = gw_mod_categories.mod_id").
+		Where("gw_mod_categories.category_id IN ? AND mods.game_id = ? AND mods.id NOT IN ?", categoryIDs, mod.GameID, exclude).
+		Group("gw_mod_categories.mod_id, mods.download_count").
+		Order("shared desc, mods.download_count desc").
+		Limit(limit).
+		Scan(&shared).Error; err != nil {
+		return nil, err
+	}
+	if len(shared) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uint, len(shared))
+	for i, item := range shared {
+		ids[i] = item.ModID
+	}
+	var mods []models.Mod
+	if err := global.App.DB.Preload("Game").Preload("Categories").
+		Where("id IN ?", ids).Find(&mods).Error; err != nil {
+		return nil, err
+	}
+	modByID := make(map[uint]models.Mod, len(mods))
+	for _, m := range mods {
+		modByID[m.ID] = m
+	}
+
+	list := make([]response.RecommendedMod, 0, len(shared))
+	for _, item := range shared {
+		m, ok := modByID[item.ModID]
+		if !ok {
+			continue
+		}
+		list = append(list, response.RecommendedMod{
+			ModItem: toModItem(m),
+			Score:   math.Round(float64(item.Shared)/float64(len(categoryIDs))*10000) / 10000,
+			Source:  models.RecommendSourceCategory,
+		})
+	}
+	return list, nil
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"gin-web/app/models"
+	"gin-web/global"
+	"testing"
+	"time"
+)
+
+func TestRecordDownloadAggregatesPerUser(t *testing.T) {
+	setupServiceTest(t)
+	s := RecommendService
+
+	for i := 0; i < 3; i++ {
+		s.RecordDownload(1, 10, 1)
+	}
+	s.RecordDownload(2, 10, 1)
+
+	var downloads []models.ModDownload
+	global.App.DB.Order("user_id").Find(&downloads)
+	if len(downloads) != 2 || downloads[0].Downloads != 3 || downloads[1].Downloads != 1 {
+		t.Fatalf("unexpected downloads %+v", downloads)
+	}
+}
+
+func TestRecommendCompute(t *testing.T) {
+	setupServiceTest(t)
+	s := RecommendService
+	a, b, c := createTestMod(t, "a"), createTestMod(t, "b"), createTestMod(t, "c")
+
+	// 用户 1、2 都下载了 a 和 b，用户 3 下载了 a 和 c
+	for _, d := range []struct{ user, mod uint }{
+		{1, a.ID}, {1, b.ID}, {2, a.ID}, {2, b.ID}, {3, a.ID}, {3, c.ID},
+	} {
+		s.RecordDownload(d.user, d.mod, 1)
+	}
+	// 统计窗口外的记录在计算时清理，不参与计算
+	stale := time.Now().AddDate(0, 0, -defaultRecommendLookbackDays-1)
+	global.App.DB.Create(&models.ModDownload{UserID: 4, ModID: a.ID, GameID: 1, Downloads: 1, LastDownloadedAt: stale})
+	global.App.DB.Create(&models.ModDownload{UserID: 4, ModID: c.ID, GameID: 1, Downloads: 1, LastDownloadedAt: stale})
+
+	s.Compute()
+
+	var count int64
+	global.App.DB.Model(&models.ModDownload{}).Where("user_id = ?", 4).Count(&count)
+	if count != 0 {
+		t.Fatalf("%d stale downloads left", count)
+	}
+
+	var recommendations []models.ModRecommendation
+	global.App.DB.Order("mod_id, related_mod_id").Find(&recommendations)
+	// 共同下载用户数低于 min_co_downloads（2）的 a、c 不推荐
+	if len(recommendations) != 2 {
+		t.Fatalf("unexpected recommendations %+v", recommendations)
+	}
+	// a 有 3 个下载用户，b 有 2 个，共同 2 个：2 / sqrt(3*2)
+	for _, r := range recommendations {
+		if r.Score != 0.8165 {
+			t.Fatalf("score = %v, want 0.8165", r.Score)
+		}
+	}
+	if recommendations[0].ModID != a.ID || recommendations[0].RelatedModID != b.ID ||
+		recommendations[1].ModID != b.ID || recommendations[1].RelatedModID != a.ID {
+		t.Fatalf("unexpected recommendations %+v", recommendations)
+	}
+}
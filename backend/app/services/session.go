@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"gin-web/app/common/response"
+	"gin-web/global"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// 会话最后活跃时间的最小更新间隔
+const sessionTouchInterval = 60
+
+type sessionService struct{}
+
+var SessionService = new(sessionService)
+
+// SessionClient 登录时的客户端信息
+type SessionClient struct {
+	UserAgent string
+	IP        string
+}
+
+// touchSessionLuaScript 会话存在时按最小间隔更新最后活跃时间，一次往返完成校验
+// 返回 1 表示会话有效
+const touchSessionLuaScript = `
+local last = redis.call("HGET", KEYS[1], "last_seen")
+if not last then
+    return 0
+end
+if tonumber(ARGV[1]) - tonumber(last) >= tonumber(ARGV[3]) then
+    redis.call("HSET", KEYS[1], "last_seen", ARGV[1], "ip", ARGV[2])
+end
+return 1
+`
+
+var touchSessionScript = redis.NewScript(touchSessionLuaScript)
+
+// 获取会话缓存 key
+func (s *sessionService) getSessionKey(GuardName string, familyID string) string {
+	return "refresh_family:" + GuardName + ":" + familyID
+}
+
+// 获取用户会话索引缓存 key
+func (s *sessionService) getUserSessionsKey(GuardName string, uid string) string {
+	return "user_sessions:" + GuardName + ":" + uid
+}
+
+// create 创建会话
+func (s *sessionService) create(GuardName string, uid string, familyID string, client SessionClient, ttl time.Duration) error {
+	ctx := context.Background()
+	now := time.Now().Unix()
+	sessionKey := s.getSessionKey(GuardName, familyID)
+	userKey := s.getUserSessionsKey(GuardName, uid)
+
+	userAgent := client.UserAgent
+	if len(userAgent) > 512 {
+		userAgent = userAgent[:512]
+	}
+
+	pipe := global.App.Redis.TxPipeline()
+	pipe.HSet(ctx, sessionKey, "uid", uid, "ua", userAgent, "ip", client.IP, "created_at", now, "last_seen", now)
+	pipe.Expire(ctx, sessionKey, ttl)
+	pipe.SAdd(ctx, userKey, familyID)
+	pipe.Expire(ctx, userKey, ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// extend refresh token 轮换时延长会话有效期
+func (s *sessionService) extend(GuardName string, uid string, familyID string, ttl time.Duration) {
+	ctx := context.Background()
+	pipe := global.App.Redis.TxPipeline()
+	pipe.HSet(ctx, s.getSessionKey(GuardName, familyID), "last_seen", time.Now().Unix())
+	pipe.Expire(ctx, s.getSessionKey(GuardName, familyID), ttl)
+	pipe.Expire(ctx, s.getUserSessionsKey(GuardName, uid), ttl)
+	pipe.Exec(ctx)
+}
+
+// revoke 删除会话
+func (s *sessionService) revoke(GuardName string, familyID string) {
+	ctx := context.Background()
+	sessionKey := s.getSessionKey(GuardName, familyID)
+	uid, _ := global.App.Redis.HGet(ctx, sessionKey, "uid").Result()
+	global.App.Redis.Del(ctx, sessionKey)
+	if uid != "" {
+		global.App.Redis.SRem(ctx, s.getUserSessionsKey(GuardName, uid), familyID)
+	}
+}
+
+// Touch 校验会话是否有效并记录最后活跃时间和 IP，供 JWTAuth 在每个请求上调用
+func (s *sessionService) Touch(GuardName string, familyID string, ip string) bool {
+	if familyID == "" {
+		return false
+	}
+	result, err := touchSessionScript.Run(context.Background(), global.App.Redis,
+		[]string{s.getSessionKey(GuardName, familyID)}, time.Now().Unix(), ip, sessionTouchInterval).Int()
+	return err == nil && result == 1
+}
+
+// List 用户的全部有效会话，最近活跃的在前
+func (s *sessionService) List(GuardName string, uid string, currentFamilyID string) ([]response.Session, error) {
+	ctx := context.Background()
+	userKey := s.getUserSessionsKey(GuardName, uid)
+	familyIDs, err := global.App.Redis.SMembers(ctx, userKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	pipe := global.App.Redis.Pipeline()
+	cmds := make([]*redis.StringStringMapCmd, len(familyIDs))
+	for i, familyID := range familyIDs {
+		cmds[i] = pipe.HGetAll(ctx, s.getSessionKey(GuardName, familyID))
+	}
+	if _, err = pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	sessions := make([]response.Session, 0, len(familyIDs))
+	for i, familyID := range familyIDs {
+		values := cmds[i].Val()
+		if len(values) == 0 {
+			// 会话已过期，顺便清理索引
+			global.App.Redis.SRem(ctx, userKey, familyID)
+			continue
+		}
+		createdAt, _ := strconv.ParseInt(values["created_at"], 10, 64)
+		lastSeen, _ := strconv.ParseInt(values["last_seen"], 10, 64)
+		sessions = append(sessions, response.Session{
+			ID:         familyID,
+			UserAgent:  values["ua"],
+			IP:         values["ip"],
+			CreatedAt:  time.Unix(createdAt, 0),
+			LastSeenAt: time.Unix(lastSeen, 0),
+			Current:    familyID == currentFamilyID,
+		})
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt) })
+	return sessions, nil
+}
+
+// Revoke 吊销用户的指定会话
+func (s *sessionService) Revoke(GuardName string, uid string, familyID string) error {
+	isMember, err := global.App.Redis.SIsMember(context.Background(), s.getUserSessionsKey(GuardName, uid), familyID).Result()
+	if err != nil {
+		return err
+	}
+	if !isMember {
+		return errors.New("会话不存在")
+	}
+	s.revoke(GuardName, familyID)
+	return nil
+}
+
+// RevokeOthers 吊销除当前会话外的全部会话，exceptFamilyID 为空时吊销全部
+func (s *sessionService) RevokeOthers(GuardName string, uid string, exceptFamilyID string) (int, error) {
+	familyIDs, err := global.App.Redis.SMembers(context.Background(), s.getUserSessionsKey(GuardName, uid)).Result()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, familyID := range familyIDs {
+		if familyID == exceptFamilyID {
+			continue
+		}
+		s.revoke(GuardName, familyID)
+		count++
+	}
+	return count, nil
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"gin-web/app/models"
+	"gin-web/global"
+	"path/filepath"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/glebarez/sqlite"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// setupServiceTest 使用 SQLite 临时库和 miniredis 初始化全局依赖，测试结束后恢复
+func setupServiceTest(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+	previous := *global.App
+	t.Cleanup(func() { *global.App = previous })
+
+	mr := miniredis.RunT(t)
+	global.App.Log = zap.NewNop()
+	global.App.Redis = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+		Logger:                                   logger.Default.LogMode(logger.Silent),
+		NamingStrategy:                           schema.NamingStrategy{TablePrefix: "gw_"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(
+		models.User{},
+		models.Game{},
+		models.Category{},
+		models.Mod{},
+		models.ModStat{},
+		models.ModDownload{},
+		models.ModRecommendation{},
+		models.Notification{},
+		models.NotificationPreference{},
+		models.Permission{},
+		models.Role{},
+		models.JwtKey{},
+		models.AdminUser{},
+		models.UserIdentity{},
+		models.UserMfa{},
+		models.UserRecoveryCode{},
+		models.PersonalAccessToken{},
+		models.OutboxMessage{},
+		models.AuthorClaim{},
+		models.ModFollow{},
+		models.ModComment{},
+	); err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+	global.App.DB = db
+	return mr
+}
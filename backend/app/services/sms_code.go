@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"gin-web/app/sms"
+	"gin-web/global"
+	"gin-web/utils"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// 短信验证码使用场景，不同场景的验证码互不通用
+const (
+	SmsSceneLogin = "login"
+)
+
+const (
+	defaultSmsCodeLength     = 6
+	defaultSmsCodeTtl        = 300
+	defaultSmsMaxAttempts    = 5
+	defaultSmsResendCooldown = 60
+	defaultSmsDailyLimit     = 10
+)
+
+type smsCodeService struct{}
+
+var SmsCodeService = new(smsCodeService)
+
+var (
+	ErrSmsCodeInvalid   = errors.New("验证码错误或已过期")
+	ErrSmsSendTooOften  = errors.New("发送过于频繁，请稍后再试")
+	ErrSmsDailyExceeded = errors.New("今日发送次数已达上限")
+)
+
+// verifySmsCodeLuaScript 原子地累加校验次数并比对验证码
+// 返回 missing（不存在或已过期）、exceeded（次数用尽）、mismatch、ok，成功或次数用尽时删除验证码
+const verifySmsCodeLuaScript = `
+local hash = redis.call("HGET", KEYS[1], "hash")
+if not hash then
+    return "missing"
+end
+local attempts = redis.call("HINCRBY", KEYS[1], "attempts", 1)
+if attempts > tonumber(ARGV[2]) then
+    redis.call("DEL", KEYS[1])
+    return "exceeded"
+end
+if hash ~= ARGV[1] then
+    return "mismatch"
+end
+redis.call("DEL", KEYS[1])
+return "ok"
+`
+
+func (s *smsCodeService) config() (length int, ttl time.Duration, maxAttempts int64, cooldown time.Duration, dailyLimit int64) {
+	conf := global.App.Config.Sms
+	length, maxAttempts, dailyLimit = conf.CodeLength, conf.MaxAttempts, conf.DailyLimit
+	ttlSeconds, cooldownSeconds := conf.CodeTtl, conf.ResendCooldown
+	if length <= 0 {
+		length = defaultSmsCodeLength
+	}
+	if ttlSeconds <= 0 {
+		ttlSeconds = defaultSmsCodeTtl
+	}
+	if maxAttempts <= 0 {
+		maxAttempts = defaultSmsMaxAttempts
+	}
+	if cooldownSeconds <= 0 {
+		cooldownSeconds = defaultSmsResendCooldown
+	}
+	if dailyLimit <= 0 {
+		dailyLimit = defaultSmsDailyLimit
+	}
+	return length, time.Duration(ttlSeconds) * time.Second, maxAttempts, time.Duration(cooldownSeconds) * time.Second, dailyLimit
+}
+
+// 获取验证码缓存 key
+func (s *smsCodeService) getCodeKey(scene string, mobile string) string {
+	return "sms_code:" + scene + ":" + mobile
+}
+
+// 获取重发冷却缓存 key
+func (s *smsCodeService) getCooldownKey(scene string, mobile string) string {
+	return "sms_code_cooldown:" + scene + ":" + mobile
+}
+
+// 获取每日发送次数缓存 key，不区分场景
+func (s *smsCodeService) getDailyKey(mobile string) string {
+	return "sms_code_daily:" + mobile + ":" + time.Now().Format("20060102")
+}
+
+// hashCode 验证码只保存哈希，混入手机号避免彩虹表
+func (s *smsCodeService) hashCode(mobile string, code string) string {
+	return utils.Sha256([]byte(mobile + ":" + code))
+}
+
+// SendCode 发送验证码，新验证码会覆盖旧验证码并重置校验次数
+func (s *smsCodeService) SendCode(scene string, mobile string) error {
+	length, ttl, _, cooldown, dailyLimit := s.config()
+	ctx := context.Background()
+
+	ok, err := global.App.Redis.SetNX(ctx, s.getCooldownKey(scene, mobile), 1, cooldown).Result()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrSmsSendTooOften
+	}
+
+	dailyKey := s.getDailyKey(mobile)
+	count, err := global.App.Redis.Incr(ctx, dailyKey).Result()
+	if err != nil {
+		return err
+	}
+	if count == 1 {
+		global.App.Redis.Expire(ctx, dailyKey, 24*time.Hour)
+	}
+	if count > dailyLimit {
+		return ErrSmsDailyExceeded
+	}
+
+	code := utils.RandDigits(length)
+	codeKey := s.getCodeKey(scene, mobile)
+	pipe := global.App.Redis.TxPipeline()
+	pipe.Del(ctx, codeKey)
+	pipe.HSet(ctx, codeKey, "hash", s.hashCode(mobile, code), "attempts", 0)
+	pipe.Expire(ctx, codeKey, ttl)
+	if _, err = pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	err = global.App.Sms.Send(ctx, sms.Message{
+		Mobile:   mobile,
+		Template: sms.TemplateVerifyCode,
+		Params: map[string]string{
+			"code":    code,
+			"minutes": strconv.Itoa(int(ttl.Minutes())),
+		},
+	})
+	if err != nil {
+		// 发送失败时允许立即重试
+		global.App.Redis.Del(ctx, s.getCooldownKey(scene, mobile), codeKey)
+	}
+	return err
+}
+
+// VerifyCode 校验验证码，成功后验证码立即失效
+func (s *smsCodeService) VerifyCode(scene string, mobile string, code string) error {
+	_, _, maxAttempts, _, _ := s.config()
+	result, err := redis.NewScript(verifySmsCodeLuaScript).Run(context.Background(), global.App.Redis,
+		[]string{s.getCodeKey(scene, mobile)}, s.hashCode(mobile, code), maxAttempts).Text()
+	if err != nil {
+		return err
+	}
+	if result != "ok" {
+		return ErrSmsCodeInvalid
+	}
+	return nil
+}
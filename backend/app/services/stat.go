@@ -0,0 +1,247 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"gin-web/app/common/request"
+	"gin-web/app/common/response"
+	"gin-web/app/models"
+	"gin-web/global"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type statService struct{}
+
+var StatService = new(statService)
+
+const (
+	statDateLayout = "2006-01-02"
+
+	// 待回写的统计增量，field 格式：类型|粒度|时间段起点|modID|版本
+	statPendingKey  = "mod_stats:pending"
+	statFlushingKey = "mod_stats:flushing"
+
+	defaultStatDays         = 30
+	defaultStatMaxDayRange  = 366
+	defaultStatMaxHourRange = 744
+)
+
+// periodStart 时间所在统计时间段的起点
+func periodStart(t time.Time, granularity string) time.Time {
+	if granularity == models.StatGranularityHour {
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// nextPeriod 下一个统计时间段的起点
+func nextPeriod(t time.Time, granularity string) time.Time {
+	if granularity == models.StatGranularityHour {
+		return t.Add(time.Hour)
+	}
+	return t.AddDate(0, 0, 1)
+}
+
+// Record 记录一次已去重的浏览或下载事件
+func (statService *statService) Record(kind string, modID uint, version string) {
+	granularities := []string{models.StatGranularityDay}
+	if global.App.Config.Stats.Hourly {
+		granularities = append(granularities, models.StatGranularityHour)
+	}
+
+	ctx := context.Background()
+	now := time.Now()
+	pipe := global.App.Redis.Pipeline()
+	for _, granularity := range granularities {
+		field := strings.Join([]string{
+			kind,
+			granularity,
+			strconv.FormatInt(periodStart(now, granularity).Unix(), 10),
+			strconv.Itoa(int(modID)),
+			version,
+		}, "|")
+		pipe.HIncrBy(ctx, statPendingKey, field, 1)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		global.App.Log.Error("mod stats record failed", zap.Uint("mod_id", modID), zap.Error(err))
+	}
+}
+
+// Flush 将 Redis 中累计的统计增量写入 mod_stats
+func (statService *statService) Flush() {
+	lock := global.Lock("mod_stats_flush_lock", int64(CounterService.FlushInterval()/time.Second))
+	if !lock.Get() {
+		return
+	}
+	defer lock.Release()
+
+	ctx := context.Background()
+	// 上次回写失败时 flushing 仍存在，先处理完再切换新的增量
+	if global.App.Redis.Exists(ctx, statFlushingKey).Val() == 0 {
+		if err := global.App.Redis.Rename(ctx, statPendingKey, statFlushingKey).Err(); err != nil {
+			// pending 不存在说明没有新的统计
+			return
+		}
+	}
+
+	fields, err := global.App.Redis.HGetAll(ctx, statFlushingKey).Result()
+	if err != nil {
+		global.App.Log.Error("mod stats flush failed", zap.Error(err))
+		return
+	}
+
+	rows := make(map[string]*models.ModStat, len(fields))
+	for field, value := range fields {
+		parts := strings.SplitN(field, "|", 5)
+		if len(parts) != 5 {
+			continue
+		}
+		count, _ := strconv.ParseInt(value, 10, 64)
+		period, _ := strconv.ParseInt(parts[2], 10, 64)
+		modID, _ := strconv.ParseUint(parts[3], 10, 32)
+
+		// 同一时间段的浏览和下载合并为一行
+		rowKey := strings.Join(parts[1:], "|")
+		row, ok := rows[rowKey]
+		if !ok {
+			row = &models.ModStat{
+				ModID:       uint(modID),
+				Granularity: parts[1],
+				PeriodStart: time.Unix(period, 0),
+				Version:     parts[4],
+			}
+			rows[rowKey] = row
+		}
+		switch parts[0] {
+		case CounterView:
+			row.Views += count
+		case CounterDownload:
+			row.Downloads += count
+		}
+	}
+
+	stats := make([]*models.ModStat, 0, len(rows))
+	for _, row := range rows {
+		stats = append(stats, row)
+	}
+	if len(stats) > 0 {
+		err = global.App.DB.Clauses(clause.OnConflict{
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"views":      gorm.Expr("views + VALUES(views)"),
+				"downloads":  gorm.Expr("downloads + VALUES(downloads)"),
+				"updated_at": gorm.Expr("VALUES(updated_at)"),
+			}),
+		}).CreateInBatches(stats, 500).Error
+		if err != nil {
+			global.App.Log.Error("mod stats flush failed", zap.Error(err))
+			return
+		}
+	}
+	global.App.Redis.Del(ctx, statFlushingKey)
+}
+
+// GetModStats 获取mod浏览、下载时间序列，无数据的时间段补 0
+func (statService *statService) GetModStats(modID uint, req request.ModStatsRequest) (*response.ModStatsResponse, error) {
+	var count int64
+	global.App.DB.Model(&models.Mod{}).Where("id = ?", modID).Count(&count)
+	if count == 0 {
+		return nil, errors.New("Mod not found")
+	}
+
+	granularity := req.Granularity
+	if granularity == "" {
+		granularity = models.StatGranularityDay
+	}
+	if granularity == models.StatGranularityHour && !global.App.Config.Stats.Hourly {
+		return nil, errors.New("未开启小时粒度统计")
+	}
+
+	from, to, err := statService.parseRange(req.From, req.To, granularity)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats []models.ModStat
+	db := global.App.DB.Model(&models.ModStat{}).
+		Select("period_start, SUM(views) AS views, SUM(downloads) AS downloads").
+		Where("mod_id = ? AND granularity = ? AND period_start >= ? AND period_start < ?", modID, granularity, from, to)
+	if req.Version != "" {
+		db = db.Where("version = ?", req.Version)
+	}
+	if err = db.Group("period_start").Find(&stats).Error; err != nil {
+		return nil, err
+	}
+
+	byPeriod := make(map[int64]models.ModStat, len(stats))
+	for _, stat := range stats {
+		byPeriod[stat.PeriodStart.Unix()] = stat
+	}
+
+	result := &response.ModStatsResponse{
+		ModID:       modID,
+		Granularity: granularity,
+		Version:     req.Version,
+		From:        from,
+		To:          to,
+		Series:      []response.ModStatPoint{},
+	}
+	for t := from; t.Before(to); t = nextPeriod(t, granularity) {
+		stat := byPeriod[t.Unix()]
+		result.Series = append(result.Series, response.ModStatPoint{
+			Time:      t,
+			Views:     stat.Views,
+			Downloads: stat.Downloads,
+		})
+		result.TotalViews += stat.Views
+		result.TotalDownloads += stat.Downloads
+	}
+	return result, nil
+}
+
+// parseRange 解析查询区间，返回左闭右开的 [from, to)
+func (statService *statService) parseRange(fromStr, toStr, granularity string) (from, to time.Time, err error) {
+	today := periodStart(time.Now(), models.StatGranularityDay)
+
+	to = today.AddDate(0, 0, 1)
+	if toStr != "" {
+		if to, err = time.ParseInLocation(statDateLayout, toStr, time.Local); err != nil {
+			return from, to, errors.New("结束日期格式错误，应为 " + statDateLayout)
+		}
+		to = to.AddDate(0, 0, 1)
+	}
+
+	from = to.AddDate(0, 0, -defaultStatDays)
+	if fromStr != "" {
+		if from, err = time.ParseInLocation(statDateLayout, fromStr, time.Local); err != nil {
+			return from, to, errors.New("开始日期格式错误，应为 " + statDateLayout)
+		}
+	}
+	if !from.Before(to) {
+		return from, to, errors.New("开始日期不能晚于结束日期")
+	}
+
+	if granularity == models.StatGranularityHour {
+		maxRange := global.App.Config.Stats.MaxHourRange
+		if maxRange <= 0 {
+			maxRange = defaultStatMaxHourRange
+		}
+		if to.Sub(from) > time.Duration(maxRange)*time.Hour {
+			return from, to, errors.New("查询跨度超过 " + strconv.Itoa(maxRange) + " 小时")
+		}
+	} else {
+		maxRange := global.App.Config.Stats.MaxDayRange
+		if maxRange <= 0 {
+			maxRange = defaultStatMaxDayRange
+		}
+		if to.Sub(from) > time.Duration(maxRange)*24*time.Hour {
+			return from, to, errors.New("查询跨度超过 " + strconv.Itoa(maxRange) + " 天")
+		}
+	}
+	return from, to, nil
+}
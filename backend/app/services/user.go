@@ -5,8 +5,11 @@ import (
 	"gin-web/app/common/request"
 	"gin-web/app/models"
 	"gin-web/global"
-	"gin-web/utils"
 	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 type userService struct {
@@ -21,17 +24,94 @@ func (userService *userService) Register(params request.Register) (err error, us
 		err = errors.New("手机号已存在")
 		return
 	}
-	user = models.User{Name: params.Name, Mobile: params.Mobile, Password: utils.BcryptMake([]byte(params.Password))}
-	err = global.App.DB.Create(&user).Error
+	email := strings.ToLower(params.Email)
+	result = global.App.DB.Where("email = ?", email).Select("id").First(&models.User{})
+	if result.RowsAffected != 0 {
+		err = errors.New("邮箱已存在")
+		return
+	}
+	password, err := PasswordService.Hash(params.Password)
+	if err != nil {
+		return
+	}
+	user = models.User{Name: params.Name, Mobile: params.Mobile, Email: &email, Password: password}
+	// 用户与默认角色同一事务写入，避免出现没有角色的账号
+	err = global.App.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		// 新用户默认为普通用户角色
+		return RbacService.AddRoleTx(tx, user.ID.ID, models.RoleUser)
+	})
+	if err != nil {
+		return
+	}
+	// 验证邮件发送失败不影响注册，用户可稍后重新发送
+	if sendErr := EmailService.SendVerification(user); sendErr != nil {
+		global.App.Log.Error("send verification mail failed", zap.Uint("user_id", user.ID.ID), zap.Error(sendErr))
+	}
 	return
 }
 
-// Login 登录
-func (userService *userService) Login(params request.Login) (err error, user *models.User) {
+// Login 登录，失败次数过多时按账号和 IP 锁定
+func (userService *userService) Login(params request.Login, ip string) (err error, user *models.User) {
+	if err = LoginThrottleService.Check(AppGuardName, params.Mobile, ip); err != nil {
+		return
+	}
+
 	err = global.App.DB.Where("mobile = ?", params.Mobile).First(&user).Error
-	if err != nil || !utils.BcryptMakeCheck([]byte(params.Password), user.Password) {
+	if err != nil {
+		// 账号不存在时同样做一次哈希比对，避免通过响应时间判断手机号是否注册
+		PasswordService.VerifyDummy(params.Password)
+	}
+	if err != nil || !PasswordService.Verify(params.Password, user.Password) {
+		LoginThrottleService.RecordFailure(AppGuardName, params.Mobile, ip)
 		err = errors.New("用户名不存在或密码错误")
+		return
 	}
+	LoginThrottleService.RecordSuccess(AppGuardName, params.Mobile)
+	userService.rehashPassword(user, params.Password)
+	return
+}
+
+// rehashPassword 登录成功后，哈希算法或参数已过时则按当前配置重新计算
+func (userService *userService) rehashPassword(user *models.User, password string) {
+	if !PasswordService.NeedsRehash(user.Password) {
+		return
+	}
+	hash, err := PasswordService.Hash(password)
+	if err != nil {
+		global.App.Log.Error("password rehash failed", zap.Uint("user_id", user.ID.ID), zap.Error(err))
+		return
+	}
+	// 以旧哈希为条件，避免覆盖并发修改的新密码
+	if err = global.App.DB.Model(&models.User{}).Where("id = ? AND password = ?", user.ID.ID, user.Password).
+		UpdateColumn("password", hash).Error; err != nil {
+		global.App.Log.Error("password rehash failed", zap.Uint("user_id", user.ID.ID), zap.Error(err))
+		return
+	}
+	user.Password = hash
+}
+
+// LoginByMobile 短信验证码通过后登录，手机号未注册时自动注册
+func (userService *userService) LoginByMobile(mobile string) (err error, user models.User) {
+	result := global.App.DB.Where("mobile = ?", mobile).Limit(1).Find(&user)
+	if result.Error != nil {
+		err = result.Error
+		return
+	}
+	if result.RowsAffected > 0 {
+		return
+	}
+
+	user = models.User{Name: "用户" + mobile[len(mobile)-4:], Mobile: mobile}
+	err = global.App.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		// 新用户默认为普通用户角色
+		return RbacService.AddRoleTx(tx, user.ID.ID, models.RoleUser)
+	})
 	return
 }
 
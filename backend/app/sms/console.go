@@ -0,0 +1,25 @@
+package sms
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// ConsoleSender 不实际发送，把短信内容写入日志，用于本地开发
+type ConsoleSender struct {
+	logger *zap.Logger
+}
+
+func NewConsoleSender(logger *zap.Logger) *ConsoleSender {
+	return &ConsoleSender{logger: logger}
+}
+
+func (s *ConsoleSender) Send(ctx context.Context, msg Message) error {
+	s.logger.Info("sms",
+		zap.String("mobile", msg.Mobile),
+		zap.String("template", msg.Template),
+		zap.Any("params", msg.Params),
+	)
+	return nil
+}
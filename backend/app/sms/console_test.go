@@ -0,0 +1,23 @@
+package sms
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestConsoleSenderLogs(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	sender := NewConsoleSender(zap.New(core))
+
+	err := sender.Send(context.Background(), Message{Mobile: "13800000000", Template: "login", Params: map[string]string{"code": "123456"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := logs.FilterField(zap.String("mobile", "13800000000")).All()
+	if len(entries) != 1 || entries[0].ContextMap()["template"] != "login" {
+		t.Fatalf("unexpected log entries %+v", logs.All())
+	}
+}
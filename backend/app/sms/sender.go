@@ -0,0 +1,20 @@
+package sms
+
+import "context"
+
+// 短信模板
+const (
+	TemplateVerifyCode = "verify_code" // 参数：code、minutes
+)
+
+// Message 短信内容，具体文案由服务商模板决定
+type Message struct {
+	Mobile   string
+	Template string
+	Params   map[string]string
+}
+
+// Sender 短信发送接口，接入服务商时实现该接口并在 bootstrap.InitializeSms 中注册
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
@@ -0,0 +1,69 @@
+package bootstrap
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"gin-web/app/models"
+	"gin-web/app/services"
+	"gin-web/global"
+	"os"
+)
+
+// RunCommand 执行命令行子命令，没有子命令时返回 false 继续启动 HTTP 服务
+// 用法：go run main.go create-admin -username=admin -password=xxx [-name=admin] [-role=admin]
+func RunCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+
+	var err error
+	switch args[0] {
+	case "create-admin":
+		err = createAdmin(args[1:])
+	default:
+		return false
+	}
+
+	if err != nil {
+		fmt.Println(args[0]+" failed:", err)
+		os.Exit(1)
+	}
+	return true
+}
+
+// createAdmin 创建管理后台账号并授予角色，登录名已存在时只追加角色
+// 前台用户角色可登录后台后通过 /api/admin/users/:id/roles 分配
+func createAdmin(args []string) error {
+	fs := flag.NewFlagSet("create-admin", flag.ContinueOnError)
+	username := fs.String("username", "", "管理员登录名")
+	password := fs.String("password", "", "管理员密码（账号已存在时忽略）")
+	name := fs.String("name", "admin", "管理员名称")
+	role := fs.String("role", models.RoleAdmin, "授予的角色")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" {
+		return errors.New("username is required")
+	}
+	if global.App.DB == nil {
+		return errors.New("database is not initialized")
+	}
+
+	err, adminUser := services.AdminUserService.GetByUsername(*username)
+	if err != nil {
+		if *password == "" {
+			return errors.New("password is required for a new admin")
+		}
+		if err, adminUser = services.AdminUserService.Create(*username, *password, *name); err != nil {
+			return err
+		}
+		fmt.Printf("admin %s (id=%d) created\n", adminUser.Username, adminUser.ID.ID)
+	}
+
+	if err = services.RbacService.AddAdminRole(adminUser.ID.ID, *role); err != nil {
+		return err
+	}
+	fmt.Printf("admin %s (id=%d) now has role %s\n", adminUser.Username, adminUser.ID.ID, *role)
+	return nil
+}
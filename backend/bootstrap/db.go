@@ -129,9 +129,48 @@ func initMySqlTables(db *gorm.DB) {
 		models.Game{},
 		models.Category{},
 		models.Mod{},
+		models.ModStat{},
+		models.ModDownload{},
+		models.ModRecommendation{},
+		models.Notification{},
+		models.NotificationPreference{},
+		models.Permission{},
+		models.Role{},
+		models.JwtKey{},
+		models.AdminUser{},
+		models.UserIdentity{},
+		models.UserMfa{},
+		models.UserRecoveryCode{},
+		models.PersonalAccessToken{},
+		models.OutboxMessage{},
+		models.AuthorClaim{},
+		models.ModFollow{},
+		models.ModComment{},
 	)
 	if err != nil {
 		global.App.Log.Error("migrate table failed", zap.Any("err", err))
 		os.Exit(0)
 	}
+	dropLegacyIndexes(db)
+	seedRoles(db)
+}
+
+// dropLegacyIndexes 删除已被替换的旧索引，AutoMigrate 不会自动删除
+func dropLegacyIndexes(db *gorm.DB) {
+	legacy := []struct {
+		model interface{}
+		name  string
+	}{
+		// 两步验证按 guard 区分后，user_id 单列唯一索引会让同 ID 的前台用户和后台账号冲突
+		{&models.UserMfa{}, "idx_gw_user_mfas_user_id"},
+		{&models.UserRecoveryCode{}, "idx_gw_user_recovery_codes_user_id"},
+	}
+	for _, index := range legacy {
+		if !db.Migrator().HasIndex(index.model, index.name) {
+			continue
+		}
+		if err := db.Migrator().DropIndex(index.model, index.name); err != nil {
+			global.App.Log.Error("drop legacy index failed", zap.String("index", index.name), zap.Error(err))
+		}
+	}
 }
@@ -0,0 +1,17 @@
+package bootstrap
+
+import (
+	"gin-web/app/mailer"
+	"gin-web/global"
+	"time"
+)
+
+// InitializeMailer 按配置初始化邮件发送器，未配置 SMTP 时只写日志
+func InitializeMailer() mailer.Mailer {
+	conf := global.App.Config.Mail
+	if conf.Driver == "smtp" && conf.Host != "" {
+		return mailer.NewSmtpMailer(conf.Host, conf.Port, conf.Username, conf.Password, conf.From, conf.FromName,
+			time.Duration(conf.Timeout)*time.Second)
+	}
+	return mailer.NewLogMailer(global.App.Log)
+}
@@ -1,146 +1,220 @@
 package bootstrap
 
 import (
+	"context"
 	"errors"
+	"gin-web/app/ampq/broker"
 	"gin-web/app/ampq/consumer"
 	"gin-web/config"
 	"gin-web/global"
-	"github.com/streadway/amqp"
-	"log"
-	//"gin-web"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultConsumerPrefetch  = 120
+	defaultReconnectInterval = 5
+	defaultRetryTimes        = 3
+	defaultRetryDelay        = 5
+	defaultMaxRetryDelay     = 300
+	// 转发重试或死信消息失败时，退回原队列前的等待时间，避免热循环
+	requeueBackoff = time.Second
+	// 死信消息中记录的错误信息最大长度
+	maxErrorHeaderLength = 1000
 )
 
+// InitializeBroker 按配置初始化消息中间件，memory 仅用于本地开发和测试
+func InitializeBroker() broker.Broker {
+	switch global.App.Config.RabbitMQ.Driver {
+	case "memory":
+		return broker.NewMemoryBroker()
+	default:
+		return broker.NewAmqpBroker(global.App.Config.RabbitMQ, global.App.Log)
+	}
+}
+
 type Consumer struct {
-	queueName string
-	handler   consumer.ConsumerHandler
-	conn      *amqp.Connection
-	done      chan struct{}
+	broker            broker.Broker
+	queueName         string
+	prefetch          int
+	handler           consumer.ConsumerHandler
+	reconnectInterval time.Duration
+	retryTimes        int
+	retryDelay        time.Duration
+	maxRetryDelay     time.Duration
 }
 
-func NewConsumer(conn *amqp.Connection, queueName string, handler consumer.ConsumerHandler) *Consumer {
+func NewConsumer(b broker.Broker, cfg config.ConsumerConfig, handler consumer.ConsumerHandler, reconnectInterval time.Duration) *Consumer {
+	prefetch := cfg.Prefetch
+	if prefetch <= 0 {
+		prefetch = defaultConsumerPrefetch
+	}
+	retryTimes := cfg.RetryTimes
+	if retryTimes == 0 {
+		retryTimes = defaultRetryTimes
+	} else if retryTimes < 0 {
+		retryTimes = 0
+	}
+	retryDelay := cfg.RetryDelay
+	if retryDelay <= 0 {
+		retryDelay = defaultRetryDelay
+	}
+	maxRetryDelay := cfg.MaxRetryDelay
+	if maxRetryDelay <= 0 {
+		maxRetryDelay = defaultMaxRetryDelay
+	}
 	return &Consumer{
-		queueName: queueName,
-		handler:   handler,
-		conn:      conn,
-		done:      make(chan struct{}),
+		broker:            b,
+		queueName:         cfg.Queue,
+		prefetch:          prefetch,
+		handler:           handler,
+		reconnectInterval: reconnectInterval,
+		retryTimes:        retryTimes,
+		retryDelay:        time.Duration(retryDelay) * time.Second,
+		maxRetryDelay:     time.Duration(maxRetryDelay) * time.Second,
 	}
 }
 
-func (c *Consumer) Start() {
+// Start 持续消费，订阅失败或连接断开后按间隔重新订阅，直到 ctx 取消
+func (c *Consumer) Start(ctx context.Context) {
 	for {
+		if err := c.consume(ctx); err != nil {
+			global.App.Log.Error("consumer subscribe failed", zap.String("queue", c.queueName), zap.Error(err))
+		}
 		select {
-		case <-c.done:
+		case <-ctx.Done():
 			return
-		default:
-			err := c.consume()
-			if err != nil {
-				// 自动重试逻辑
-				continue
-			}
+		case <-time.After(c.reconnectInterval):
 		}
 	}
 }
 
-func (c *Consumer) consume() error {
-	ch, err := c.conn.Channel()
+func (c *Consumer) consume(ctx context.Context) error {
+	msgs, err := c.broker.Subscribe(ctx, c.queueName, broker.SubscribeOptions{Prefetch: c.prefetch})
 	if err != nil {
 		return err
 	}
-	defer ch.Close()
-
-	// 设置预取值为 5（每次最多处理 5 条未确认消息）
-	err = ch.Qos(
-		120,   // prefetchCount（预取值）
-		0,     // prefetchSize（预取大小，通常为 0）
-		false, // global（是否全局应用）
-	)
-	if err != nil {
-		log.Fatalf("设置预取值失败: %v", err)
+	// 停止消费时不中断处理中的消息
+	handleCtx := context.WithoutCancel(ctx)
+	for msg := range msgs {
+		if err := c.handler.HandleMessage(handleCtx, msg); err != nil {
+			c.handleFailure(msg, err)
+		} else {
+			c.broker.Ack(msg)
+		}
 	}
+	return nil
+}
 
-	q, err := ch.QueueDeclare(
-		c.queueName,
-		true,  // durable
-		false, // autoDelete
-		false, // exclusive
-		false, // noWait
-		nil,   // args
-	)
-	if err != nil {
-		return err
+// backoff 第 n 次重试的延迟，按指数增长且不超过上限
+func (c *Consumer) backoff(retryCount int) time.Duration {
+	delay := c.retryDelay
+	for i := 0; i < retryCount && delay < c.maxRetryDelay; i++ {
+		delay *= 2
 	}
-
-	msgs, err := ch.Consume(
-		q.Name,
-		"",    // consumer
-		false, // autoAck
-		false, // exclusive
-		false, // noLocal
-		false, // noWait
-		nil,   // args
-	)
-	if err != nil {
-		return err
+	if delay > c.maxRetryDelay {
+		delay = c.maxRetryDelay
 	}
+	return delay
+}
 
-	for {
-		select {
-		case msg, ok := <-msgs:
-			if !ok {
-				return errors.New("message channel closed")
-			}
-			if err := c.handler.HandleMessage(msg); err != nil {
-				msg.Nack(false, true)
-			} else {
-				msg.Ack(false)
-			}
-		case <-c.done:
-			return nil
-		}
+// handleFailure 处理失败时延迟重试，重试次数用尽或消息无法解码时转入死信队列
+// 消息正由其他消费者处理时只延迟重新投递，不增加重试次数
+// 转发成功后才确认原消息，转发失败则稍后退回原队列，保证消息不丢失
+func (c *Consumer) handleFailure(msg *broker.Message, handleErr error) {
+	retryCount := msg.RetryCount()
+	failed := broker.Message{
+		ID:          msg.ID,
+		ContentType: msg.ContentType,
+		Headers:     make(map[string]interface{}, len(msg.Headers)+4),
+		Body:        msg.Body,
+		Timestamp:   msg.Timestamp,
+	}
+	for k, v := range msg.Headers {
+		failed.Headers[k] = v
+	}
+	errMsg := handleErr.Error()
+	if len(errMsg) > maxErrorHeaderLength {
+		errMsg = errMsg[:maxErrorHeaderLength]
+	}
+	failed.Headers[broker.HeaderLastError] = errMsg
+
+	ctx := context.Background()
+	var err error
+	if errors.Is(handleErr, consumer.ErrMessageInProgress) {
+		// 其他消费者正在处理，原样延迟投递，不占用重试次数
+		err = c.broker.PublishDelayed(ctx, c.queueName, failed, c.retryDelay)
+		global.App.Log.Info("consumer message in progress, redelivery scheduled",
+			zap.String("queue", c.queueName),
+			zap.String("message_id", msg.ID),
+			zap.Duration("delay", c.retryDelay),
+		)
+	} else if retryCount < c.retryTimes && !errors.Is(handleErr, consumer.ErrUndecodable) {
+		failed.Headers[broker.HeaderRetryCount] = retryCount + 1
+		delay := c.backoff(retryCount)
+		err = c.broker.PublishDelayed(ctx, c.queueName, failed, delay)
+		global.App.Log.Warn("consumer message failed, retry scheduled",
+			zap.String("queue", c.queueName),
+			zap.String("message_id", msg.ID),
+			zap.Int("retry", retryCount+1),
+			zap.Int("retry_times", c.retryTimes),
+			zap.Duration("delay", delay),
+			zap.Error(handleErr),
+		)
+	} else {
+		failed.Headers[broker.HeaderOriginalQueue] = c.queueName
+		failed.Headers[broker.HeaderFailedAt] = time.Now().Unix()
+		err = c.broker.Publish(ctx, broker.DeadLetterQueue(c.queueName), failed)
+		global.App.Log.Error("consumer message moved to dead letter queue",
+			zap.String("queue", c.queueName),
+			zap.String("message_id", msg.ID),
+			zap.Int("retries", retryCount),
+			zap.Error(handleErr),
+		)
 	}
-}
 
-func (c *Consumer) Stop() {
-	close(c.done)
+	if err != nil {
+		global.App.Log.Error("consumer forward failed message failed, requeue",
+			zap.String("queue", c.queueName),
+			zap.String("message_id", msg.ID),
+			zap.Error(err),
+		)
+		time.Sleep(requeueBackoff)
+		c.broker.Nack(msg, true)
+		return
+	}
+	c.broker.Ack(msg)
 }
 
-func InitRabbitmq() {
+// InitRabbitmq 按 consumer.yaml 启动消费者，rabbitmq.consumer_enable_start 关闭时返回 nil
+// 服务关闭时需调用返回值的 Stop，等待处理中的消息完成
+func InitRabbitmq() *ConsumerManager {
+	if !global.App.Config.RabbitMQ.ConsumerEnableStart {
+		return nil
+	}
 
 	// 加载配置
-	cfgConsumer, err := config.LoadConfig("./config/yaml/consumer.yaml")
+	cfgConsumer, err := config.LoadConfig(config.ConsumerConfigPath)
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		global.App.Log.Error("load consumer config failed", zap.String("path", config.ConsumerConfigPath), zap.Error(err))
+		return nil
 	}
 
 	// 注册消费者处理器
 	handlers := map[string]consumer.ConsumerHandler{
-		"LogConsumer": &consumer.LogConsumer{},
+		"LogConsumer":          consumer.NewLogConsumer(),
+		"NotificationConsumer": consumer.NewNotificationConsumer(),
 		//"PaymentConsumer": &consumer.PaymentConsumer{},
 	}
 
-	cfg := config.RabbitMQConfig{
-		Host:     global.App.Config.RabbitMQ.Host,
-		Port:     global.App.Config.RabbitMQ.Port,
-		Username: global.App.Config.RabbitMQ.Username,
-		Password: global.App.Config.RabbitMQ.Password,
-		Vhost:    global.App.Config.RabbitMQ.Vhost,
+	reconnectInterval := global.App.Config.RabbitMQ.ReconnectInterval
+	if reconnectInterval <= 0 {
+		reconnectInterval = defaultReconnectInterval
 	}
 
-	appCfg := &config.AppConfig{
-		Consumers: cfgConsumer.Consumers,
-		RabbitMQ:  cfg,
-	}
 	// 创建消费者管理器
-	cm := NewConsumerManager(appCfg, handlers)
-	go cm.Start()
-
-	// 初始化Gin路由（可选）
-	// r := gin.Default()
-	// r.GET("/health", func(c *gin.Context) {
-	//     c.JSON(200, gin.H{"status": "ok"})
-	// })
-	// r.Run()
-
-	// 保持主程序运行
-	select {}
+	cm := NewConsumerManager(global.App.Broker, cfgConsumer, handlers, time.Duration(reconnectInterval)*time.Second)
+	cm.Start()
+	return cm
 }
@@ -2,121 +2,139 @@
 package bootstrap
 
 import (
-	"fmt"
+	"context"
+	"gin-web/app/ampq/broker"
 	"gin-web/app/ampq/consumer"
 	"gin-web/config"
-	"github.com/streadway/amqp"
-	"log"
+	"gin-web/global"
 	"sync"
 	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultConsumerTimeout = 60
+	defaultDedupTTL        = 24 * 3600
 )
 
+// defaultConsumerMiddlewares consumer.yaml 未配置全局中间件时使用
+var defaultConsumerMiddlewares = []string{"trace", "logging", "metrics", "idempotency", "recovery", "timeout"}
+
+// MiddlewareFactory 按消费者配置和注册的 handler 创建中间件
+type MiddlewareFactory func(cfg config.ConsumerConfig, handler consumer.ConsumerHandler) consumer.Middleware
+
 type ConsumerManager struct {
-	cfg            *config.AppConfig
-	handlers       map[string]consumer.ConsumerHandler
-	conn           *amqp.Connection
-	consumers      []*Consumer
-	wg             sync.WaitGroup
-	done           chan struct{}
-	reconnectTimer *time.Ticker
+	broker            broker.Broker
+	consumerCfgs      []config.ConsumerConfig
+	middlewares       []string
+	handlers          map[string]consumer.ConsumerHandler
+	factories         map[string]MiddlewareFactory
+	reconnectInterval time.Duration
+	consumers         []*Consumer
+	wg                sync.WaitGroup
+	ctx               context.Context
+	cancel            context.CancelFunc
 }
 
-func NewConsumerManager(cfg *config.AppConfig, handlers map[string]consumer.ConsumerHandler) *ConsumerManager {
+func NewConsumerManager(b broker.Broker, cfg *config.AppConfig, handlers map[string]consumer.ConsumerHandler, reconnectInterval time.Duration) *ConsumerManager {
+	ctx, cancel := context.WithCancel(context.Background())
+	middlewares := cfg.Middlewares
+	if middlewares == nil {
+		middlewares = defaultConsumerMiddlewares
+	}
 	return &ConsumerManager{
-		cfg:      cfg,
-		handlers: handlers,
-		done:     make(chan struct{}),
+		broker:       b,
+		consumerCfgs: cfg.Consumers,
+		middlewares:  middlewares,
+		handlers:     handlers,
+		factories: map[string]MiddlewareFactory{
+			"recovery": func(config.ConsumerConfig, consumer.ConsumerHandler) consumer.Middleware { return consumer.Recovery() },
+			"trace":    func(config.ConsumerConfig, consumer.ConsumerHandler) consumer.Middleware { return consumer.Trace() },
+			"logging":  func(config.ConsumerConfig, consumer.ConsumerHandler) consumer.Middleware { return consumer.Logging() },
+			"metrics":  func(config.ConsumerConfig, consumer.ConsumerHandler) consumer.Middleware { return consumer.Metrics() },
+			"timeout": func(cfg config.ConsumerConfig, _ consumer.ConsumerHandler) consumer.Middleware {
+				return consumer.Timeout(consumerTimeout(cfg))
+			},
+			"idempotency": func(cfg config.ConsumerConfig, handler consumer.ConsumerHandler) consumer.Middleware {
+				ttl := cfg.DedupTTL
+				if ttl <= 0 {
+					ttl = defaultDedupTTL
+				}
+				opts := consumer.IdempotencyOptions{
+					TTL: time.Duration(ttl) * time.Second,
+					// 处理中标记需比处理超时更久，避免处理未结束时标记过期
+					ProcessingTTL: 2 * consumerTimeout(cfg),
+				}
+				if keyer, ok := handler.(consumer.IdempotencyKeyer); ok {
+					opts.Key = keyer.IdempotencyKey
+				}
+				return consumer.Idempotency(opts)
+			},
+		},
+		reconnectInterval: reconnectInterval,
+		ctx:               ctx,
+		cancel:            cancel,
 	}
 }
 
-func (cm *ConsumerManager) Start() {
-	for {
-		select {
-		case <-cm.done:
-			return
-		default:
-			if err := cm.connect(); err != nil {
-				log.Printf("Connection failed: %v", err)
-				cm.reconnect()
-				continue
-			}
-
-			cm.startConsumers()
-			cm.monitorConnection()
-			return
-		}
+func consumerTimeout(cfg config.ConsumerConfig) time.Duration {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultConsumerTimeout
 	}
+	return time.Duration(timeout) * time.Second
+}
+
+// RegisterMiddleware 注册自定义中间件，之后可在 consumer.yaml 中按名称引用，需在 Start 前调用
+func (cm *ConsumerManager) RegisterMiddleware(name string, factory MiddlewareFactory) {
+	cm.factories[name] = factory
 }
 
-func (cm *ConsumerManager) connect() error {
-	conn, err := amqp.Dial(fmt.Sprintf("amqp://%s:%s@%s:%d/%s",
-		cm.cfg.RabbitMQ.Username,
-		cm.cfg.RabbitMQ.Password,
-		cm.cfg.RabbitMQ.Host,
-		cm.cfg.RabbitMQ.Port,
-		cm.cfg.RabbitMQ.Vhost,
-	))
-	if err != nil {
-		return err
+// wrap 按消费者配置（未配置时使用全局配置）组合中间件
+func (cm *ConsumerManager) wrap(cfg config.ConsumerConfig, handler consumer.ConsumerHandler) consumer.ConsumerHandler {
+	names := cfg.Middlewares
+	if names == nil {
+		names = cm.middlewares
+	}
+	middlewares := make([]consumer.Middleware, 0, len(names))
+	for _, name := range names {
+		factory, ok := cm.factories[name]
+		if !ok {
+			global.App.Log.Error("consumer middleware not registered", zap.String("queue", cfg.Queue), zap.String("middleware", name))
+			continue
+		}
+		middlewares = append(middlewares, factory(cfg, handler))
 	}
-	cm.conn = conn
-	return nil
+	return consumer.Chain(handler, middlewares...)
 }
 
-func (cm *ConsumerManager) startConsumers() {
-	for _, consumerCfg := range cm.cfg.Consumers {
+// Start 按配置启动全部消费者，每个消费者自行处理断线重连
+func (cm *ConsumerManager) Start() {
+	for _, consumerCfg := range cm.consumerCfgs {
 		handler, ok := cm.handlers[consumerCfg.Handler]
 		if !ok {
-			log.Printf("Handler %s not registered", consumerCfg.Handler)
+			global.App.Log.Error("consumer handler not registered", zap.String("queue", consumerCfg.Queue), zap.String("handler", consumerCfg.Handler))
 			continue
 		}
+		handler = cm.wrap(consumerCfg, handler)
 
 		for i := 0; i < consumerCfg.Concurrency; i++ {
-			log.Printf("Starting consumer"+consumerCfg.Handler, i)
-			consumer := NewConsumer(cm.conn, consumerCfg.Queue, handler)
+			global.App.Log.Info("starting consumer", zap.String("queue", consumerCfg.Queue), zap.String("handler", consumerCfg.Handler), zap.Int("index", i))
+			consumer := NewConsumer(cm.broker, consumerCfg, handler, cm.reconnectInterval)
 			cm.consumers = append(cm.consumers, consumer)
 			cm.wg.Add(1)
 			go func(c *Consumer) {
 				defer cm.wg.Done()
-				c.Start()
+				c.Start(cm.ctx)
 			}(consumer)
 		}
 	}
 }
 
-func (cm *ConsumerManager) monitorConnection() {
-	closeChan := make(chan *amqp.Error)
-	cm.conn.NotifyClose(closeChan)
-
-	select {
-	case err := <-closeChan:
-		log.Printf("Connection closed: %v", err)
-		cm.reconnect()
-	case <-cm.done:
-		return
-	}
-}
-
-func (cm *ConsumerManager) reconnect() {
-	cm.stopConsumers()
-	interval := time.Duration(cm.cfg.RabbitMQ.ReconnectInterval) * time.Second
-	log.Printf("Reconnecting in %v...", interval)
-	time.Sleep(interval)
-	cm.Start()
-}
-
-func (cm *ConsumerManager) stopConsumers() {
-	for _, consumer := range cm.consumers {
-		consumer.Stop()
-	}
+// Stop 停止全部消费者并等待处理中的消息完成
+func (cm *ConsumerManager) Stop() {
+	cm.cancel()
 	cm.wg.Wait()
 	cm.consumers = nil
 }
-
-func (cm *ConsumerManager) Stop() {
-	close(cm.done)
-	cm.stopConsumers()
-	if cm.conn != nil {
-		cm.conn.Close()
-	}
-}
@@ -0,0 +1,175 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gin-web/app/ampq/broker"
+	"gin-web/app/ampq/consumer"
+	"gin-web/global"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const testQueue = "test"
+
+// startTestConsumer 在 MemoryBroker 上启动消费者，重试延迟缩短到毫秒级
+func startTestConsumer(t *testing.T, handler consumer.HandlerFunc) *broker.MemoryBroker {
+	previous := global.App.Log
+	global.App.Log = zap.NewNop()
+
+	b := broker.NewMemoryBroker()
+	c := &Consumer{
+		broker:            b,
+		queueName:         testQueue,
+		handler:           handler,
+		reconnectInterval: time.Millisecond,
+		retryTimes:        2,
+		retryDelay:        time.Millisecond,
+		maxRetryDelay:     10 * time.Millisecond,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.Start(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		b.Close()
+		<-done
+		global.App.Log = previous
+	})
+	return b
+}
+
+// getDeadLetter 在超时前从死信队列取出一条消息
+func getDeadLetter(t *testing.T, b *broker.MemoryBroker) *broker.Message {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		msg, err := b.Get(context.Background(), broker.DeadLetterQueue(testQueue))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if msg != nil {
+			b.Ack(msg)
+			return msg
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("no dead letter message")
+	return nil
+}
+
+// attemptRecorder 记录每次处理时消息的重试次数
+type attemptRecorder struct {
+	mu      sync.Mutex
+	retries []int
+}
+
+func (r *attemptRecorder) record(msg *broker.Message) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.retries = append(r.retries, msg.RetryCount())
+	return len(r.retries)
+}
+
+func (r *attemptRecorder) snapshot() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]int(nil), r.retries...)
+}
+
+func TestConsumerRetryThenDeadLetter(t *testing.T) {
+	attempts := &attemptRecorder{}
+	b := startTestConsumer(t, func(ctx context.Context, msg *broker.Message) error {
+		return fmt.Errorf("attempt %d failed", attempts.record(msg))
+	})
+	b.Publish(context.Background(), testQueue, broker.Message{ID: "1", Body: []byte("body")})
+
+	dead := getDeadLetter(t, b)
+	if dead.ID != "1" || string(dead.Body) != "body" {
+		t.Fatalf("unexpected dead letter %+v", dead)
+	}
+	if dead.RetryCount() != 2 {
+		t.Fatalf("retry count = %d, want 2", dead.RetryCount())
+	}
+	if dead.HeaderString(broker.HeaderOriginalQueue) != testQueue ||
+		dead.HeaderString(broker.HeaderLastError) != "attempt 3 failed" ||
+		dead.HeaderInt(broker.HeaderFailedAt) == 0 {
+		t.Fatalf("unexpected dead letter headers %v", dead.Headers)
+	}
+	if got := attempts.snapshot(); fmt.Sprint(got) != "[0 1 2]" {
+		t.Fatalf("attempt retry counts = %v, want [0 1 2]", got)
+	}
+}
+
+func TestConsumerRetrySucceeds(t *testing.T) {
+	attempts := &attemptRecorder{}
+	handled := make(chan struct{})
+	b := startTestConsumer(t, func(ctx context.Context, msg *broker.Message) error {
+		if attempts.record(msg) == 1 {
+			return errors.New("temporary")
+		}
+		close(handled)
+		return nil
+	})
+	b.Publish(context.Background(), testQueue, broker.Message{ID: "1"})
+
+	select {
+	case <-handled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("message not retried")
+	}
+	if dead, _ := b.Get(context.Background(), broker.DeadLetterQueue(testQueue)); dead != nil {
+		t.Fatalf("unexpected dead letter %+v", dead)
+	}
+}
+
+func TestConsumerUndecodableSkipsRetry(t *testing.T) {
+	attempts := &attemptRecorder{}
+	b := startTestConsumer(t, func(ctx context.Context, msg *broker.Message) error {
+		attempts.record(msg)
+		return fmt.Errorf("%w: bad json", consumer.ErrUndecodable)
+	})
+	b.Publish(context.Background(), testQueue, broker.Message{ID: "1"})
+
+	if dead := getDeadLetter(t, b); dead.RetryCount() != 0 {
+		t.Fatalf("retry count = %d, want 0", dead.RetryCount())
+	}
+	if got := attempts.snapshot(); len(got) != 1 {
+		t.Fatalf("handled %d times, want 1", len(got))
+	}
+}
+
+func TestConsumerInProgressKeepsRetries(t *testing.T) {
+	attempts := &attemptRecorder{}
+	handled := make(chan struct{})
+	b := startTestConsumer(t, func(ctx context.Context, msg *broker.Message) error {
+		// 超过 retryTimes 次处于处理中，仍不进入死信队列
+		if attempts.record(msg) <= 5 {
+			return consumer.ErrMessageInProgress
+		}
+		close(handled)
+		return nil
+	})
+	b.Publish(context.Background(), testQueue, broker.Message{ID: "1"})
+
+	select {
+	case <-handled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("message not redelivered")
+	}
+	for _, retry := range attempts.snapshot() {
+		if retry != 0 {
+			t.Fatalf("attempt retry counts = %v, want all 0", attempts.snapshot())
+		}
+	}
+	if dead, _ := b.Get(context.Background(), broker.DeadLetterQueue(testQueue)); dead != nil {
+		t.Fatalf("unexpected dead letter %+v", dead)
+	}
+}
@@ -2,10 +2,12 @@ package bootstrap
 
 import (
 	"context"
+	app "gin-web/app/controllers"
 	"gin-web/app/middleware"
 	"gin-web/global"
 	"gin-web/routes"
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 	"log"
 	"net/http"
 	"os"
@@ -20,10 +22,18 @@ func setupRouter() *gin.Engine {
 		gin.SetMode(gin.ReleaseMode)
 	}
 	router := gin.New()
+	// 只采信可信代理转发的 X-Forwarded-For，否则 ClientIP 可被客户端伪造，绕过或滥用按 IP 的登录限流
+	if err := router.SetTrustedProxies(global.App.Config.App.TrustedProxies); err != nil {
+		global.App.Log.Error("invalid trusted proxies", zap.Strings("trusted_proxies", global.App.Config.App.TrustedProxies), zap.Error(err))
+		router.SetTrustedProxies(nil)
+	}
 	router.Use(gin.Logger(), middleware.CustomRecovery())
 	router.Use(middleware.Cors())
 	//router := gin.Default()
 
+	// 公开 JWT 验签公钥
+	router.GET("/.well-known/jwks.json", app.Jwks)
+
 	// 注册 api 分组路由
 	apiGroup := router.Group("/api")
 	routes.SetApiGroupRoutes(apiGroup)
@@ -34,7 +44,6 @@ func setupRouter() *gin.Engine {
 // RunServer 启动服务器
 func RunServer() {
 	r := setupRouter()
-	r.Run(":" + global.App.Config.App.Port)
 
 	srv := &http.Server{
 		Addr:    ":" + global.App.Config.App.Port,
@@ -48,7 +57,7 @@ func RunServer() {
 	}()
 
 	// 等待中断信号以优雅地关闭服务器（设置 5 秒的超时时间）
-	quit := make(chan os.Signal)
+	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("Shutdown Server ...")
@@ -0,0 +1,43 @@
+package bootstrap
+
+import (
+	"gin-web/app/services"
+	"gin-web/global"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// InitializeSchedule 启动定时任务
+func InitializeSchedule() {
+	// JWT 签名密钥：启动时先加载，之后定时检查轮换并同步其他实例生成的密钥
+	services.JwtKeyService.Rotate()
+	go runEvery("jwt_key_rotate", time.Minute, services.JwtKeyService.Rotate)
+	// mod 浏览、下载计数回写
+	go runEvery("mod_counter_flush", services.CounterService.FlushInterval(), services.CounterService.Flush)
+	// mod 浏览、下载时间序列统计回写
+	go runEvery("mod_stats_flush", services.CounterService.FlushInterval(), services.StatService.Flush)
+	// 相关 mod 离线计算
+	go runEvery("mod_recommend_compute", services.RecommendService.Interval(), services.RecommendService.Compute)
+	// 清除注销冷静期已过的账号
+	go runEvery("account_purge", time.Hour, services.AccountService.PurgeDeleted)
+	// 投递 outbox 中的领域事件，并清理已投递的旧消息
+	go runEvery("outbox_relay", services.OutboxService.RelayInterval(), services.OutboxService.Relay)
+	go runEvery("outbox_cleanup", time.Hour, services.OutboxService.Cleanup)
+}
+
+// runEvery 按固定间隔执行任务，单次执行 panic 不影响后续调度
+func runEvery(name string, interval time.Duration, task func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					global.App.Log.Error("schedule task panic", zap.String("task", name), zap.Any("err", r))
+				}
+			}()
+			task()
+		}()
+	}
+}
@@ -0,0 +1,43 @@
+package bootstrap
+
+import (
+	"gin-web/app/models"
+	"gin-web/global"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// seedRoles 同步内置角色和权限，已存在的只更新名称和权限关联
+func seedRoles(db *gorm.DB) {
+	permissions := make(map[string]models.Permission, len(models.DefaultPermissions))
+	for name, displayName := range models.DefaultPermissions {
+		permission := models.Permission{Name: name}
+		if err := db.Where(models.Permission{Name: name}).
+			Assign(models.Permission{DisplayName: displayName}).
+			FirstOrCreate(&permission).Error; err != nil {
+			global.App.Log.Error("seed permission failed", zap.String("permission", name), zap.Error(err))
+			return
+		}
+		permissions[name] = permission
+	}
+
+	for _, defaultRole := range models.DefaultRoles {
+		role := models.Role{Name: defaultRole.Name}
+		if err := db.Where(models.Role{Name: defaultRole.Name}).
+			Assign(models.Role{DisplayName: defaultRole.DisplayName}).
+			FirstOrCreate(&role).Error; err != nil {
+			global.App.Log.Error("seed role failed", zap.String("role", defaultRole.Name), zap.Error(err))
+			return
+		}
+
+		rolePermissions := make([]models.Permission, 0, len(defaultRole.Permissions))
+		for _, name := range defaultRole.Permissions {
+			rolePermissions = append(rolePermissions, permissions[name])
+		}
+		if err := db.Model(&role).Association("Permissions").Replace(rolePermissions); err != nil {
+			global.App.Log.Error("seed role permissions failed", zap.String("role", defaultRole.Name), zap.Error(err))
+			return
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package bootstrap
+
+import (
+	"gin-web/app/sms"
+	"gin-web/global"
+)
+
+// InitializeSms 按配置初始化短信发送器，目前只有写日志的 console 实现
+func InitializeSms() sms.Sender {
+	switch global.App.Config.Sms.Driver {
+	default:
+		return sms.NewConsoleSender(global.App.Log)
+	}
+}
@@ -1,6 +1,7 @@
 package bootstrap
 
 import (
+	"gin-web/global"
 	"gin-web/utils"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
@@ -13,6 +14,10 @@ func InitializeValidator() {
 		// 注册自定义验证器
 		_ = v.RegisterValidation("mobile", utils.ValidateMobile)
 		_ = v.RegisterValidation("email", utils.ValidateEmail)
+		// 密码强度策略读取运行时配置
+		_ = v.RegisterValidation("password", func(fl validator.FieldLevel) bool {
+			return utils.CheckPasswordPolicy(fl.Field().String(), global.App.Config.Password) == nil
+		})
 
 		// 注册自定义 json tag 函数
 		v.RegisterTagNameFunc(func(fld reflect.StructField) string {
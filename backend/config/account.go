@@ -0,0 +1,5 @@
+package config
+
+type Account struct {
+	DeletionGracePeriod int64 `mapstructure:"deletion_grace_period" json:"deletion_grace_period" yaml:"deletion_grace_period"` // 申请注销后的冷静期（秒），期间可撤销，到期后清除账号数据
+}
@@ -1,8 +1,9 @@
 package config
 
 type App struct {
-	Env     string `mapstructure:"env" json:"env" yaml:"env"`
-	Port    string `mapstructure:"port" json:"port" yaml:"port"`
-	AppName string `mapstructure:"app_name" json:"app_name" yaml:"app_name"`
-	AppUrl  string `mapstructure:"app_url" json:"app_url" yaml:"app_url"`
+	Env            string   `mapstructure:"env" json:"env" yaml:"env"`
+	Port           string   `mapstructure:"port" json:"port" yaml:"port"`
+	AppName        string   `mapstructure:"app_name" json:"app_name" yaml:"app_name"`
+	AppUrl         string   `mapstructure:"app_url" json:"app_url" yaml:"app_url"`
+	TrustedProxies []string `mapstructure:"trusted_proxies" json:"trusted_proxies" yaml:"trusted_proxies"` // 可信反向代理 IP/CIDR，只有来自这些地址的 X-Forwarded-For 才会被采信
 }
@@ -1,11 +1,22 @@
 package config
 
 type Configuration struct {
-	App      App            `mapstructure:"app" json:"app" yaml:"app"`
-	Log      Log            `mapstructure:"log" json:"log" yaml:"log"`
-	Database Database       `mapstructure:"database" json:"database" yaml:"database"`
-	Jwt      Jwt            `mapstructure:"jwt" json:"jwt" yaml:"jwt"`
-	Redis    Redis          `mapstructure:"redis" json:"redis" yaml:"redis"`
-	RabbitMQ RabbitMQ       `mapstructure:"rabbitmq" json:"rabbitMQ" yaml:"rabbitMQ"`
-	ApiUrls  map[string]any `yaml:"api_url"`
+	App           App            `mapstructure:"app" json:"app" yaml:"app"`
+	Log           Log            `mapstructure:"log" json:"log" yaml:"log"`
+	Database      Database       `mapstructure:"database" json:"database" yaml:"database"`
+	Jwt           Jwt            `mapstructure:"jwt" json:"jwt" yaml:"jwt"`
+	Redis         Redis          `mapstructure:"redis" json:"redis" yaml:"redis"`
+	RabbitMQ      RabbitMQ       `mapstructure:"rabbitmq" json:"rabbitMQ" yaml:"rabbitMQ"`
+	Counter       Counter        `mapstructure:"counter" json:"counter" yaml:"counter"`
+	Stats         Stats          `mapstructure:"stats" json:"stats" yaml:"stats"`
+	Recommend     Recommend      `mapstructure:"recommend" json:"recommend" yaml:"recommend"`
+	Oauth         Oauth          `mapstructure:"oauth" json:"oauth" yaml:"oauth"`
+	Mail          Mail           `mapstructure:"mail" json:"mail" yaml:"mail"`
+	Sms           Sms            `mapstructure:"sms" json:"sms" yaml:"sms"`
+	Mfa           Mfa            `mapstructure:"mfa" json:"mfa" yaml:"mfa"`
+	LoginThrottle LoginThrottle  `mapstructure:"login_throttle" json:"login_throttle" yaml:"login_throttle"`
+	Password      Password       `mapstructure:"password" json:"password" yaml:"password"`
+	Account       Account        `mapstructure:"account" json:"account" yaml:"account"`
+	Outbox        Outbox         `mapstructure:"outbox" json:"outbox" yaml:"outbox"`
+	ApiUrls       map[string]any `yaml:"api_url"`
 }
@@ -0,0 +1,7 @@
+package config
+
+type Counter struct {
+	DedupWindow   int64 `mapstructure:"dedup_window" json:"dedup_window" yaml:"dedup_window"`       // 同一用户/IP 重复计数的去重窗口（秒）
+	FlushInterval int64 `mapstructure:"flush_interval" json:"flush_interval" yaml:"flush_interval"` // Redis 计数回写 MySQL 的间隔（秒）
+	BatchSize     int64 `mapstructure:"batch_size" json:"batch_size" yaml:"batch_size"`             // 每批回写的 mod 数量
+}
@@ -1,8 +1,17 @@
 package config
 
 type Jwt struct {
-	Secret                  string `mapstructure:"secret" json:"secret" yaml:"secret"`
-	JwtTtl                  int64  `mapstructure:"jwt_ttl" json:"jwt_ttl" yaml:"jwt_ttl"`                                                          // token 有效期（秒）
-	JwtBlacklistGracePeriod int64  `mapstructure:"jwt_blacklist_grace_period" json:"jwt_blacklist_grace_period" yaml:"jwt_blacklist_grace_period"` // 黑名单宽限时间（秒）
-	RefreshGracePeriod      int64  `mapstructure:"refresh_grace_period" json:"refresh_grace_period" yaml:"refresh_grace_period"`                   // token 自动刷新宽限时间（秒）
+	Secret                  string              `mapstructure:"secret" json:"secret" yaml:"secret"`
+	JwtTtl                  int64               `mapstructure:"jwt_ttl" json:"jwt_ttl" yaml:"jwt_ttl"`                                                          // access token 有效期（秒）
+	JwtBlacklistGracePeriod int64               `mapstructure:"jwt_blacklist_grace_period" json:"jwt_blacklist_grace_period" yaml:"jwt_blacklist_grace_period"` // 黑名单宽限时间（秒）
+	RefreshTtl              int64               `mapstructure:"refresh_ttl" json:"refresh_ttl" yaml:"refresh_ttl"`                                              // refresh token 有效期（秒）
+	SigningMethod           string              `mapstructure:"signing_method" json:"signing_method" yaml:"signing_method"`                                     // 签名算法：HS256（使用 secret）、RS256、EdDSA
+	KeyRotationInterval     int64               `mapstructure:"key_rotation_interval" json:"key_rotation_interval" yaml:"key_rotation_interval"`                // 非对称密钥轮换间隔（秒）
+	Guards                  map[string]JwtGuard `mapstructure:"guards" json:"guards" yaml:"guards"`                                                             // 按 guard 覆盖 token 有效期
+}
+
+// JwtGuard 单个 guard 的 token 配置，未配置或为 0 时使用全局配置
+type JwtGuard struct {
+	JwtTtl     int64 `mapstructure:"jwt_ttl" json:"jwt_ttl" yaml:"jwt_ttl"`
+	RefreshTtl int64 `mapstructure:"refresh_ttl" json:"refresh_ttl" yaml:"refresh_ttl"`
 }
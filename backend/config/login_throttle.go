@@ -0,0 +1,10 @@
+package config
+
+type LoginThrottle struct {
+	MaxAccountFailures int64 `mapstructure:"max_account_failures" json:"max_account_failures" yaml:"max_account_failures"` // 同一账号在统计窗口内允许的失败次数，达到后锁定
+	MaxIpFailures      int64 `mapstructure:"max_ip_failures" json:"max_ip_failures" yaml:"max_ip_failures"`                // 同一 IP 在统计窗口内允许的失败次数，达到后锁定
+	FailureWindow      int64 `mapstructure:"failure_window" json:"failure_window" yaml:"failure_window"`                   // 失败次数统计窗口（秒）
+	LockoutDuration    int64 `mapstructure:"lockout_duration" json:"lockout_duration" yaml:"lockout_duration"`             // 锁定时长（秒）
+	DelayAfter         int64 `mapstructure:"delay_after" json:"delay_after" yaml:"delay_after"`                            // 连续失败几次后开始要求等待
+	MaxDelay           int64 `mapstructure:"max_delay" json:"max_delay" yaml:"max_delay"`                                  // 两次尝试之间的最长等待（秒），等待时间按失败次数翻倍
+}
@@ -0,0 +1,16 @@
+package config
+
+type Mail struct {
+	Driver    string `mapstructure:"driver" json:"driver" yaml:"driver"` // smtp、log（仅写日志，用于本地开发）
+	Host      string `mapstructure:"host" json:"host" yaml:"host"`
+	Port      int    `mapstructure:"port" json:"port" yaml:"port"` // 465 使用 SSL，其他端口在服务端支持时使用 STARTTLS
+	Username  string `mapstructure:"username" json:"username" yaml:"username"`
+	Password  string `mapstructure:"password" json:"password" yaml:"password"`
+	From      string `mapstructure:"from" json:"from" yaml:"from"`
+	FromName  string `mapstructure:"from_name" json:"from_name" yaml:"from_name"`
+	VerifyUrl string `mapstructure:"verify_url" json:"verify_url" yaml:"verify_url"` // 邮箱验证页面地址，token 以 query 参数拼接
+	ResetUrl  string `mapstructure:"reset_url" json:"reset_url" yaml:"reset_url"`    // 重置密码页面地址，token 以 query 参数拼接
+	VerifyTtl int64  `mapstructure:"verify_ttl" json:"verify_ttl" yaml:"verify_ttl"` // 邮箱验证链接有效期（秒）
+	ResetTtl  int64  `mapstructure:"reset_ttl" json:"reset_ttl" yaml:"reset_ttl"`    // 重置密码链接有效期（秒）
+	Timeout   int64  `mapstructure:"timeout" json:"timeout" yaml:"timeout"`          // 单封邮件发送超时（秒），默认 30
+}
@@ -0,0 +1,7 @@
+package config
+
+type Mfa struct {
+	Issuer       string   `mapstructure:"issuer" json:"issuer" yaml:"issuer"`                      // 验证器 App 中显示的签发方名称
+	EnforceRoles []string `mapstructure:"enforce_roles" json:"enforce_roles" yaml:"enforce_roles"` // 拥有这些角色的用户登录时必须完成两步验证绑定
+	ChallengeTtl int64    `mapstructure:"challenge_ttl" json:"challenge_ttl" yaml:"challenge_ttl"` // 登录第二步的有效期（秒）
+}
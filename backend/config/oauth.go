@@ -0,0 +1,17 @@
+package config
+
+type Oauth struct {
+	StateTtl     int64                    `mapstructure:"state_ttl" json:"state_ttl" yaml:"state_ttl"`             // 授权 state 有效期（秒）
+	CookieSecure bool                     `mapstructure:"cookie_secure" json:"cookie_secure" yaml:"cookie_secure"` // 浏览器绑定 cookie 是否仅通过 HTTPS 发送
+	Providers    map[string]OauthProvider `mapstructure:"providers" json:"providers" yaml:"providers"`             // key 为 provider 名称，用于路由 /auth/oauth/:provider
+}
+
+// OauthProvider OpenID Connect 身份提供方配置，issuer 需支持 /.well-known/openid-configuration 发现
+type OauthProvider struct {
+	DisplayName  string   `mapstructure:"display_name" json:"display_name" yaml:"display_name"`
+	Issuer       string   `mapstructure:"issuer" json:"issuer" yaml:"issuer"`
+	ClientID     string   `mapstructure:"client_id" json:"client_id" yaml:"client_id"`
+	ClientSecret string   `mapstructure:"client_secret" json:"client_secret" yaml:"client_secret"`
+	RedirectURL  string   `mapstructure:"redirect_url" json:"redirect_url" yaml:"redirect_url"` // 前端回调页面，拿到 code、state 后调用 callback 接口
+	Scopes       []string `mapstructure:"scopes" json:"scopes" yaml:"scopes"`                   // 默认 openid profile email
+}
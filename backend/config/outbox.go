@@ -0,0 +1,6 @@
+package config
+
+type Outbox struct {
+	RelayInterval int64 `mapstructure:"relay_interval" json:"relay_interval" yaml:"relay_interval"` // relay 扫描待投递消息的间隔（秒）
+	Retention     int64 `mapstructure:"retention" json:"retention" yaml:"retention"`                // 已投递消息的保留时长（秒），超出后定时清理
+}
@@ -0,0 +1,11 @@
+package config
+
+type Password struct {
+	Algorithm         string `mapstructure:"algorithm" json:"algorithm" yaml:"algorithm"`                            // bcrypt、argon2id，变更后旧哈希在用户下次登录时自动重新计算
+	BcryptCost        int    `mapstructure:"bcrypt_cost" json:"bcrypt_cost" yaml:"bcrypt_cost"`                      // bcrypt 计算成本（4-31）
+	Argon2Memory      uint32 `mapstructure:"argon2_memory" json:"argon2_memory" yaml:"argon2_memory"`                // argon2id 内存（KiB）
+	Argon2Iterations  uint32 `mapstructure:"argon2_iterations" json:"argon2_iterations" yaml:"argon2_iterations"`    // argon2id 迭代次数
+	Argon2Parallelism uint8  `mapstructure:"argon2_parallelism" json:"argon2_parallelism" yaml:"argon2_parallelism"` // argon2id 并行度
+	MinLength         int    `mapstructure:"min_length" json:"min_length" yaml:"min_length"`                         // 密码最小长度
+	MinCharClasses    int    `mapstructure:"min_char_classes" json:"min_char_classes" yaml:"min_char_classes"`       // 至少包含的字符类型数（小写、大写、数字、符号）
+}
@@ -6,31 +6,37 @@ import (
 )
 
 type RabbitMQ struct {
-	Host     string `mapstructure:"host" json:"host" yaml:"host"`
-	Port     int    `mapstructure:"port" json:"port" yaml:"port"`
-	Username string `mapstructure:"username" json:"username" yaml:"username"`
-	Password string `mapstructure:"password" json:"password" yaml:"password"`
-	Vhost    string `mapstructure:"vhost" json:"vhost" yaml:"vhost"`
+	Driver              string `mapstructure:"driver" json:"driver" yaml:"driver"`                                              // amqp、memory（进程内存，仅用于本地开发和测试）
+	ConsumerEnableStart bool   `mapstructure:"consumer_enable_start" json:"consumer_enable_start" yaml:"consumer_enable_start"` // 是否在本进程启动 consumer.yaml 中的消费者
+	Host                string `mapstructure:"host" json:"host" yaml:"host"`
+	Port                int    `mapstructure:"port" json:"port" yaml:"port"`
+	Username            string `mapstructure:"username" json:"username" yaml:"username"`
+	Password            string `mapstructure:"password" json:"password" yaml:"password"`
+	Vhost               string `mapstructure:"vhost" json:"vhost" yaml:"vhost"`
+	ReconnectInterval   int    `mapstructure:"reconnect_interval" json:"reconnect_interval" yaml:"reconnect_interval"` // 断线重连间隔（秒）
+	PublishBuffer       int    `mapstructure:"publish_buffer" json:"publish_buffer" yaml:"publish_buffer"`             // 断线期间暂存的待发布消息上限，超出后发布直接失败
+	PublishTimeout      int    `mapstructure:"publish_timeout" json:"publish_timeout" yaml:"publish_timeout"`          // 等待 broker 确认的超时（秒）
 }
 
-type RabbitMQConfig struct {
-	Host              string `yaml:"host"`
-	Port              int    `yaml:"port"`
-	Username          string `yaml:"username"`
-	Password          string `yaml:"password"`
-	ReconnectInterval int    `yaml:"reconnect_interval"`
-	Vhost             string `yaml:"vhost"`
-}
+// ConsumerConfigPath 消费者配置文件
+const ConsumerConfigPath = "./config/yaml/consumer.yaml"
 
 type ConsumerConfig struct {
-	Queue       string `yaml:"queue"`
-	Concurrency int    `yaml:"concurrency"`
-	Handler     string `yaml:"handler"`
+	Queue         string   `yaml:"queue"`
+	Concurrency   int      `yaml:"concurrency"`
+	Prefetch      int      `yaml:"prefetch"` // 每个消费者的未确认消息上限
+	Handler       string   `yaml:"handler"`
+	RetryTimes    int      `yaml:"retry_times"`     // 处理失败后的重试次数，用尽后进入死信队列，-1 表示不重试
+	RetryDelay    int      `yaml:"retry_delay"`     // 首次重试的延迟（秒），之后每次翻倍
+	MaxRetryDelay int      `yaml:"max_retry_delay"` // 重试延迟上限（秒）
+	Timeout       int      `yaml:"timeout"`         // 单条消息的处理超时（秒）
+	DedupTTL      int      `yaml:"dedup_ttl"`       // 幂等中间件保留已处理记录的时长（秒）
+	Middlewares   []string `yaml:"middlewares"`     // 消费者中间件，配置后替换全局中间件
 }
 
 type AppConfig struct {
-	RabbitMQ  RabbitMQConfig   `yaml:"rabbitmq"`
-	Consumers []ConsumerConfig `yaml:"consumers"`
+	Middlewares []string         `yaml:"middlewares"` // 全局消费者中间件，按顺序从外到内包装 handler
+	Consumers   []ConsumerConfig `yaml:"consumers"`
 }
 
 func LoadConfig(path string) (*AppConfig, error) {
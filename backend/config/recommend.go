@@ -0,0 +1,8 @@
+package config
+
+type Recommend struct {
+	TopK           int   `mapstructure:"top_k" json:"top_k" yaml:"top_k"`                                  // 每个 mod 保留的相关 mod 数量
+	Interval       int64 `mapstructure:"interval" json:"interval" yaml:"interval"`                         // 离线计算间隔（秒）
+	LookbackDays   int   `mapstructure:"lookback_days" json:"lookback_days" yaml:"lookback_days"`          // 参与计算的下载记录天数，更早的下载汇总记录在计算时清理
+	MinCoDownloads int   `mapstructure:"min_co_downloads" json:"min_co_downloads" yaml:"min_co_downloads"` // 共同下载用户数下限，低于该值不推荐
+}
@@ -0,0 +1,10 @@
+package config
+
+type Sms struct {
+	Driver         string `mapstructure:"driver" json:"driver" yaml:"driver"`                            // console（只写日志，本地开发使用）
+	CodeLength     int    `mapstructure:"code_length" json:"code_length" yaml:"code_length"`             // 验证码位数
+	CodeTtl        int64  `mapstructure:"code_ttl" json:"code_ttl" yaml:"code_ttl"`                      // 验证码有效期（秒）
+	MaxAttempts    int64  `mapstructure:"max_attempts" json:"max_attempts" yaml:"max_attempts"`          // 单个验证码最多校验次数，超过后作废
+	ResendCooldown int64  `mapstructure:"resend_cooldown" json:"resend_cooldown" yaml:"resend_cooldown"` // 同一手机号两次发送的最小间隔（秒）
+	DailyLimit     int64  `mapstructure:"daily_limit" json:"daily_limit" yaml:"daily_limit"`             // 同一手机号每天最多发送次数
+}
@@ -0,0 +1,7 @@
+package config
+
+type Stats struct {
+	Hourly       bool `mapstructure:"hourly" json:"hourly" yaml:"hourly"`                         // 是否额外记录小时粒度统计
+	MaxDayRange  int  `mapstructure:"max_day_range" json:"max_day_range" yaml:"max_day_range"`    // 按天查询的最大跨度（天）
+	MaxHourRange int  `mapstructure:"max_hour_range" json:"max_hour_range" yaml:"max_hour_range"` // 按小时查询的最大跨度（小时）
+}
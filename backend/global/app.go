@@ -1,6 +1,9 @@
 package global
 
 import (
+	"gin-web/app/ampq/broker"
+	"gin-web/app/mailer"
+	"gin-web/app/sms"
 	"gin-web/config"
 	"github.com/go-redis/redis/v8"
 
@@ -15,6 +18,9 @@ type Application struct {
 	Log         *zap.Logger
 	DB          *gorm.DB
 	Redis       *redis.Client
+	Mailer      mailer.Mailer
+	Sms         sms.Sender
+	Broker      broker.Broker
 }
 
 var App = new(Application)
@@ -6,13 +6,17 @@ type CustomError struct {
 }
 
 type CustomErrors struct {
-	BusinessError CustomError
-	ValidateError CustomError
-	TokenError    CustomError
+	BusinessError  CustomError
+	ValidateError  CustomError
+	TokenError     CustomError
+	ForbiddenError CustomError
+	MfaRequired    CustomError
 }
 
 var Errors = CustomErrors{
-	BusinessError: CustomError{40000, "业务错误"},
-	ValidateError: CustomError{42200, "请求参数错误"},
-	TokenError:    CustomError{40100, "登录授权失效"},
+	BusinessError:  CustomError{40000, "业务错误"},
+	ValidateError:  CustomError{42200, "请求参数错误"},
+	TokenError:     CustomError{40100, "登录授权失效"},
+	ForbiddenError: CustomError{40300, "无权限访问"},
+	MfaRequired:    CustomError{40301, "请先开启两步验证"},
 }
@@ -3,6 +3,7 @@ package main
 import (
 	"gin-web/bootstrap"
 	"gin-web/global"
+	"os"
 )
 
 func main() {
@@ -24,7 +25,23 @@ func main() {
 	bootstrap.InitializeValidator()
 	// 初始化Redis
 	global.App.Redis = bootstrap.InitializeRedis()
-	// go bootstrap.InitRabbitmq() // 临时禁用RabbitMQ
+	// 初始化邮件发送
+	global.App.Mailer = bootstrap.InitializeMailer()
+	// 初始化短信发送
+	global.App.Sms = bootstrap.InitializeSms()
+	// 初始化消息中间件（连接在首次使用时建立）
+	global.App.Broker = bootstrap.InitializeBroker()
+	defer global.App.Broker.Close()
+	// 执行命令行子命令（如 create-admin），执行完直接退出
+	if bootstrap.RunCommand(os.Args[1:]) {
+		return
+	}
+	// 启动定时任务
+	bootstrap.InitializeSchedule()
+	// 启动消息消费者，服务关闭时先停止消费并等待处理中的消息完成，再关闭连接
+	if consumerManager := bootstrap.InitRabbitmq(); consumerManager != nil {
+		defer consumerManager.Stop()
+	}
 	bootstrap.RunServer()
 
 }
@@ -0,0 +1,42 @@
+package routes
+
+import (
+	app "gin-web/app/controllers"
+	"gin-web/app/middleware"
+	"gin-web/app/models"
+	"gin-web/app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetAdminGroupRoutes 定义管理后台相关的路由，使用独立的 admin guard，前台 token 无法访问
+func SetAdminGroupRoutes(router *gin.RouterGroup) {
+	router.POST("/admin/auth/login", app.AdminLogin)
+	router.POST("/admin/auth/refresh", app.AdminRefresh)
+	router.POST("/admin/auth/mfa/verify", app.AdminMfaLogin)       // 登录第二步
+	router.POST("/admin/auth/mfa/setup", app.AdminMfaLoginSetup)   // 首次登录绑定验证器
+	router.POST("/admin/auth/mfa/enable", app.AdminMfaLoginEnable) // 绑定后校验验证码并完成登录
+
+	// 未开启两步验证的账号只能登出
+	router.POST("/admin/auth/logout", middleware.JWTAuth(services.AdminGuardName), app.AdminLogout)
+
+	// 后台全部接口要求已开启两步验证
+	adminRouter := router.Group("/admin").Use(
+		middleware.JWTAuth(services.AdminGuardName),
+		middleware.RequireMfa(services.AdminGuardName),
+	)
+	{
+		adminRouter.POST("/auth/info", app.AdminInfo)
+		adminRouter.GET("/auth/mfa", app.MfaStatus)                        // 两步验证状态
+		adminRouter.POST("/auth/mfa/recovery-codes", app.MfaRecoveryCodes) // 重新生成恢复码
+
+		// 后台账号按所分配角色的权限访问各接口
+		adminRouter.GET("/roles", middleware.RequireAdminPermission(models.PermissionRoleAssign), app.Roles)                     // 前台角色列表
+		adminRouter.PUT("/users/:id/roles", middleware.RequireAdminPermission(models.PermissionRoleAssign), app.AssignUserRoles) // 分配前台用户角色
+		adminRouter.POST("/login-locks/unlock", middleware.RequireAdminPermission(models.PermissionUserManage), app.UnlockLogin) // 解除登录锁定
+
+		adminRouter.GET("/dead-letters", middleware.RequireAdminPermission(models.PermissionQueueManage), app.DeadLetters)               // 查看死信队列
+		adminRouter.POST("/dead-letters/replay", middleware.RequireAdminPermission(models.PermissionQueueManage), app.ReplayDeadLetters) // 重放死信
+		adminRouter.GET("/consumer-metrics", middleware.RequireAdminPermission(models.PermissionQueueManage), app.ConsumerMetrics)       // 消费统计
+	}
+}
@@ -24,4 +24,13 @@ func SetApiGroupRoutes(router *gin.RouterGroup) {
 
 	// 注册 Mod 相关的路由
 	SetModGroupRoutes(router)
+
+	// 注册作者相关的路由
+	SetAuthorGroupRoutes(router)
+
+	// 注册站内通知相关的路由
+	SetNotificationGroupRoutes(router)
+
+	// 注册管理后台相关的路由
+	SetAdminGroupRoutes(router)
 }
@@ -4,6 +4,7 @@ import (
 	"gin-web/app/common/request"
 	app "gin-web/app/controllers"
 	"gin-web/app/middleware"
+	"gin-web/app/models"
 	"gin-web/app/services"
 	"net/http"
 
@@ -27,10 +28,53 @@ func SetAuthGroupRoutes(router *gin.RouterGroup) {
 
 	router.POST("/auth/register", app.Register)
 	router.POST("/auth/login", app.Login)
+	router.POST("/auth/refresh", app.Refresh)
+	router.POST("/auth/mfa/verify", app.MfaLogin)
+	router.POST("/auth/mfa/setup", app.MfaLoginSetup)   // 角色要求两步验证但尚未开启时，登录过程中绑定
+	router.POST("/auth/mfa/enable", app.MfaLoginEnable) // 绑定后校验验证码并完成登录
+	router.POST("/auth/sms/code", app.SmsLoginCode)
+	router.POST("/auth/sms/login", app.SmsLogin)
+	router.POST("/auth/email/verify", app.VerifyEmail)
+	router.POST("/auth/password/forgot", app.ForgotPassword)
+	router.POST("/auth/password/reset", app.ResetPassword)
+
+	// 第三方登录（OpenID Connect），已登录时发起授权则绑定到当前用户
+	router.GET("/auth/oauth/providers", app.OauthProviders)
+	router.GET("/auth/oauth/:provider/authorize", middleware.JWTAuthOptional(services.AppGuardName), app.OauthAuthorize)
+	router.POST("/auth/oauth/:provider/callback", middleware.JWTAuthOptional(services.AppGuardName), app.OauthCallback)
+
+	// 个人访问令牌（read 范围）也可获取用户信息，便于 CI 校验令牌
+	router.POST("/auth/info", middleware.JWTOrTokenAuth(models.TokenScopeRead), app.Info)
 
 	authRouter := router.Group("").Use(middleware.JWTAuth(services.AppGuardName))
 	{
-		authRouter.POST("/auth/info", app.Info)
 		authRouter.POST("/auth/logout", app.Logout)
+		authRouter.POST("/auth/email/resend", app.ResendVerifyEmail)
+
+		authRouter.PUT("/user/profile", app.UpdateProfile)                   // 修改个人资料
+		authRouter.POST("/user/verify-code", app.SendIdentityCode)           // 发送身份确认验证码
+		authRouter.PUT("/user/password", app.ChangePassword)                 // 修改密码
+		authRouter.POST("/user/mobile/code", app.ChangeMobileCode)           // 发送更换手机号验证码
+		authRouter.PUT("/user/mobile", app.ChangeMobile)                     // 更换手机号
+		authRouter.POST("/user/account/deletion", app.DeleteAccount)         // 申请注销账号
+		authRouter.DELETE("/user/account/deletion", app.CancelDeleteAccount) // 撤销注销申请
+		authRouter.GET("/user/export", app.ExportAccount)                    // 导出个人数据
+
+		authRouter.GET("/user/identities", app.Identities)                  // 已绑定的第三方账号
+		authRouter.DELETE("/user/identities/:provider", app.UnlinkIdentity) // 解绑第三方账号
+
+		authRouter.GET("/user/mfa", app.MfaStatus)                        // 两步验证状态
+		authRouter.POST("/user/mfa/setup", app.MfaSetup)                  // 生成绑定二维码
+		authRouter.POST("/user/mfa/enable", app.MfaEnable)                // 开启两步验证
+		authRouter.POST("/user/mfa/disable", app.MfaDisable)              // 关闭两步验证
+		authRouter.POST("/user/mfa/recovery-codes", app.MfaRecoveryCodes) // 重新生成恢复码
+
+		authRouter.GET("/user/sessions", app.Sessions)                           // 登录会话（设备）列表
+		authRouter.DELETE("/user/sessions/:id", app.RevokeSession)               // 下线指定会话
+		authRouter.POST("/user/sessions/revoke-others", app.RevokeOtherSessions) // 下线其他全部会话
+
+		authRouter.GET("/user/tokens", app.PersonalAccessTokens)             // 个人访问令牌列表
+		authRouter.POST("/user/tokens", app.CreatePersonalAccessToken)       // 创建个人访问令牌
+		authRouter.DELETE("/user/tokens/:id", app.RevokePersonalAccessToken) // 吊销个人访问令牌
 	}
 }
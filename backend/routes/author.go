@@ -0,0 +1,34 @@
+package routes
+
+import (
+	app "gin-web/app/controllers"
+	"gin-web/app/middleware"
+	"gin-web/app/models"
+	"gin-web/app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetAuthorGroupRoutes 定义作者相关的路由
+func SetAuthorGroupRoutes(router *gin.RouterGroup) {
+	authorController := &app.AuthorController{}
+	{
+		router.GET("/authors/:id", authorController.Profile) // 作者主页
+	}
+
+	authRouter := router.Group("").Use(middleware.JWTAuth(services.AppGuardName))
+	{
+		authRouter.POST("/authors/claim", authorController.Claim)    // 申请认领历史作者署名
+		authRouter.GET("/authors/claims", authorController.MyClaims) // 我的认领申请
+	}
+
+	// 认领需版主核实所有权证明后审核通过，可使用 admin 范围的个人访问令牌
+	moderateRouter := router.Group("/moderation").Use(
+		middleware.JWTOrTokenAuth(models.TokenScopeAdmin),
+		middleware.RequirePermission(models.PermissionModModerate),
+	)
+	{
+		moderateRouter.GET("/author-claims", authorController.Claims)                  // 认领申请列表
+		moderateRouter.POST("/author-claims/:id/review", authorController.ReviewClaim) // 审核认领申请
+	}
+}
@@ -2,6 +2,9 @@ package routes
 
 import (
 	app "gin-web/app/controllers"
+	"gin-web/app/middleware"
+	"gin-web/app/models"
+	"gin-web/app/services"
 
 	"github.com/gin-gonic/gin"
 )
@@ -10,10 +13,43 @@ import (
 func SetModGroupRoutes(router *gin.RouterGroup) {
 	modController := &app.ModController{}
 	{
-		router.GET("/mods/search", modController.Search)         // 搜索mod
-		router.GET("/mods/:id", modController.Detail)            // 获取mod详情
-		router.GET("/mods/:id/download", modController.Download) // 下载mod
-		router.GET("/games", modController.Games)                // 获取游戏列表
-		router.GET("/categories", modController.Categories)      // 获取分类列表
+		router.GET("/mods/search", modController.Search)                       // 搜索mod
+		router.GET("/mods/:id/stats", modController.Stats)                     // mod浏览、下载统计
+		router.GET("/mods/:id/recommendations", modController.Recommendations) // 相关mod推荐
+		router.GET("/mods/:id/comments", modController.Comments)               // mod评论列表
+		router.GET("/games", modController.Games)                              // 获取游戏列表
+		router.GET("/categories", modController.Categories)                    // 获取分类列表
+	}
+
+	// 浏览、下载计数按登录用户去重，游客按IP去重
+	visitorRouter := router.Group("").Use(middleware.JWTAuthOptional(services.AppGuardName))
+	{
+		visitorRouter.GET("/mods/:id", modController.Detail)            // 获取mod详情
+		visitorRouter.GET("/mods/:id/download", modController.Download) // 下载mod
+	}
+
+	// 发布新版本，CI 可使用 publish 范围的个人访问令牌
+	publishRouter := router.Group("").Use(
+		middleware.JWTOrTokenAuth(models.TokenScopePublish),
+		middleware.RequirePermission(models.PermissionModPublish),
+	)
+	{
+		publishRouter.POST("/mods/:id/releases", modController.PublishRelease) // 发布mod新版本
+	}
+
+	authRouter := router.Group("").Use(middleware.JWTAuth(services.AppGuardName))
+	{
+		authRouter.POST("/mods/:id/comments", modController.CreateComment) // 发表评论
+		authRouter.POST("/mods/:id/follow", modController.Follow)          // 关注mod
+		authRouter.DELETE("/mods/:id/follow", modController.Unfollow)      // 取消关注
+	}
+
+	// 删除评论会通知评论作者，可使用 admin 范围的个人访问令牌
+	moderateRouter := router.Group("/moderation").Use(
+		middleware.JWTOrTokenAuth(models.TokenScopeAdmin),
+		middleware.RequirePermission(models.PermissionCommentModerate),
+	)
+	{
+		moderateRouter.DELETE("/comments/:id", modController.DeleteComment) // 删除评论
 	}
 }
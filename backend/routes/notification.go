@@ -0,0 +1,24 @@
+package routes
+
+import (
+	app "gin-web/app/controllers"
+	"gin-web/app/middleware"
+	"gin-web/app/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetNotificationGroupRoutes 定义站内通知相关的路由
+func SetNotificationGroupRoutes(router *gin.RouterGroup) {
+	notificationController := &app.NotificationController{}
+
+	authRouter := router.Group("/notifications").Use(middleware.JWTAuth(services.AppGuardName))
+	{
+		authRouter.GET("", notificationController.List)                          // 通知列表
+		authRouter.GET("/unread-count", notificationController.UnreadCount)      // 未读数
+		authRouter.POST("/:id/read", notificationController.MarkRead)            // 标记已读
+		authRouter.POST("/read-all", notificationController.MarkAllRead)         // 全部已读
+		authRouter.GET("/preferences", notificationController.Preferences)       // 通知偏好
+		authRouter.PUT("/preferences", notificationController.UpdatePreferences) // 更新通知偏好
+	}
+}
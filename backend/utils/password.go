@@ -0,0 +1,186 @@
+package utils
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"gin-web/config"
+	"strings"
+	"unicode"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 密码哈希算法
+const (
+	PasswordAlgorithmBcrypt   = "bcrypt"
+	PasswordAlgorithmArgon2id = "argon2id"
+)
+
+const (
+	defaultBcryptCost        = 12
+	defaultArgon2Memory      = 64 * 1024
+	defaultArgon2Iterations  = 3
+	defaultArgon2Parallelism = 2
+	argon2SaltLength         = 16
+	argon2KeyLength          = 32
+
+	defaultPasswordMinLength      = 8
+	defaultPasswordMinCharClasses = 2
+	// bcrypt 只处理前 72 字节，超出部分直接拒绝
+	passwordMaxLength = 72
+)
+
+// argon2Params argon2id 参数
+type argon2Params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+}
+
+// passwordHashConfig 补全默认值后的哈希配置
+func passwordHashConfig(conf config.Password) (algorithm string, bcryptCost int, params argon2Params) {
+	algorithm = conf.Algorithm
+	if algorithm != PasswordAlgorithmArgon2id {
+		algorithm = PasswordAlgorithmBcrypt
+	}
+	bcryptCost = conf.BcryptCost
+	if bcryptCost < bcrypt.MinCost || bcryptCost > bcrypt.MaxCost {
+		bcryptCost = defaultBcryptCost
+	}
+	params = argon2Params{conf.Argon2Memory, conf.Argon2Iterations, conf.Argon2Parallelism}
+	if params.memory == 0 {
+		params.memory = defaultArgon2Memory
+	}
+	if params.iterations == 0 {
+		params.iterations = defaultArgon2Iterations
+	}
+	if params.parallelism == 0 {
+		params.parallelism = defaultArgon2Parallelism
+	}
+	return
+}
+
+// PasswordHash 按配置计算密码哈希，结果为自描述格式：bcrypt 为 $2a$...，argon2id 为 PHC 格式 $argon2id$v=19$m=,t=,p=$salt$hash
+func PasswordHash(password string, conf config.Password) (string, error) {
+	algorithm, bcryptCost, params := passwordHashConfig(conf)
+	if algorithm == PasswordAlgorithmArgon2id {
+		salt := randBytes(argon2SaltLength)
+		key := argon2.IDKey([]byte(password), salt, params.iterations, params.memory, params.parallelism, argon2KeyLength)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version,
+			params.memory, params.iterations, params.parallelism,
+			base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// PasswordVerify 校验密码，根据哈希前缀识别算法
+func PasswordVerify(password string, encoded string) bool {
+	if strings.HasPrefix(encoded, "$argon2id$") {
+		params, salt, key, err := parseArgon2Hash(encoded)
+		if err != nil {
+			return false
+		}
+		actual := argon2.IDKey([]byte(password), salt, params.iterations, params.memory, params.parallelism, uint32(len(key)))
+		return subtle.ConstantTimeCompare(actual, key) == 1
+	}
+	return bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password)) == nil
+}
+
+// PasswordNeedsRehash 哈希算法或参数与当前配置不一致时需要重新计算
+func PasswordNeedsRehash(encoded string, conf config.Password) bool {
+	algorithm, bcryptCost, params := passwordHashConfig(conf)
+	if strings.HasPrefix(encoded, "$argon2id$") {
+		if algorithm != PasswordAlgorithmArgon2id {
+			return true
+		}
+		current, _, _, err := parseArgon2Hash(encoded)
+		return err != nil || current != params
+	}
+	if algorithm != PasswordAlgorithmBcrypt {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != bcryptCost
+}
+
+// parseArgon2Hash 解析 PHC 格式的 argon2id 哈希
+func parseArgon2Hash(encoded string) (params argon2Params, salt []byte, key []byte, err error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != PasswordAlgorithmArgon2id {
+		err = errors.New("invalid argon2id hash")
+		return
+	}
+	var version int
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return
+	}
+	if version != argon2.Version {
+		err = errors.New("unsupported argon2 version")
+		return
+	}
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism); err != nil {
+		return
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err == nil && len(key) == 0 {
+		err = errors.New("invalid argon2id hash")
+	}
+	return
+}
+
+// passwordPolicy 补全默认值后的密码策略
+func passwordPolicy(conf config.Password) (minLength int, minCharClasses int) {
+	minLength, minCharClasses = conf.MinLength, conf.MinCharClasses
+	if minLength <= 0 {
+		minLength = defaultPasswordMinLength
+	}
+	if minCharClasses <= 0 {
+		minCharClasses = defaultPasswordMinCharClasses
+	}
+	if minCharClasses > 4 {
+		minCharClasses = 4
+	}
+	return
+}
+
+// PasswordPolicyHint 密码策略说明
+func PasswordPolicyHint(conf config.Password) string {
+	minLength, minCharClasses := passwordPolicy(conf)
+	return fmt.Sprintf("密码长度需为 %d-%d 位，且至少包含小写字母、大写字母、数字、符号中的 %d 类", minLength, passwordMaxLength, minCharClasses)
+}
+
+// CheckPasswordPolicy 校验密码强度
+func CheckPasswordPolicy(password string, conf config.Password) error {
+	minLength, minCharClasses := passwordPolicy(conf)
+	if len([]rune(password)) < minLength || len(password) > passwordMaxLength {
+		return errors.New(PasswordPolicyHint(conf))
+	}
+
+	var lower, upper, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+	if lower+upper+digit+symbol < minCharClasses {
+		return errors.New(PasswordPolicyHint(conf))
+	}
+	return nil
+}
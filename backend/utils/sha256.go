@@ -0,0 +1,11 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+func Sha256(str []byte) string {
+	h := sha256.Sum256(str)
+	return hex.EncodeToString(h[:])
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	crand "crypto/rand"
+	"encoding/base64"
 	"math/rand"
 	"path/filepath"
 	"strings"
@@ -17,6 +19,52 @@ func RandString(len int) string {
 	return string(bytes)
 }
 
+// RandToken 生成密码学安全的随机串（base64url），用于 refresh token 等凭证
+func RandToken(byteLen int) string {
+	return base64.RawURLEncoding.EncodeToString(randBytes(byteLen))
+}
+
+// randBytes 密码学安全的随机字节
+func randBytes(n int) []byte {
+	b := make([]byte, n)
+	if _, err := crand.Read(b); err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// RandDigits 生成密码学安全的数字验证码
+func RandDigits(length int) string {
+	b := make([]byte, length)
+	if _, err := crand.Read(b); err != nil {
+		panic(err)
+	}
+	for i := range b {
+		// 256 不能被 10 整除，丢弃高位区间避免分布偏差
+		for b[i] >= 250 {
+			if _, err := crand.Read(b[i : i+1]); err != nil {
+				panic(err)
+			}
+		}
+		b[i] = '0' + b[i]%10
+	}
+	return string(b)
+}
+
+// MaskAccount 脱敏账号（手机号、用户名），用于日志
+func MaskAccount(account string) string {
+	switch {
+	case len(account) >= 7:
+		return account[:3] + "****" + account[len(account)-4:]
+	case len(account) > 2:
+		return account[:1] + "***" + account[len(account)-1:]
+	case account == "":
+		return ""
+	default:
+		return "***"
+	}
+}
+
 func GetConfigKeyFromFilename(filePath string) string {
 	base := filepath.Base(filePath)                      // 获取 logConsumer.go
 	name := strings.TrimSuffix(base, filepath.Ext(base)) // 去除扩展名 -> logConsumer